@@ -1,16 +1,87 @@
 package agent
 
-import "nyxze/fayth/model"
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"nyxze/fayth/model"
+	"nyxze/fayth/trace"
+)
 
 type Agent struct {
-	name  string
-	model model.Model // The underlying model used for performing task
+	name   string
+	model  model.Model  // The underlying model used for performing task
+	logger *slog.Logger // Logger for agent steps and tool executions
+	tracer trace.Tracer // Tracer recording agent steps and tool executions
+}
+
+// Tool is an action an agent can perform on behalf of the model.
+type Tool interface {
+	// Name identifies the tool, as exposed to the model
+	Name() string
+	// Call executes the tool with the given input and returns its output
+	Call(ctx context.Context, input string) (string, error)
+}
+
+// Option configures an [Agent]
+type Option func(*Agent)
+
+// WithLogger sets the logger used by the agent.
+func WithLogger(logger *slog.Logger) Option {
+	return func(a *Agent) {
+		if logger != nil {
+			a.logger = logger
+		}
+	}
+}
 
+// WithTracer sets the tracer used to record agent steps and tool executions.
+func WithTracer(tracer trace.Tracer) Option {
+	return func(a *Agent) {
+		if tracer != nil {
+			a.tracer = tracer
+		}
+	}
 }
 
-func NewAgent(name string, model model.Model) *Agent {
-	return &Agent{
-		name:  name,
-		model: model,
+func NewAgent(name string, model model.Model, opts ...Option) *Agent {
+	a := &Agent{
+		name:   name,
+		model:  model,
+		logger: slog.New(slog.DiscardHandler),
+		tracer: trace.Noop(),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.logger = a.logger.With("agent", name)
+	return a
+}
+
+// ExecuteTool runs tool with input, recording a span and a log record for the execution.
+func (a *Agent) ExecuteTool(ctx context.Context, tool Tool, input string) (string, error) {
+	ctx, span := a.tracer.Start(ctx, trace.OperationExecuteTool+" "+tool.Name(),
+		trace.String(trace.GenAIOperationName, trace.OperationExecuteTool),
+		trace.String(trace.GenAIToolName, tool.Name()),
+		trace.String(trace.GenAIAgentName, a.name),
+	)
+	defer span.End()
+
+	start := time.Now()
+	out, err := tool.Call(ctx, input)
+	if err != nil {
+		span.RecordError(err)
+		a.logger.ErrorContext(ctx, "agent: tool execution failed",
+			"tool", tool.Name(),
+			"latency", time.Since(start),
+			"error", err,
+		)
+		return "", err
 	}
+	a.logger.InfoContext(ctx, "agent: tool executed",
+		"tool", tool.Name(),
+		"latency", time.Since(start),
+	)
+	return out, nil
 }
@@ -0,0 +1,281 @@
+// Command fayth sends prompts to a model from the command line.
+//
+// The prompt is read from the arguments, from stdin, or both, and the answer is
+// streamed to stdout:
+//
+//	fayth "Tell me a joke"
+//	git diff | fayth --system "You review code" "Review this change"
+//	fayth --image cat.png "What is in this picture?"
+//
+// Without a prompt on a terminal, or with --interactive, fayth starts a chat
+// whose history is kept in a file across sessions. The OpenAI client is configured
+// from the OPENAI_API_KEY and OPENAI_BASE_URL environment variables.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"nyxze/fayth/memory"
+	"nyxze/fayth/model"
+	"nyxze/fayth/model/openai"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	stat, err := os.Stdin.Stat()
+	c := &cli{
+		stdin:    os.Stdin,
+		stdout:   os.Stdout,
+		stderr:   os.Stderr,
+		terminal: err == nil && stat.Mode()&os.ModeCharDevice != 0,
+		newModel: func() (model.Model, error) { return openai.New() },
+	}
+	if err := c.run(ctx, os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "fayth:", err)
+		os.Exit(1)
+	}
+}
+
+// config holds the command-line flags.
+type config struct {
+	model       string
+	temperature *float64
+	json        bool
+	system      string
+	images      []string
+	interactive bool
+	history     string
+}
+
+// cli runs the command against its streams, so it can be driven by tests.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	// terminal reports whether stdin is an interactive terminal
+	terminal bool
+	newModel func() (model.Model, error)
+}
+
+func (c *cli) parse(args []string) (config, []string, error) {
+	cfg := config{}
+	fs := flag.NewFlagSet("fayth", flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(c.stderr, "Usage: fayth [flags] [prompt...]")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&cfg.model, "model", os.Getenv("OPENAI_MODEL"), "model `name`, defaults to $OPENAI_MODEL or the client default")
+	fs.Func("temperature", "sampling `temperature`, between 0 and 2", func(s string) error {
+		t, err := strconv.ParseFloat(s, 64)
+		cfg.temperature = &t
+		return err
+	})
+	fs.BoolVar(&cfg.json, "json", false, "ask the model for a JSON object")
+	fs.StringVar(&cfg.system, "system", "", "system `prompt`")
+	fs.Func("image", "attach an image `file or url` to the prompt, can be repeated", func(s string) error {
+		cfg.images = append(cfg.images, s)
+		return nil
+	})
+	fs.BoolVar(&cfg.interactive, "interactive", false, "start an interactive chat")
+	fs.BoolVar(&cfg.interactive, "i", false, "shorthand for --interactive")
+	fs.StringVar(&cfg.history, "history", defaultHistory(), "chat history `file`")
+	if err := fs.Parse(args); err != nil {
+		return cfg, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+// defaultHistory returns the history file in the user configuration directory.
+func defaultHistory() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".fayth_history.json"
+	}
+	return filepath.Join(dir, "fayth", "history.json")
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	cfg, args, err := c.parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	images, err := loadImages(cfg.images)
+	if err != nil {
+		return err
+	}
+	m, err := c.newModel()
+	if err != nil {
+		return err
+	}
+
+	if cfg.interactive || (len(args) == 0 && c.terminal) {
+		return c.chat(ctx, m, cfg, images)
+	}
+
+	prompt := strings.Join(args, " ")
+	if !c.terminal {
+		input, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return err
+		}
+		prompt = strings.TrimSpace(strings.Join([]string{prompt, string(input)}, "\n\n"))
+	}
+	if prompt == "" {
+		return errors.New("no prompt, pass it as arguments or on stdin")
+	}
+	messages := append(systemMessages(cfg), userMessage(prompt, images))
+	_, err = c.stream(ctx, m, messages, cfg)
+	return err
+}
+
+// chat runs the interactive loop, saving the history after every answer.
+func (c *cli) chat(ctx context.Context, m model.Model, cfg config, images []model.ContentFunc) error {
+	if err := os.MkdirAll(filepath.Dir(cfg.history), 0o755); err != nil {
+		return err
+	}
+	mem := memory.NewFileMemory(cfg.history)
+	history, err := mem.Load()
+	if err != nil {
+		return fmt.Errorf("loading history %s: %w", cfg.history, err)
+	}
+	if len(history) > 0 {
+		fmt.Fprintf(c.stderr, "Resuming %d messages from %s, /reset to start over.\n", len(history), cfg.history)
+	}
+
+	scanner := bufio.NewScanner(c.stdin)
+	for {
+		fmt.Fprint(c.stderr, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(c.stderr)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		switch line {
+		case "":
+			continue
+		case "/exit", "/quit":
+			return nil
+		case "/reset":
+			history = nil
+			if err := mem.Save(history); err != nil {
+				fmt.Fprintln(c.stderr, "error: saving history:", err)
+			}
+			continue
+		}
+
+		turn := userMessage(line, images)
+		answer, err := c.stream(ctx, m, append(systemMessages(cfg), append(history, turn)...), cfg)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintln(c.stderr, "error:", err)
+			continue
+		}
+		// Images are only attached to the first turn
+		images = nil
+		history = append(history, turn, answer)
+		if err := mem.Save(history); err != nil {
+			fmt.Fprintln(c.stderr, "error: saving history:", err)
+		}
+	}
+}
+
+// stream prints the first choice generated for messages as it arrives and returns it.
+func (c *cli) stream(ctx context.Context, m model.Model, messages []model.Message, cfg config) (model.Message, error) {
+	opts := []model.ModelOption{model.WithStream(true)}
+	if cfg.model != "" {
+		opts = append(opts, model.WithModel(cfg.model))
+	}
+	if cfg.temperature != nil {
+		opts = append(opts, model.WithTemperature(*cfg.temperature))
+	}
+	if cfg.json {
+		opts = append(opts, model.WithJSONMode())
+	}
+
+	gen, err := m.Generate(ctx, messages, opts...)
+	if err != nil {
+		return model.Message{}, err
+	}
+	var (
+		chunks []model.Message
+		last   string
+	)
+	for msg := range gen.Messages() {
+		if msg.Index != 0 {
+			continue
+		}
+		chunks = append(chunks, msg)
+		if text := msg.Text(); text != "" {
+			fmt.Fprint(c.stdout, text)
+			last = text
+		}
+	}
+	if last != "" && !strings.HasSuffix(last, "\n") {
+		fmt.Fprintln(c.stdout)
+	}
+	if err := gen.Error(); err != nil {
+		return model.Message{}, err
+	}
+	merged := model.MergeChunks(chunks)
+	if len(merged) == 0 {
+		return model.Message{}, errors.New("empty answer")
+	}
+	answer := merged[0]
+	answer.Role = model.Assistant
+	if err := answer.Refusal(); err != nil {
+		fmt.Fprintln(c.stderr, err)
+	}
+	return answer, nil
+}
+
+func systemMessages(cfg config) []model.Message {
+	if cfg.system == "" {
+		return nil
+	}
+	return []model.Message{model.NewTextMessage(model.System, cfg.system)}
+}
+
+func userMessage(prompt string, images []model.ContentFunc) model.Message {
+	return model.NewMessage(model.User, append([]model.ContentFunc{model.WithTextContent(prompt)}, images...)...)
+}
+
+// loadImages reads image files, urls are passed through.
+func loadImages(paths []string) ([]model.ContentFunc, error) {
+	images := make([]model.ContentFunc, 0, len(paths))
+	for _, p := range paths {
+		if strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://") {
+			images = append(images, model.WithImageURL(p))
+			continue
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		mimeType := mime.TypeByExtension(filepath.Ext(p))
+		if mimeType == "" {
+			mimeType = http.DetectContentType(data)
+		}
+		images = append(images, model.WithImageContent(mimeType, data))
+	}
+	return images, nil
+}
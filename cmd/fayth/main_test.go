@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nyxze/fayth/model"
+)
+
+// recordingModel streams a numbered answer word by word and records the requests
+type recordingModel struct {
+	requests [][]model.Message
+	options  []model.ModelOptions
+}
+
+func (r *recordingModel) Generate(_ context.Context, messages []model.Message, opts ...model.ModelOption) (*model.Generation, error) {
+	r.requests = append(r.requests, messages)
+	r.options = append(r.options, model.MergeOptions(model.ModelOptions{}, opts...))
+	answer := []string{"Answer ", strings.Repeat("i", len(r.requests))}
+	return model.NewGenerationWithStream(func(yield func(model.Message) bool) {
+		for _, word := range answer {
+			if !yield(model.NewTextMessage(model.Assistant, word)) {
+				return
+			}
+		}
+	}), nil
+}
+
+func newTestCLI(stdin string, terminal bool) (*cli, *recordingModel, *bytes.Buffer) {
+	m := &recordingModel{}
+	out := &bytes.Buffer{}
+	return &cli{
+		stdin:    strings.NewReader(stdin),
+		stdout:   out,
+		stderr:   &bytes.Buffer{},
+		terminal: terminal,
+		newModel: func() (model.Model, error) { return m, nil },
+	}, m, out
+}
+
+func TestRun_Prompt(t *testing.T) {
+	image := filepath.Join(t.TempDir(), "cat.png")
+	if err := os.WriteFile(image, []byte("\x89PNG"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		terminal bool
+		prompt   string
+		images   int
+		check    func(model.ModelOptions) bool
+	}{
+		{"Arguments", []string{"Tell", "me", "a", "joke"}, "", true, "Tell me a joke", 0, nil},
+		{"Stdin", nil, "Summarize this\n", false, "Summarize this", 0, nil},
+		{"Arguments and stdin", []string{"Review this change"}, "diff --git\n", false, "Review this change\n\ndiff --git", 0, nil},
+		{
+			"Options",
+			[]string{"--model", "gpt-4.1", "--temperature", "0.2", "--json", "List colors"}, "", true, "List colors", 0,
+			func(o model.ModelOptions) bool {
+				return o.Model == "gpt-4.1" && o.Temperature == 0.2 && o.ResponseFormat.Type == "json_object" && o.Stream
+			},
+		},
+		{"Images", []string{"--image", image, "--image", "https://example.com/a.jpg", "What is this?"}, "", true, "What is this?", 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, m, out := newTestCLI(tt.stdin, tt.terminal)
+			if err := c.run(context.Background(), tt.args); err != nil {
+				t.Fatalf("run() unexpected error: %v", err)
+			}
+			if out.String() != "Answer i\n" {
+				t.Errorf("stdout = %q, want the streamed answer", out.String())
+			}
+			if len(m.requests) != 1 || len(m.requests[0]) != 1 {
+				t.Fatalf("requests = %+v, want a single user message", m.requests)
+			}
+			msg := m.requests[0][0]
+			if msg.Role != model.User || msg.Text() != tt.prompt || len(msg.Contents) != 1+tt.images {
+				t.Errorf("message = %+v, want prompt %q with %d images", msg, tt.prompt, tt.images)
+			}
+			if tt.check != nil && !tt.check(m.options[0]) {
+				t.Errorf("options = %+v", m.options[0])
+			}
+		})
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"--image", "missing.png", "What is this?"},
+		{"--temperature", "warm", "Hi"},
+	} {
+		c, _, _ := newTestCLI("", false)
+		if err := c.run(context.Background(), args); err == nil {
+			t.Errorf("run(%q) expected an error", args)
+		}
+	}
+}
+
+func TestRun_Chat(t *testing.T) {
+	history := filepath.Join(t.TempDir(), "fayth", "history.json")
+
+	c, m, out := newTestCLI("Hello\n\nHow are you?\n/exit\nIgnored\n", true)
+	if err := c.run(context.Background(), []string{"--system", "Be brief", "--history", history}); err != nil {
+		t.Fatalf("run() unexpected error: %v", err)
+	}
+	if out.String() != "Answer i\nAnswer ii\n" || len(m.requests) != 2 {
+		t.Fatalf("stdout = %q after %d requests", out.String(), len(m.requests))
+	}
+	second := m.requests[1]
+	if len(second) != 4 || second[0].Role != model.System || second[2].Role != model.Assistant || second[2].Text() != "Answer i" {
+		t.Errorf("second request = %+v, want the system prompt and the history", second)
+	}
+
+	// A new session resumes the saved history
+	c, m, _ = newTestCLI("And now?\n", false)
+	if err := c.run(context.Background(), []string{"-i", "--history", history}); err != nil {
+		t.Fatalf("run() unexpected error: %v", err)
+	}
+	if len(m.requests) != 1 || len(m.requests[0]) != 5 || m.requests[0][3].Text() != "Answer ii" {
+		t.Errorf("resumed request = %+v, want the 4 saved messages and the new one", m.requests)
+	}
+
+	c, m, _ = newTestCLI("/reset\nHi\n", true)
+	if err := c.run(context.Background(), []string{"--history", history}); err != nil {
+		t.Fatalf("run() unexpected error: %v", err)
+	}
+	if len(m.requests) != 1 || len(m.requests[0]) != 1 {
+		t.Errorf("request after reset = %+v, want the new message only", m.requests)
+	}
+}
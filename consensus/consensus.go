@@ -0,0 +1,312 @@
+// Package consensus implements self-consistency: the same prompt is sampled
+// several times and the most common answer wins.
+package consensus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"nyxze/fayth/model"
+	"nyxze/fayth/output"
+)
+
+// Errors
+var (
+	ErrNoConsensus     = errors.New("consensus: no candidate produced an answer")
+	ErrBudgetExhausted = errors.New("consensus: token budget exhausted")
+)
+
+// Extractor returns the answer a candidate message votes for.
+type Extractor func(msg model.Message) (string, error)
+
+// Text votes with the trimmed message text.
+func Text(msg model.Message) (string, error) {
+	return strings.TrimSpace(msg.Text()), nil
+}
+
+// LastLine votes with the last non-empty line of the message, typically the
+// conclusion of a chain of thought.
+func LastLine(msg model.Message) (string, error) {
+	lines := strings.Split(strings.TrimSpace(msg.Text()), "\n")
+	return strings.TrimSpace(lines[len(lines)-1]), nil
+}
+
+// Regexp votes with the first submatch of the last match of re, such as `answer is (\d+)`.
+func Regexp(re *regexp.Regexp) Extractor {
+	return func(msg model.Message) (string, error) {
+		matches := re.FindAllStringSubmatch(msg.Text(), -1)
+		if len(matches) == 0 || len(matches[len(matches)-1]) < 2 {
+			return "", fmt.Errorf("consensus: no match for %s", re)
+		}
+		return matches[len(matches)-1][1], nil
+	}
+}
+
+// Parsed votes with the value parsed by p, formatted with fmt.Sprint.
+func Parsed[T any](p output.Parser[T]) Extractor {
+	return func(msg model.Message) (string, error) {
+		v, err := p.Parse(msg.Text())
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(v), nil
+	}
+}
+
+// Candidate is one sampled answer.
+type Candidate struct {
+	Message model.Message
+	// Answer is the extracted answer, empty when Err is set
+	Answer string
+	// Err is the generation or extraction failure of the candidate
+	Err error
+}
+
+// Result is the outcome of a vote.
+type Result struct {
+	// Answer is the most common answer, ties going to the answer sampled first
+	Answer string
+	// Message is the first candidate message voting for Answer
+	Message model.Message
+	// Votes is the number of candidates voting for Answer
+	Votes int
+	// Agreement is the share of valid candidates voting for Answer
+	Agreement float64
+	// Counts holds the number of votes of every answer
+	Counts map[string]int
+	// Candidates holds every sample, in the order they were requested
+	Candidates []Candidate
+}
+
+// Sampler generates several candidates for a conversation and votes on their answers.
+type Sampler struct {
+	model       model.Model
+	k           int
+	extractor   Extractor
+	concurrency int
+	useN        bool
+	tokenizer   model.Tokenizer
+	budget      int
+}
+
+// Option configures a [Sampler]
+type Option func(*Sampler)
+
+// WithExtractor sets how answers are extracted from candidates. The default is [Text].
+func WithExtractor(e Extractor) Option {
+	return func(s *Sampler) {
+		s.extractor = e
+	}
+}
+
+// WithConcurrency bounds the number of concurrent Generate calls. The default runs all samples at once.
+// It is ignored with [WithTokenBudget], which runs the samples one at a time.
+func WithConcurrency(n int) Option {
+	return func(s *Sampler) {
+		s.concurrency = max(n, 1)
+	}
+}
+
+// WithChoices requests every sample in a single call with [model.WithN],
+// for providers supporting multiple choices.
+func WithChoices() Option {
+	return func(s *Sampler) {
+		s.useN = true
+	}
+}
+
+// WithTokenBudget bounds the tokens spent across all samples, prompts included. A sample spends
+// the usage reported by the provider, or its prompt and output counted with tokenizer otherwise.
+// Each sample is limited to an equal share of the budget for its output, and no sample is started
+// once the rest of the budget cannot pay for its prompt. Samples run one at a time, so the tokens
+// spent are known before each one starts. With [WithChoices], the single call is not made
+// when the budget cannot pay for the prompt.
+func WithTokenBudget(tokenizer model.Tokenizer, budget int) Option {
+	return func(s *Sampler) {
+		s.tokenizer = tokenizer
+		s.budget = budget
+	}
+}
+
+// New returns a Sampler drawing k candidates from m.
+// Sampling needs diversity, so calls are typically made with a temperature above zero.
+func New(m model.Model, k int, opts ...Option) *Sampler {
+	s := &Sampler{
+		model:     m,
+		k:         max(k, 1),
+		extractor: Text,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	switch {
+	case s.budget > 0:
+		s.concurrency = 1
+	case s.concurrency == 0:
+		s.concurrency = s.k
+	}
+	return s
+}
+
+// Run samples the candidates for messages and returns the consensus.
+// It fails with [ErrNoConsensus] when no candidate produced an answer.
+func (s *Sampler) Run(ctx context.Context, messages []model.Message, opts ...model.ModelOption) (Result, error) {
+	opts = append(opts[:len(opts):len(opts)], model.WithStream(false))
+	if s.budget > 0 {
+		share := s.budget / s.k
+		if current := model.MergeOptions(model.ModelOptions{}, opts...).MaxTokens; current == 0 || current > share {
+			opts = append(opts, model.WithMaxTokens(max(share, 1)))
+		}
+	}
+
+	var candidates []Candidate
+	if s.useN {
+		candidates = s.sampleChoices(ctx, messages, opts)
+	} else {
+		candidates = s.sample(ctx, messages, opts)
+	}
+	for i, c := range candidates {
+		if c.Err == nil {
+			candidates[i].Answer, candidates[i].Err = s.extractor(c.Message)
+		}
+	}
+	return vote(candidates)
+}
+
+// sample runs one Generate call per candidate, at most concurrency at once.
+func (s *Sampler) sample(ctx context.Context, messages []model.Message, opts []model.ModelOption) []Candidate {
+	candidates := make([]Candidate, s.k)
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		spent  int
+		sem    = make(chan struct{}, s.concurrency)
+		prompt = s.promptTokens(messages)
+	)
+	for i := range candidates {
+		sem <- struct{}{}
+		mu.Lock()
+		exhausted := s.budget > 0 && spent+prompt >= s.budget
+		mu.Unlock()
+		if err := ctx.Err(); err != nil || exhausted {
+			if err == nil {
+				err = ErrBudgetExhausted
+			}
+			candidates[i].Err = err
+			<-sem
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			msg, usage, err := generate(ctx, s.model, messages, opts)
+			candidates[i] = Candidate{Message: msg, Err: err}
+			if s.budget > 0 && (err == nil || usage != (model.Usage{})) {
+				mu.Lock()
+				spent += s.spent(usage, prompt, msg)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return candidates
+}
+
+// sampleChoices requests every candidate as a choice of a single call.
+func (s *Sampler) sampleChoices(ctx context.Context, messages []model.Message, opts []model.ModelOption) []Candidate {
+	candidates := make([]Candidate, s.k)
+	if s.budget > 0 && s.promptTokens(messages) >= s.budget {
+		for i := range candidates {
+			candidates[i].Err = ErrBudgetExhausted
+		}
+		return candidates
+	}
+	gen, err := s.model.Generate(ctx, messages, append(opts, model.WithN(s.k))...)
+	if err != nil {
+		for i := range candidates {
+			candidates[i].Err = err
+		}
+		return candidates
+	}
+	choices := gen.Choices()
+	for i := range candidates {
+		switch {
+		case gen.Error() != nil:
+			candidates[i].Err = gen.Error()
+		case i >= len(choices):
+			candidates[i].Err = fmt.Errorf("consensus: model returned %d choices, want %d", len(choices), s.k)
+		default:
+			candidates[i].Message = choices[i]
+		}
+	}
+	return candidates
+}
+
+// promptTokens counts the text of messages with the budget tokenizer, zero without budget.
+func (s *Sampler) promptTokens(messages []model.Message) int {
+	if s.budget <= 0 {
+		return 0
+	}
+	n := 0
+	for _, msg := range messages {
+		n += model.CountTokens(s.tokenizer, msg.Text())
+	}
+	return n
+}
+
+// spent returns the tokens spent by a sample, reported by the provider or counted.
+func (s *Sampler) spent(usage model.Usage, prompt int, msg model.Message) int {
+	if usage != (model.Usage{}) {
+		return usage.Total()
+	}
+	return prompt + model.CountTokens(s.tokenizer, msg.Text())
+}
+
+// generate returns the first message generated for messages, with the usage of the call.
+func generate(ctx context.Context, m model.Model, messages []model.Message, opts []model.ModelOption) (model.Message, model.Usage, error) {
+	gen, err := m.Generate(ctx, messages, opts...)
+	if err != nil {
+		return model.Message{}, model.Usage{}, err
+	}
+	choices := gen.Choices()
+	if gen.Error() != nil {
+		return model.Message{}, gen.Usage(), gen.Error()
+	}
+	if len(choices) == 0 {
+		return model.Message{}, gen.Usage(), errors.New("consensus: model returned no message")
+	}
+	return choices[0], gen.Usage(), nil
+}
+
+// vote tallies the answers of candidates.
+func vote(candidates []Candidate) (Result, error) {
+	r := Result{Counts: make(map[string]int), Candidates: candidates}
+	valid := 0
+	var firstErr error
+	for _, c := range candidates {
+		if c.Err != nil {
+			if firstErr == nil {
+				firstErr = c.Err
+			}
+			continue
+		}
+		valid++
+		r.Counts[c.Answer]++
+	}
+	if valid == 0 {
+		return r, fmt.Errorf("%w: %w", ErrNoConsensus, firstErr)
+	}
+	// Walk in sampling order so ties go to the first answer
+	for _, c := range candidates {
+		if c.Err == nil && r.Counts[c.Answer] > r.Votes {
+			r.Answer, r.Message, r.Votes = c.Answer, c.Message, r.Counts[c.Answer]
+		}
+	}
+	r.Agreement = float64(r.Votes) / float64(valid)
+	return r, nil
+}
@@ -0,0 +1,201 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nyxze/fayth/model"
+	"nyxze/fayth/output"
+)
+
+// pollModel answers with the next reply on each call, or with every reply as choices when n is set
+type pollModel struct {
+	replies []string
+	// usage is reported by every call when set
+	usage model.Usage
+
+	mu      sync.Mutex
+	next    int
+	options []model.ModelOptions
+
+	active, peak atomic.Int32
+}
+
+func (p *pollModel) Generate(ctx context.Context, _ []model.Message, opts ...model.ModelOption) (*model.Generation, error) {
+	if n := p.active.Add(1); n > p.peak.Load() {
+		p.peak.Store(n)
+	}
+	defer p.active.Add(-1)
+	time.Sleep(2 * time.Millisecond)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	options := model.MergeOptions(model.ModelOptions{}, opts...)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.options = append(p.options, options)
+	if options.N > 1 {
+		var choices []model.Message
+		for i, r := range p.replies[:options.N] {
+			msg := model.NewTextMessage(model.Assistant, r)
+			msg.Index = i
+			choices = append(choices, msg)
+		}
+		gen := model.NewGeneration(choices)
+		gen.SetUsage(p.usage)
+		return gen, nil
+	}
+	reply := p.replies[p.next]
+	p.next++
+	if reply == "error" {
+		return nil, errors.New("provider unavailable")
+	}
+	gen := model.NewGeneration([]model.Message{model.NewTextMessage(model.Assistant, reply)})
+	gen.SetUsage(p.usage)
+	return gen, nil
+}
+
+var question = []model.Message{model.NewTextMessage(model.User, "What is 6 x 7?")}
+
+func TestSampler_Run(t *testing.T) {
+	tests := []struct {
+		name      string
+		replies   []string
+		opts      []Option
+		answer    string
+		votes     int
+		agreement float64
+	}{
+		{"Majority", []string{"42", "41", "42", "42", "40"}, nil, "42", 3, 0.6},
+		{"Tie goes to the first", []string{"41", "42", "42", "41"}, []Option{WithConcurrency(1)}, "41", 2, 0.5},
+		{"Failures are ignored", []string{"42", "error", "41", "42"}, nil, "42", 2, 2.0 / 3},
+		{
+			"Regexp",
+			[]string{"6 x 7... the answer is 42", "I think the answer is 42.", "answer is 24", "no idea"},
+			[]Option{WithExtractor(Regexp(regexp.MustCompile(`answer is (\d+)`)))},
+			"42", 2, 2.0 / 3,
+		},
+		{
+			"Parsed",
+			[]string{"Yes.", "no", "YES"},
+			[]Option{WithExtractor(Parsed(output.Enum("yes", "no")))},
+			"yes", 2, 2.0 / 3,
+		},
+		{
+			"Last line",
+			[]string{"6 x 7 = 42\n42", "Let me think\n\n42\n", "7 x 6\n24"},
+			[]Option{WithExtractor(LastLine)},
+			"42", 2, 2.0 / 3,
+		},
+		{"Choices", []string{"42", "41", "42"}, []Option{WithChoices()}, "42", 2, 2.0 / 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := &pollModel{replies: tt.replies}
+			result, err := New(llm, len(tt.replies), tt.opts...).Run(context.Background(), question, model.WithTemperature(0.8))
+			if err != nil {
+				t.Fatalf("Run() unexpected error: %v", err)
+			}
+			if result.Answer != tt.answer || result.Votes != tt.votes || result.Agreement != tt.agreement {
+				t.Errorf("Run() = %q with %d votes and %v agreement, want %q with %d and %v",
+					result.Answer, result.Votes, result.Agreement, tt.answer, tt.votes, tt.agreement)
+			}
+			if len(result.Candidates) != len(tt.replies) || result.Counts[tt.answer] != tt.votes {
+				t.Errorf("Run() candidates = %d, counts = %v", len(result.Candidates), result.Counts)
+			}
+			if answer, _ := New(nil, 1, tt.opts...).extractor(result.Message); answer != tt.answer {
+				t.Errorf("Run() message %q does not vote for the answer", result.Message.Text())
+			}
+			for _, o := range llm.options {
+				if o.Temperature != 0.8 || o.Stream {
+					t.Errorf("options = %+v, want the caller options without streaming", o)
+				}
+			}
+		})
+	}
+}
+
+func TestSampler_Concurrency(t *testing.T) {
+	llm := &pollModel{replies: slices.Repeat([]string{"42"}, 8)}
+	if _, err := New(llm, 8, WithConcurrency(2)).Run(context.Background(), question); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if llm.peak.Load() > 2 || len(llm.options) != 8 {
+		t.Errorf("peak = %d for %d calls, want at most 2 concurrent calls", llm.peak.Load(), len(llm.options))
+	}
+
+	llm = &pollModel{replies: []string{"42", "42"}}
+	result, err := New(llm, 2, WithChoices()).Run(context.Background(), question)
+	if err != nil || len(llm.options) != 1 || llm.options[0].N != 2 || result.Votes != 2 {
+		t.Errorf("Run() with choices = %+v, %v after %d calls", result, err, len(llm.options))
+	}
+}
+
+func TestSampler_TokenBudget(t *testing.T) {
+	// The question and each reply are 6 tokens, a sample spends 12 tokens unless usage is reported
+	tests := []struct {
+		name      string
+		opts      []Option
+		usage     model.Usage
+		wantCalls int
+	}{
+		{"Default concurrency", nil, model.Usage{}, 2},
+		{"Concurrent", []Option{WithConcurrency(4)}, model.Usage{}, 2},
+		{"Reported usage", nil, model.Usage{InputTokens: 20, OutputTokens: 4}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := &pollModel{replies: slices.Repeat([]string{"the answer is 42 yes"}, 4), usage: tt.usage}
+			opts := append(tt.opts, WithTokenBudget(model.ApproxTokenizer{}, 24))
+			result, err := New(llm, 4, opts...).Run(context.Background(), question)
+			if err != nil {
+				t.Fatalf("Run() unexpected error: %v", err)
+			}
+			if len(llm.options) != tt.wantCalls || result.Votes != tt.wantCalls || llm.peak.Load() != 1 {
+				t.Errorf("calls = %d, votes = %d, peak = %d, want %d sequential samples within budget",
+					len(llm.options), result.Votes, llm.peak.Load(), tt.wantCalls)
+			}
+			for _, c := range result.Candidates[tt.wantCalls:] {
+				if !errors.Is(c.Err, ErrBudgetExhausted) {
+					t.Errorf("skipped candidate error = %v, want ErrBudgetExhausted", c.Err)
+				}
+			}
+			for _, o := range llm.options {
+				if o.MaxTokens != 6 {
+					t.Errorf("max tokens = %d, want an equal share of 6", o.MaxTokens)
+				}
+			}
+		})
+	}
+
+	// The single call of choices is not made when the budget cannot pay for the prompt
+	llm := &pollModel{replies: []string{"42", "42"}}
+	result, err := New(llm, 2, WithChoices(), WithTokenBudget(model.ApproxTokenizer{}, 6)).Run(context.Background(), question)
+	if !errors.Is(err, ErrNoConsensus) || len(llm.options) != 0 || !errors.Is(result.Candidates[0].Err, ErrBudgetExhausted) {
+		t.Errorf("Run() with choices = %v after %d calls, want every candidate out of budget", err, len(llm.options))
+	}
+	if _, err := New(llm, 2, WithChoices(), WithTokenBudget(model.ApproxTokenizer{}, 7)).Run(context.Background(), question); err != nil || len(llm.options) != 1 {
+		t.Errorf("Run() with choices within budget = %v after %d calls", err, len(llm.options))
+	}
+}
+
+func TestSampler_Errors(t *testing.T) {
+	llm := &pollModel{replies: []string{"error", "error"}}
+	if _, err := New(llm, 2).Run(context.Background(), question); !errors.Is(err, ErrNoConsensus) {
+		t.Errorf("Run() error = %v, want ErrNoConsensus", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	llm = &pollModel{replies: []string{"42", "42"}}
+	if _, err := New(llm, 2).Run(ctx, question); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+}
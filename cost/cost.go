@@ -0,0 +1,244 @@
+// Package cost prices generations from their token usage and enforces spending budgets.
+//
+// An [Accountant] keeps the spending of named accounts, such as an agent, a session
+// or a tenant. Models wrapped with [Accountant.Wrap] charge every call to their accounts,
+// and refuse calls whose estimated cost would exceed a budget before they are sent.
+package cost
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"nyxze/fayth/model"
+)
+
+// Errors
+var (
+	ErrBudgetExceeded = errors.New("cost: budget exceeded")
+	ErrUnknownModel   = errors.New("cost: unknown model")
+)
+
+// BudgetError reports a call refused because it would exceed the budget of an account.
+// It matches [ErrBudgetExceeded] with errors.Is.
+type BudgetError struct {
+	Account string
+	// Limit is the budget of the account, in US dollars
+	Limit float64
+	// Spent is the cost already recorded or reserved by calls in flight
+	Spent float64
+	// Estimate is the estimated cost of the refused call
+	Estimate float64
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("cost: budget exceeded for %q: $%.6f spent of $%.6f, call estimated at $%.6f",
+		e.Account, e.Spent, e.Limit, e.Estimate)
+}
+
+func (e *BudgetError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// Entry records one priced call, for reconciliation.
+type Entry struct {
+	Time     time.Time   `json:"time"`
+	Accounts []string    `json:"accounts"`
+	Model    string      `json:"model"`
+	Usage    model.Usage `json:"usage"`
+	Cost     float64     `json:"cost"`
+}
+
+// Summary is the spending of an account.
+type Summary struct {
+	Account string      `json:"account"`
+	Spent   float64     `json:"spent"`
+	Limit   float64     `json:"limit,omitzero"`
+	Usage   model.Usage `json:"usage"`
+	Calls   int         `json:"calls"`
+	// Unmetered counts the calls whose provider reported no usage, such as cache hits
+	Unmetered int `json:"unmetered,omitzero"`
+}
+
+// Accountant prices calls with the models of a registry and accumulates their cost per account.
+// It is safe for concurrent use.
+type Accountant struct {
+	registry  *model.Registry
+	tokenizer model.Tokenizer
+	ledger    func(Entry)
+
+	mu       sync.Mutex
+	accounts map[string]*account
+}
+
+type account struct {
+	Summary
+	// reserved is the estimated cost of the calls in flight
+	reserved float64
+}
+
+// Option configures an [Accountant]
+type Option func(*Accountant)
+
+// WithTokenizer sets the tokenizer estimating prompts before calls, [model.ApproxTokenizer] by default.
+func WithTokenizer(t model.Tokenizer) Option {
+	return func(a *Accountant) {
+		a.tokenizer = t
+	}
+}
+
+// WithLedger sets a function called with every priced call, such as to append it to a file.
+// It is called synchronously and must not call the Accountant.
+func WithLedger(f func(Entry)) Option {
+	return func(a *Accountant) {
+		a.ledger = f
+	}
+}
+
+// New returns an Accountant pricing models with registry, such as openai.Models.
+func New(registry *model.Registry, opts ...Option) *Accountant {
+	a := &Accountant{
+		registry:  registry,
+		tokenizer: model.ApproxTokenizer{},
+		accounts:  make(map[string]*account),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Cost returns the price in US dollars of usage with the named model.
+// Fine-tuned models must be registered with their own pricing, see [model.Registry.Price].
+func (a *Accountant) Cost(modelName string, usage model.Usage) (float64, error) {
+	pricing, ok := a.registry.Price(modelName)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownModel, modelName)
+	}
+	return pricing.Cost(usage), nil
+}
+
+// SetBudget limits the spending of an account, in US dollars. A zero limit removes the budget.
+func (a *Accountant) SetBudget(name string, limit float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.account(name).Limit = limit
+}
+
+// Record charges usage of the named model to accounts and returns its cost.
+func (a *Accountant) Record(modelName string, usage model.Usage, accounts ...string) (float64, error) {
+	cost, err := a.Cost(modelName, usage)
+	if err != nil {
+		return 0, err
+	}
+	a.mu.Lock()
+	for _, name := range accounts {
+		acc := a.account(name)
+		acc.Spent += cost
+		acc.Usage = acc.Usage.Add(usage)
+		acc.Calls++
+		if usage == (model.Usage{}) {
+			acc.Unmetered++
+		}
+	}
+	a.mu.Unlock()
+	if a.ledger != nil {
+		a.ledger(Entry{
+			Time:     time.Now(),
+			Accounts: slices.Clone(accounts),
+			Model:    modelName,
+			Usage:    usage,
+			Cost:     cost,
+		})
+	}
+	return cost, nil
+}
+
+// Summary returns the spending of an account.
+func (a *Accountant) Summary(name string) Summary {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if acc, ok := a.accounts[name]; ok {
+		return acc.Summary
+	}
+	return Summary{Account: name}
+}
+
+// Summaries returns the spending of every account, sorted by name.
+func (a *Accountant) Summaries() []Summary {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make([]Summary, 0, len(a.accounts))
+	for _, acc := range a.accounts {
+		out = append(out, acc.Summary)
+	}
+	slices.SortFunc(out, func(x, y Summary) int { return strings.Compare(x.Account, y.Account) })
+	return out
+}
+
+// Estimate returns the estimated cost of sending messages to the named model:
+// the prompt is counted with the tokenizer, and the output is bounded by the MaxTokens
+// and N options. Without MaxTokens, the output is bounded by the MaxOutputTokens of the model,
+// and only the prompt is estimated when the registry does not know it either.
+func (a *Accountant) Estimate(messages []model.Message, options model.ModelOptions) (float64, error) {
+	return a.Cost(options.Model, a.estimateUsage(messages, options))
+}
+
+// estimateUsage returns the usage bounding a call, as priced by [Accountant.Estimate].
+func (a *Accountant) estimateUsage(messages []model.Message, options model.ModelOptions) model.Usage {
+	maxTokens := options.MaxTokens
+	if maxTokens == 0 {
+		info, _ := a.registry.Lookup(options.Model)
+		maxTokens = info.MaxOutputTokens
+	}
+	usage := model.Usage{OutputTokens: maxTokens * max(options.N, 1)}
+	for _, msg := range messages {
+		// Role and separators of the chat format
+		usage.InputTokens += 4
+		for _, c := range msg.Contents {
+			if text, ok := c.(model.TextContent); ok {
+				usage.InputTokens += model.CountTokens(a.tokenizer, text.Text)
+			}
+		}
+	}
+	return usage
+}
+
+// reserve checks that a call estimated at estimate fits the budget of every account,
+// and reserves it until release.
+func (a *Accountant) reserve(estimate float64, accounts []string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, name := range accounts {
+		acc := a.account(name)
+		if acc.Limit > 0 && acc.Spent+acc.reserved+estimate > acc.Limit {
+			return &BudgetError{Account: name, Limit: acc.Limit, Spent: acc.Spent + acc.reserved, Estimate: estimate}
+		}
+	}
+	for _, name := range accounts {
+		a.accounts[name].reserved += estimate
+	}
+	return nil
+}
+
+// release cancels a reservation.
+func (a *Accountant) release(estimate float64, accounts []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, name := range accounts {
+		a.accounts[name].reserved -= estimate
+	}
+}
+
+// account returns the named account, creating it. The caller holds the lock.
+func (a *Accountant) account(name string) *account {
+	acc, ok := a.accounts[name]
+	if !ok {
+		acc = &account{Summary: Summary{Account: name}}
+		a.accounts[name] = acc
+	}
+	return acc
+}
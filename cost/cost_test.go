@@ -0,0 +1,211 @@
+package cost
+
+import (
+	"context"
+	"errors"
+	"math"
+	"runtime"
+	"testing"
+	"time"
+
+	"nyxze/fayth/model"
+)
+
+var registry = model.NewRegistry(
+	model.Info{Name: "small", MaxOutputTokens: 10_000, Pricing: model.Pricing{Input: 2, CachedInput: 1, Output: 8}},
+	model.Info{Name: "large", Pricing: model.Pricing{Input: 10, Output: 30}},
+)
+
+// billedModel answers "ok" reporting a fixed usage, streamed as "o" and "k"
+type billedModel struct {
+	usage model.Usage
+	calls int
+}
+
+func (b *billedModel) Generate(_ context.Context, _ []model.Message, opts ...model.ModelOption) (*model.Generation, error) {
+	b.calls++
+	msg := model.NewTextMessage(model.Assistant, "ok")
+	if model.MergeOptions(model.ModelOptions{}, opts...).Stream {
+		gen := &model.Generation{}
+		gen.MsgIter = func(yield func(model.Message) bool) {
+			for _, text := range []string{"o", "k"} {
+				if !yield(model.NewTextMessage(model.Assistant, text)) {
+					return
+				}
+			}
+			gen.SetUsage(b.usage)
+		}
+		return gen, nil
+	}
+	gen := model.NewGeneration([]model.Message{msg})
+	gen.SetUsage(b.usage)
+	return gen, nil
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+var hello = []model.Message{model.NewTextMessage(model.User, "Hello")}
+
+func TestAccountant_Cost(t *testing.T) {
+	a := New(registry)
+	tests := []struct {
+		model string
+		usage model.Usage
+		want  float64
+	}{
+		{"small", model.Usage{InputTokens: 1_000_000, OutputTokens: 500_000}, 6},
+		{"small", model.Usage{InputTokens: 1_000, CachedInputTokens: 400, OutputTokens: 100}, 0.0012 + 0.0004 + 0.0008},
+		{"large-2025-01-01", model.Usage{InputTokens: 1_000, CachedInputTokens: 1_000}, 0.01},
+	}
+	for _, tt := range tests {
+		if got, err := a.Cost(tt.model, tt.usage); err != nil || !near(got, tt.want) {
+			t.Errorf("Cost(%s, %+v) = %v, %v, want %v", tt.model, tt.usage, got, err, tt.want)
+		}
+	}
+	for _, name := range []string{"unknown", "ft:small:acme::1"} {
+		if _, err := a.Cost(name, model.Usage{}); !errors.Is(err, ErrUnknownModel) {
+			t.Errorf("Cost(%s) error = %v, want ErrUnknownModel", name, err)
+		}
+	}
+
+	cost, err := a.Estimate(hello, model.ModelOptions{Model: "large", MaxTokens: 100, N: 2})
+	if err != nil || !near(cost, (6*10+200*30)/1e6) {
+		t.Errorf("Estimate() = %v, %v", cost, err)
+	}
+}
+
+func TestMetered(t *testing.T) {
+	var ledger []Entry
+	a := New(registry, WithLedger(func(e Entry) { ledger = append(ledger, e) }))
+	next := &billedModel{usage: model.Usage{InputTokens: 1_000, OutputTokens: 1_000}}
+	tenant := a.Wrap(next, "small", "tenant:acme")
+	session := tenant.With("session:1")
+
+	if _, err := tenant.Generate(context.Background(), hello); err != nil {
+		t.Fatalf("Generate() unexpected error: %v", err)
+	}
+	gen, err := session.Generate(context.Background(), hello, model.WithModel("large"), model.WithStream(true))
+	if err != nil {
+		t.Fatalf("Generate() unexpected error: %v", err)
+	}
+	if got := a.Summary("session:1"); got.Calls != 0 {
+		t.Errorf("stream charged before it was consumed: %+v", got)
+	}
+	for range gen.Messages() {
+	}
+	if gen.Usage() != next.usage {
+		t.Errorf("Usage() = %+v, want the reported usage", gen.Usage())
+	}
+
+	tenantSpent := 0.010 + 0.040
+	if got := a.Summary("tenant:acme"); got.Calls != 2 || !near(got.Spent, tenantSpent) || got.Usage.OutputTokens != 2_000 {
+		t.Errorf("tenant summary = %+v", got)
+	}
+	if got := a.Summary("session:1"); got.Calls != 1 || !near(got.Spent, 0.040) {
+		t.Errorf("session summary = %+v", got)
+	}
+	if len(ledger) != 2 || ledger[1].Model != "large" || len(ledger[1].Accounts) != 2 || !near(ledger[1].Cost, 0.040) {
+		t.Errorf("ledger = %+v", ledger)
+	}
+	if s := a.Summaries(); len(s) != 2 || s[0].Account != "session:1" {
+		t.Errorf("Summaries() = %+v", s)
+	}
+
+	if _, err := a.Wrap(next, "unknown").Generate(context.Background(), hello); !errors.Is(err, ErrUnknownModel) {
+		t.Errorf("Generate() error = %v, want ErrUnknownModel", err)
+	}
+}
+
+func TestMetered_UnfinishedStream(t *testing.T) {
+	tests := []struct {
+		name string
+		// leave stops reading the stream of gen
+		leave func(gen *model.Generation, cancel context.CancelFunc)
+	}{
+		{"Early break", func(gen *model.Generation, _ context.CancelFunc) {
+			for range gen.Messages() {
+				break
+			}
+		}},
+		{"Canceled", func(_ *model.Generation, cancel context.CancelFunc) { cancel() }},
+		{"Dropped", func(*model.Generation, context.CancelFunc) {}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(registry)
+			a.SetBudget("tenant", 1)
+			m := a.Wrap(&billedModel{usage: model.Usage{InputTokens: 1_000, OutputTokens: 1_000}}, "small", "tenant")
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			gen, err := m.Generate(ctx, hello, model.WithStream(true), model.WithMaxTokens(100))
+			if err != nil {
+				t.Fatalf("Generate() unexpected error: %v", err)
+			}
+			tt.leave(gen, cancel)
+			gen = nil
+
+			deadline := time.Now().Add(time.Second)
+			for a.Summary("tenant").Calls == 0 && time.Now().Before(deadline) {
+				runtime.GC()
+				time.Sleep(time.Millisecond)
+			}
+			a.mu.Lock()
+			reserved := a.accounts["tenant"].reserved
+			a.mu.Unlock()
+			if got := a.Summary("tenant"); got.Calls != 1 || got.Usage.OutputTokens != 100 || reserved != 0 {
+				t.Errorf("summary = %+v with %v reserved, want the estimate charged and the reservation released", got, reserved)
+			}
+		})
+	}
+}
+
+func TestMetered_Budget(t *testing.T) {
+	a := New(registry)
+	a.SetBudget("tenant", 0.025)
+	next := &billedModel{usage: model.Usage{InputTokens: 1_000, OutputTokens: 1_000}}
+	m := a.Wrap(next, "small", "tenant")
+
+	for range 2 {
+		if _, err := m.Generate(context.Background(), hello, model.WithMaxTokens(100)); err != nil {
+			t.Fatalf("Generate() unexpected error: %v", err)
+		}
+	}
+	// 0.02 spent, the 1000 output tokens estimate does not fit the remaining 0.005
+	_, err := m.Generate(context.Background(), hello, model.WithMaxTokens(1_000))
+	var budget *BudgetError
+	if !errors.Is(err, ErrBudgetExceeded) || !errors.As(err, &budget) {
+		t.Fatalf("Generate() error = %v, want a BudgetError", err)
+	}
+	if budget.Account != "tenant" || !near(budget.Spent, 0.02) || budget.Limit != 0.025 || next.calls != 2 {
+		t.Errorf("BudgetError = %+v after %d calls", budget, next.calls)
+	}
+
+	// Reservations count calls in flight
+	a.SetBudget("stream", 0.001)
+	s := a.Wrap(next, "small", "stream")
+	gen, err := s.Generate(context.Background(), hello, model.WithStream(true), model.WithMaxTokens(100))
+	if err != nil {
+		t.Fatalf("Generate() unexpected error: %v", err)
+	}
+	if _, err := s.Generate(context.Background(), hello, model.WithMaxTokens(100)); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Generate() with a reservation in flight error = %v, want ErrBudgetExceeded", err)
+	}
+	for range gen.Messages() {
+	}
+
+	// Without MaxTokens, the 10000 output tokens the model may generate cost 0.08
+	a.SetBudget("unbounded", 0.05)
+	u := a.Wrap(next, "small", "unbounded")
+	if _, err := u.Generate(context.Background(), hello); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Generate() without max tokens error = %v, want ErrBudgetExceeded", err)
+	}
+	if _, err := u.Generate(context.Background(), hello, model.WithMaxTokens(1_000)); err != nil {
+		t.Errorf("Generate() with max tokens unexpected error: %v", err)
+	}
+
+	a.SetBudget("tenant", 0)
+	if _, err := m.Generate(context.Background(), hello, model.WithMaxTokens(1_000)); err != nil {
+		t.Errorf("Generate() without budget unexpected error: %v", err)
+	}
+}
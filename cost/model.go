@@ -0,0 +1,104 @@
+package cost
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"sync"
+
+	"nyxze/fayth/model"
+)
+
+// Metered is a [model.Model] charging its calls to accounts of an [Accountant].
+type Metered struct {
+	next       model.Model
+	accountant *Accountant
+	model      string
+	accounts   []string
+}
+
+// Compile type interface assertion
+var _ model.Model = (*Metered)(nil)
+
+// Wrap returns m charging its calls to accounts. Calls made without the Model option are
+// priced as defaultModel, which should be the default model of m.
+func (a *Accountant) Wrap(m model.Model, defaultModel string, accounts ...string) *Metered {
+	return &Metered{next: m, accountant: a, model: defaultModel, accounts: slices.Clone(accounts)}
+}
+
+// With returns a copy of m also charging accounts, such as a session within a tenant.
+func (m *Metered) With(accounts ...string) *Metered {
+	out := *m
+	out.accounts = append(slices.Clone(m.accounts), accounts...)
+	return &out
+}
+
+// Generate fails with a [BudgetError] before sending the request when its estimated cost
+// exceeds the budget of an account, and with [ErrUnknownModel] when the model has no price.
+// The usage reported by the provider is charged once the generation completes,
+// at the end of the stream when streaming. A stream left early, canceled by ctx or dropped
+// unread is charged the estimated usage unless the provider reported one.
+func (m *Metered) Generate(ctx context.Context, messages []model.Message, opts ...model.ModelOption) (*model.Generation, error) {
+	options := model.MergeOptions(model.ModelOptions{Model: m.model}, opts...)
+	estimated := m.accountant.estimateUsage(messages, options)
+	estimate, err := m.accountant.Cost(options.Model, estimated)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.accountant.reserve(estimate, m.accounts); err != nil {
+		return nil, err
+	}
+
+	gen, err := m.next.Generate(ctx, messages, opts...)
+	if err != nil {
+		m.accountant.release(estimate, m.accounts)
+		return nil, err
+	}
+	if !options.Stream {
+		m.charge(options.Model, estimate, gen.Usage())
+		return gen, nil
+	}
+
+	s := &settlement{metered: m, model: options.Model, estimate: estimate, estimated: estimated}
+	out := &model.Generation{}
+	stop := context.AfterFunc(ctx, func() { s.settle(s.estimated) })
+	runtime.AddCleanup(out, func(s *settlement) { s.settle(s.estimated) }, s)
+	out.MsgIter = func(yield func(model.Message) bool) {
+		defer stop()
+		for msg := range gen.Messages() {
+			if !yield(msg) {
+				usage := gen.Usage()
+				if usage == (model.Usage{}) {
+					usage = s.estimated
+				}
+				s.settle(usage)
+				return
+			}
+		}
+		out.Err = gen.Error()
+		out.SetUsage(gen.Usage())
+		s.settle(out.Usage())
+	}
+	return out, nil
+}
+
+// settlement charges a streamed call once, whichever of its end, its context
+// or the collection of its generation comes first.
+type settlement struct {
+	once      sync.Once
+	metered   *Metered
+	model     string
+	estimate  float64
+	estimated model.Usage
+}
+
+func (s *settlement) settle(usage model.Usage) {
+	s.once.Do(func() { s.metered.charge(s.model, s.estimate, usage) })
+}
+
+// charge replaces the reservation of a call with its reported usage.
+func (m *Metered) charge(modelName string, estimate float64, usage model.Usage) {
+	m.accountant.release(estimate, m.accounts)
+	// The model was priced by the estimate, recording cannot fail
+	_, _ = m.accountant.Record(modelName, usage, m.accounts...)
+}
@@ -0,0 +1,113 @@
+// Package document loads source files into documents and splits them into chunks
+// suited to embedding and retrieval.
+package document
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"nyxze/fayth/model"
+)
+
+// Metadata keys set by loaders and splitters.
+const (
+	// SourceKey holds the file name or identifier the content was loaded from
+	SourceKey = "source"
+	// OffsetKey holds the byte offset of the content within the source
+	OffsetKey = "offset"
+	// HeadingKey holds the heading path of a Markdown section, such as "Install > Linux"
+	HeadingKey = "heading"
+	// TitleKey holds the title of an HTML page
+	TitleKey = "title"
+	// LineKey holds the 1-based line of a JSONL record
+	LineKey = "line"
+	// RowKey holds the 1-based data row of a CSV record
+	RowKey = "row"
+	// ChunkKey holds the 0-based position of a chunk within its document
+	ChunkKey = "chunk"
+)
+
+// Document is a piece of text along with metadata describing where it comes from.
+// Metadata uses the same shape as [model.Message] metadata so it can flow between both.
+type Document struct {
+	Content  string
+	Metadata map[string]string
+}
+
+// ID returns an identifier derived from the source, offset and chunk metadata.
+func (d Document) ID() string {
+	id := d.Metadata[SourceKey] + "#" + d.Metadata[OffsetKey]
+	if c, ok := d.Metadata[ChunkKey]; ok {
+		id += "-" + c
+	}
+	return id
+}
+
+// Offset returns the byte offset of the document within its source.
+func (d Document) Offset() int {
+	o, _ := strconv.Atoi(d.Metadata[OffsetKey])
+	return o
+}
+
+// Message returns a text message holding the document content and metadata.
+func (d Document) Message(role model.Role) model.Message {
+	msg := model.NewTextMessage(role, d.Content)
+	msg.Metadata = maps.Clone(d.Metadata)
+	return msg
+}
+
+// newDocument returns a Document for content found at offset within source.
+func newDocument(content, source string, offset int) Document {
+	return Document{
+		Content: content,
+		Metadata: map[string]string{
+			SourceKey: source,
+			OffsetKey: strconv.Itoa(offset),
+		},
+	}
+}
+
+// Loader reads documents from r, source identifies r in the documents metadata.
+type Loader func(r io.Reader, source string) ([]Document, error)
+
+// LoadFile loads the file at path with the loader matching its extension.
+// Unknown extensions are loaded as plain text.
+func LoadFile(path string) ([]Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoaderFor(path)(f, path)
+}
+
+// LoaderFor returns the default loader for the extension of name.
+func LoaderFor(name string) Loader {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown":
+		return Markdown()
+	case ".html", ".htm":
+		return HTML()
+	case ".json":
+		return JSON("")
+	case ".jsonl", ".ndjson":
+		return JSONL("")
+	case ".csv":
+		return CSV("")
+	default:
+		return Text()
+	}
+}
+
+func readAll(r io.Reader, source string) (string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("document: reading %s: %w", source, err)
+	}
+	return string(b), nil
+}
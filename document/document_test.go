@@ -0,0 +1,222 @@
+package document
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"nyxze/fayth/model"
+)
+
+func TestMarkdown_SplitsByHeadings(t *testing.T) {
+	input := "Intro text\n# Install\nRun it.\n## Linux\nUse apt.\n```sh\n# not a heading\n```\n# Usage\nCall it.\n"
+	docs, err := Markdown()(strings.NewReader(input), "README.md")
+	if err != nil {
+		t.Fatalf("Markdown() unexpected error: %v", err)
+	}
+	want := []struct{ heading, prefix string }{
+		{"", "Intro text"},
+		{"Install", "# Install"},
+		{"Install > Linux", "## Linux"},
+		{"Usage", "# Usage"},
+	}
+	if len(docs) != len(want) {
+		t.Fatalf("Expected %d sections, got %d: %+v", len(want), len(docs), docs)
+	}
+	for i, w := range want {
+		if docs[i].Metadata[HeadingKey] != w.heading {
+			t.Errorf("Section %d: expected heading %q, got %q", i, w.heading, docs[i].Metadata[HeadingKey])
+		}
+		if !strings.HasPrefix(docs[i].Content, w.prefix) {
+			t.Errorf("Section %d: expected content starting with %q, got %q", i, w.prefix, docs[i].Content)
+		}
+		if input[docs[i].Offset():][:len(w.prefix)] != w.prefix {
+			t.Errorf("Section %d: offset %d does not point to the section", i, docs[i].Offset())
+		}
+	}
+	if !strings.Contains(docs[2].Content, "# not a heading") {
+		t.Errorf("Expected fenced code to stay in the Linux section, got %q", docs[2].Content)
+	}
+}
+
+func TestHTML_ReadableText(t *testing.T) {
+	input := `<html><head><title>My &amp; Page</title><style>p{color:red}</style></head>
+<body><nav><a href="/">Home</a></nav>
+<h1>Hello</h1><p>First   paragraph with <b>bold</b> text.</p>
+<script>alert("x")</script><ul><li>One</li><li>Two</li></ul></body></html>`
+	docs, err := HTML()(strings.NewReader(input), "page.html")
+	if err != nil {
+		t.Fatalf("HTML() unexpected error: %v", err)
+	}
+	got := docs[0].Content
+	want := "Hello\n\nFirst paragraph with bold text.\n\n- One\n- Two"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if docs[0].Metadata[TitleKey] != "My & Page" {
+		t.Errorf("Expected title, got %q", docs[0].Metadata[TitleKey])
+	}
+
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"Self-closing":  {`<p>Logo <svg viewBox="0 0 1 1"/> and text</p><p>Kept</p>`, "Logo and text\n\nKept"},
+		"Unclosed":      {`<p>Before</p><iframe src="/ad"><p>After</p>`, "Before\n\nAfter"},
+		"Form":          {`<form><label>Email</label><input name="email"/></form>`, "Email"},
+		"Closed script": {`<p>A</p><SCRIPT>x()</script ><p>B</p>`, "A\n\nB"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			docs, err := HTML()(strings.NewReader(tt.input), "page.html")
+			if err != nil {
+				t.Fatalf("HTML() unexpected error: %v", err)
+			}
+			if docs[0].Content != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, docs[0].Content)
+			}
+		})
+	}
+}
+
+func TestJSONL_AndCSV(t *testing.T) {
+	jsonl := "{\"text\":\"first\",\"lang\":\"en\"}\n\n{\"text\":\"second\",\"lang\":\"fr\",\"n\":2}\n"
+	docs, err := JSONL("text", "lang", "n")(strings.NewReader(jsonl), "data.jsonl")
+	if err != nil {
+		t.Fatalf("JSONL() unexpected error: %v", err)
+	}
+	if len(docs) != 2 || docs[1].Content != "second" || docs[1].Metadata["lang"] != "fr" || docs[1].Metadata["n"] != "2" {
+		t.Errorf("Unexpected JSONL documents: %+v", docs)
+	}
+	if docs[1].Metadata[LineKey] != "3" || docs[1].Offset() != strings.Index(jsonl, "{\"text\":\"second\"") {
+		t.Errorf("Unexpected JSONL position: %+v", docs[1].Metadata)
+	}
+
+	csv := "question,answer\nWhat?,That.\nWho?,Them.\n"
+	docs, err = CSV("")(strings.NewReader(csv), "faq.csv")
+	if err != nil {
+		t.Fatalf("CSV() unexpected error: %v", err)
+	}
+	if len(docs) != 2 || docs[1].Content != "question: Who?\nanswer: Them." || docs[1].Metadata[RowKey] != "2" {
+		t.Errorf("Unexpected CSV documents: %+v", docs)
+	}
+
+	docs, err = JSON("body")(strings.NewReader(`[{"body":"a"},{"body":"b"}]`), "data.json")
+	if err != nil || len(docs) != 2 || docs[1].Content != "b" {
+		t.Errorf("Unexpected JSON documents: %+v, %v", docs, err)
+	}
+}
+
+func TestDocument_ID(t *testing.T) {
+	input := `[
+  {"body": "the first element"},
+  {"body": "the second element"}, {"body": "the third element"}
+]`
+	loaders := map[string]struct {
+		loader Loader
+		source string
+		input  string
+	}{
+		"JSON":     {JSON("body"), "data.json", input},
+		"JSON raw": {JSON(""), "data.json", input},
+		"Object":   {JSON("body"), "data.json", "\n {\"body\": \"alone\"}"},
+		"JSONL":    {JSONL("body"), "data.jsonl", "{\"body\":\"one\"}\n{\"body\":\"two\"}\n"},
+		"CSV":      {CSV("body"), "data.csv", "body\none\ntwo\n"},
+	}
+	for name, tt := range loaders {
+		t.Run(name, func(t *testing.T) {
+			docs, err := tt.loader(strings.NewReader(tt.input), tt.source)
+			if err != nil {
+				t.Fatalf("Loader unexpected error: %v", err)
+			}
+			ids := map[string]bool{}
+			for _, doc := range docs {
+				if ids[doc.ID()] {
+					t.Errorf("Duplicate document ID %s", doc.ID())
+				}
+				ids[doc.ID()] = true
+				if !strings.HasPrefix(doc.ID(), tt.source+"#") {
+					t.Errorf("Expected an ID within %s, got %s", tt.source, doc.ID())
+				}
+				if name != "CSV" && tt.input[doc.Offset()] != '{' {
+					t.Errorf("Offset %d of %s does not point to its element", doc.Offset(), doc.ID())
+				}
+				for _, chunk := range NewCharacterSplitter(8, 2).Split(doc) {
+					if ids[chunk.ID()] {
+						t.Errorf("Duplicate chunk ID %s", chunk.ID())
+					}
+					ids[chunk.ID()] = true
+				}
+			}
+		})
+	}
+
+	if _, err := JSON("body")(strings.NewReader(`[{"body":"a"}] {}`), "data.json"); err == nil {
+		t.Error("Expected an error for data after the array")
+	}
+}
+
+func TestSplitters(t *testing.T) {
+	doc := newDocument("The quick brown fox. Jumps over the lazy dog.\n\nA second paragraph here.", "fox.txt", 100)
+	doc.Metadata[HeadingKey] = "Animals"
+
+	tests := map[string]struct {
+		splitter Splitter
+		check    func(t *testing.T, chunks []Document)
+	}{
+		"Characters": {
+			splitter: NewCharacterSplitter(20, 5),
+			check: func(t *testing.T, chunks []Document) {
+				if chunks[0].Content != "The quick brown fox." {
+					t.Errorf("Unexpected first chunk %q", chunks[0].Content)
+				}
+				// Second window starts 15 characters in, on the space before "fox."
+				if chunks[1].Offset() != 116 || !strings.HasPrefix(chunks[1].Content, "fox.") {
+					t.Errorf("Expected overlapping second chunk at 116, got %d %q", chunks[1].Offset(), chunks[1].Content)
+				}
+			},
+		},
+		"Tokens": {
+			splitter: NewTokenSplitter(model.ApproxTokenizer{}, 6, 2),
+			check: func(t *testing.T, chunks []Document) {
+				for _, c := range chunks {
+					if n := model.CountTokens(model.ApproxTokenizer{}, c.Content); n > 6 {
+						t.Errorf("Chunk %q has %d tokens", c.Content, n)
+					}
+				}
+			},
+		},
+		"Recursive": {
+			splitter: NewRecursiveSplitter(30, 0),
+			check: func(t *testing.T, chunks []Document) {
+				want := []string{"The quick brown fox.", "Jumps over the lazy dog.", "A second paragraph here."}
+				if len(chunks) != len(want) {
+					t.Fatalf("Expected %q, got %+v", want, chunks)
+				}
+				for i := range want {
+					if chunks[i].Content != want[i] {
+						t.Errorf("Chunk %d: expected %q, got %q", i, want[i], chunks[i].Content)
+					}
+				}
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			chunks := tt.splitter.Split(doc)
+			if len(chunks) < 2 {
+				t.Fatalf("Expected several chunks, got %d", len(chunks))
+			}
+			for i, c := range chunks {
+				if c.Metadata[HeadingKey] != "Animals" || c.Metadata[SourceKey] != "fox.txt" || c.Metadata[ChunkKey] != strconv.Itoa(i) {
+					t.Errorf("Chunk %d lost metadata: %+v", i, c.Metadata)
+				}
+				start := c.Offset() - 100
+				if doc.Content[start:start+len(c.Content)] != c.Content {
+					t.Errorf("Chunk %d offset %d does not point to its content", i, c.Offset())
+				}
+			}
+			tt.check(t, chunks)
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package document
+
+import (
+	"html"
+	"io"
+	"strings"
+)
+
+// Elements whose content is never readable text
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true,
+	"svg": true, "nav": true, "footer": true, "iframe": true,
+}
+
+// Elements breaking the flow of text
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "hr": true, "section": true, "article": true,
+	"main": true, "header": true, "aside": true, "ul": true, "ol": true, "li": true,
+	"table": true, "tr": true, "blockquote": true, "pre": true, "dl": true, "dt": true,
+	"dd": true, "figure": true, "figcaption": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// HTML returns a Loader extracting the readable text of a page as a single document.
+// Scripts, styles and navigation are dropped, block elements become line breaks
+// and the page title is kept in the metadata.
+func HTML() Loader {
+	return func(r io.Reader, source string) ([]Document, error) {
+		content, err := readAll(r, source)
+		if err != nil {
+			return nil, err
+		}
+		text, title := extractText(content)
+		doc := newDocument(text, source, 0)
+		if title != "" {
+			doc.Metadata[TitleKey] = title
+		}
+		return []Document{doc}, nil
+	}
+}
+
+// extractText returns the readable text and the title of an HTML page.
+func extractText(page string) (string, string) {
+	var (
+		out   textWriter
+		title string
+		pre   int
+	)
+	for i := 0; i < len(page); {
+		if page[i] != '<' {
+			next := strings.IndexByte(page[i:], '<')
+			if next < 0 {
+				next = len(page) - i
+			}
+			out.text(html.UnescapeString(page[i:i+next]), pre > 0)
+			i += next
+			continue
+		}
+
+		// Comments and declarations
+		if strings.HasPrefix(page[i:], "<!--") {
+			end := strings.Index(page[i+4:], "-->")
+			if end < 0 {
+				break
+			}
+			i += 4 + end + 3
+			continue
+		}
+		end := strings.IndexByte(page[i:], '>')
+		if end < 0 {
+			break
+		}
+		name, closing := tagName(page[i+1 : i+end])
+		selfClosing := strings.HasSuffix(page[i+1:i+end], "/")
+		i += end + 1
+
+		switch {
+		case name == "title" && !closing:
+			stop := indexFold(page[i:], "</title")
+			if stop < 0 {
+				stop = len(page) - i
+			}
+			title = strings.Join(strings.Fields(html.UnescapeString(page[i:i+stop])), " ")
+			i += stop
+		case skippedElements[name] && !closing && !selfClosing:
+			// An unclosed element only drops its tag, not the rest of the page
+			if stop := indexFold(page[i:], "</"+name); stop >= 0 {
+				i += stop
+			}
+		case name == "pre":
+			if closing {
+				pre = max(pre-1, 0)
+			} else {
+				pre++
+			}
+			out.lineBreak(1)
+		case name == "li" && !closing:
+			out.lineBreak(1)
+			out.prefix = "- "
+		case isHeading(name), name == "p":
+			out.lineBreak(2)
+		case blockElements[name]:
+			out.lineBreak(1)
+		case name == "td" || name == "th":
+			out.space = true
+		}
+	}
+	return strings.TrimSpace(out.sb.String()), title
+}
+
+// textWriter accumulates text, deferring line breaks and spaces until more text follows.
+type textWriter struct {
+	sb      strings.Builder
+	breaks  int
+	space   bool
+	prefix  string
+	started bool
+}
+
+func (w *textWriter) lineBreak(n int) {
+	w.breaks = max(w.breaks, n)
+}
+
+func (w *textWriter) text(text string, preformatted bool) {
+	if !preformatted {
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			w.space = w.space || text != ""
+			return
+		}
+		if strings.TrimLeft(text, " \t\r\n") != text {
+			w.space = true
+		}
+		trailing := strings.TrimRight(text, " \t\r\n") != text
+		text = strings.Join(fields, " ")
+		defer func() { w.space = trailing }()
+	}
+	if w.started {
+		switch {
+		case w.breaks > 0:
+			w.sb.WriteString(strings.Repeat("\n", w.breaks))
+		case w.space:
+			w.sb.WriteString(" ")
+		}
+	}
+	w.sb.WriteString(w.prefix)
+	w.sb.WriteString(text)
+	w.breaks, w.space, w.prefix, w.started = 0, false, "", true
+}
+
+func isHeading(name string) bool {
+	return len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6'
+}
+
+// tagName returns the lowercase name of a tag from its inner text, such as "a href=...".
+func tagName(tag string) (string, bool) {
+	closing := strings.HasPrefix(tag, "/")
+	tag = strings.TrimPrefix(tag, "/")
+	end := strings.IndexFunc(tag, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '/'
+	})
+	if end >= 0 {
+		tag = tag[:end]
+	}
+	return strings.ToLower(tag), closing
+}
+
+// indexFold returns the index of the first ASCII case-insensitive match of substr in s, or -1.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
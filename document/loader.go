@@ -0,0 +1,282 @@
+package document
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Text returns a Loader reading the whole input as a single document.
+func Text() Loader {
+	return func(r io.Reader, source string) ([]Document, error) {
+		content, err := readAll(r, source)
+		if err != nil {
+			return nil, err
+		}
+		return []Document{newDocument(content, source, 0)}, nil
+	}
+}
+
+// Markdown returns a Loader producing one document per section, split on ATX headings.
+// Each section carries its heading path, headings inside fenced code blocks are ignored.
+func Markdown() Loader {
+	return func(r io.Reader, source string) ([]Document, error) {
+		content, err := readAll(r, source)
+		if err != nil {
+			return nil, err
+		}
+		return splitMarkdown(content, source), nil
+	}
+}
+
+func splitMarkdown(content, source string) []Document {
+	var (
+		docs    []Document
+		path    []string // current heading titles, indexed by level-1
+		start   int
+		fence   string
+		heading string
+	)
+	flush := func(end int) {
+		if strings.TrimSpace(content[start:end]) == "" {
+			return
+		}
+		doc := newDocument(content[start:end], source, start)
+		if heading != "" {
+			doc.Metadata[HeadingKey] = heading
+		}
+		docs = append(docs, doc)
+	}
+
+	for offset := 0; offset < len(content); {
+		end := strings.IndexByte(content[offset:], '\n')
+		if end < 0 {
+			end = len(content)
+		} else {
+			end += offset + 1
+		}
+		line := strings.TrimRight(content[offset:end], "\r\n")
+
+		trimmed := strings.TrimLeft(line, " ")
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```"), strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+		default:
+			if level, title, ok := parseHeading(trimmed); ok {
+				flush(offset)
+				start = offset
+				if len(path) >= level {
+					path = path[:level-1]
+				}
+				for len(path) < level-1 {
+					path = append(path, "")
+				}
+				path = append(path, title)
+				heading = joinHeadings(path)
+			}
+		}
+		offset = end
+	}
+	flush(len(content))
+	return docs
+}
+
+// parseHeading parses an ATX heading such as "## Title".
+func parseHeading(line string) (int, string, bool) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ' && line[level] != '\t') {
+		return 0, "", false
+	}
+	title := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(line[level:]), "#"))
+	return level, title, true
+}
+
+func joinHeadings(path []string) string {
+	parts := make([]string, 0, len(path))
+	for _, p := range path {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
+
+// JSON returns a Loader reading a JSON array of objects, or a single object, as one document each.
+// The content is the string value of contentField, or the whole object when contentField is empty.
+// Scalar values of metadataFields are copied to the metadata.
+func JSON(contentField string, metadataFields ...string) Loader {
+	return func(r io.Reader, source string) ([]Document, error) {
+		content, err := readAll(r, source)
+		if err != nil {
+			return nil, err
+		}
+		trimmed := strings.TrimLeft(content, " \t\r\n")
+		if !strings.HasPrefix(trimmed, "[") {
+			offset := len(content) - len(trimmed)
+			doc, err := jsonDocument([]byte(strings.TrimSpace(trimmed)), source, offset, contentField, metadataFields)
+			if err != nil {
+				return nil, fmt.Errorf("document: decoding %s: %w", source, err)
+			}
+			doc.Metadata[RowKey] = "1"
+			return []Document{doc}, nil
+		}
+
+		// Elements are decoded one by one to keep their offset
+		dec := json.NewDecoder(strings.NewReader(content))
+		if _, err := dec.Token(); err != nil {
+			return nil, fmt.Errorf("document: decoding %s: %w", source, err)
+		}
+		var docs []Document
+		for i := 0; dec.More(); i++ {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return nil, fmt.Errorf("document: decoding %s: %w", source, err)
+			}
+			offset := int(dec.InputOffset()) - len(raw)
+			doc, err := jsonDocument(raw, source, offset, contentField, metadataFields)
+			if err != nil {
+				return nil, fmt.Errorf("document: decoding %s element %d: %w", source, i, err)
+			}
+			doc.Metadata[RowKey] = strconv.Itoa(i + 1)
+			docs = append(docs, doc)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, fmt.Errorf("document: decoding %s: %w", source, err)
+		}
+		if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("document: decoding %s: unexpected data after the array", source)
+		}
+		return docs, nil
+	}
+}
+
+// JSONL returns a Loader reading one JSON object per line, see [JSON] for the fields handling.
+// Blank lines are skipped.
+func JSONL(contentField string, metadataFields ...string) Loader {
+	return func(r io.Reader, source string) ([]Document, error) {
+		var docs []Document
+		reader := bufio.NewReader(r)
+		for line, offset := 1, 0; ; line++ {
+			raw, err := reader.ReadBytes('\n')
+			if len(bytes.TrimSpace(raw)) > 0 {
+				doc, derr := jsonDocument(bytes.TrimSpace(raw), source, offset, contentField, metadataFields)
+				if derr != nil {
+					return nil, fmt.Errorf("document: decoding %s line %d: %w", source, line, derr)
+				}
+				doc.Metadata[LineKey] = strconv.Itoa(line)
+				docs = append(docs, doc)
+			}
+			offset += len(raw)
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("document: reading %s: %w", source, err)
+			}
+		}
+	}
+}
+
+func jsonDocument(raw []byte, source string, offset int, contentField string, metadataFields []string) (Document, error) {
+	if contentField == "" && len(metadataFields) == 0 {
+		return newDocument(string(raw), source, offset), nil
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return Document{}, err
+	}
+	content := string(raw)
+	if contentField != "" {
+		s, ok := obj[contentField].(string)
+		if !ok {
+			return Document{}, fmt.Errorf("field %q is not a string", contentField)
+		}
+		content = s
+	}
+	doc := newDocument(content, source, offset)
+	for _, f := range metadataFields {
+		switch v := obj[f].(type) {
+		case nil, map[string]any, []any:
+			// Only scalar values are kept
+		case string:
+			doc.Metadata[f] = v
+		default:
+			doc.Metadata[f] = fmt.Sprint(v)
+		}
+	}
+	return doc, nil
+}
+
+// CSV returns a Loader producing one document per data row, the first row being the header.
+// The content is the value of contentColumn, or "column: value" lines for every column when empty.
+// Values of metadataColumns are copied to the metadata.
+func CSV(contentColumn string, metadataColumns ...string) Loader {
+	return func(r io.Reader, source string) ([]Document, error) {
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		header, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("document: decoding %s: %w", source, err)
+		}
+		columns := make(map[string]int, len(header))
+		for i, h := range header {
+			columns[h] = i
+		}
+		if _, ok := columns[contentColumn]; contentColumn != "" && !ok {
+			return nil, fmt.Errorf("document: %s has no column %q", source, contentColumn)
+		}
+
+		var docs []Document
+		for row := 1; ; row++ {
+			offset := int(reader.InputOffset())
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("document: decoding %s: %w", source, err)
+			}
+			value := func(col string) string {
+				if i, ok := columns[col]; ok && i < len(record) {
+					return record[i]
+				}
+				return ""
+			}
+
+			var content string
+			if contentColumn != "" {
+				content = value(contentColumn)
+			} else {
+				lines := make([]string, 0, len(header))
+				for i, h := range header {
+					if i < len(record) {
+						lines = append(lines, h+": "+record[i])
+					}
+				}
+				content = strings.Join(lines, "\n")
+			}
+			doc := newDocument(content, source, offset)
+			doc.Metadata[RowKey] = strconv.Itoa(row)
+			for _, col := range metadataColumns {
+				doc.Metadata[col] = value(col)
+			}
+			docs = append(docs, doc)
+		}
+	}
+}
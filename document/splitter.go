@@ -0,0 +1,228 @@
+package document
+
+import (
+	"maps"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"nyxze/fayth/model"
+)
+
+// Splitter cuts a document into smaller chunks.
+// Chunks keep the document metadata, their offset within the source and their position.
+type Splitter interface {
+	Split(doc Document) []Document
+}
+
+// SplitAll splits every document of docs with s.
+func SplitAll(s Splitter, docs []Document) []Document {
+	var out []Document
+	for _, d := range docs {
+		out = append(out, s.Split(d)...)
+	}
+	return out
+}
+
+// DefaultSeparators are tried in order by the [RecursiveSplitter],
+// from paragraphs down to single characters.
+var DefaultSeparators = []string{"\n\n", "\n", ". ", " ", ""}
+
+// span is a byte range of a document content, along with its measured length
+type span struct {
+	start, end, length int
+}
+
+// chunks returns the documents covering spans of doc, trimmed of surrounding whitespace.
+func chunks(doc Document, spans []span) []Document {
+	base := doc.Offset()
+	out := make([]Document, 0, len(spans))
+	for _, s := range spans {
+		raw := doc.Content[s.start:s.end]
+		content := strings.TrimSpace(raw)
+		if content == "" {
+			continue
+		}
+		lead := len(raw) - len(strings.TrimLeft(raw, " \t\r\n"))
+		md := maps.Clone(doc.Metadata)
+		if md == nil {
+			md = map[string]string{}
+		}
+		md[OffsetKey] = strconv.Itoa(base + s.start + lead)
+		md[ChunkKey] = strconv.Itoa(len(out))
+		out = append(out, Document{Content: content, Metadata: md})
+	}
+	return out
+}
+
+// windows groups units into spans of at most size units, consecutive spans sharing overlap units.
+// bounds holds the byte offsets of the units, plus the end offset of the last one.
+func windows(bounds []int, size, overlap int) []span {
+	units := len(bounds) - 1
+	size = max(size, 1)
+	overlap = min(max(overlap, 0), size-1)
+	var out []span
+	for start := 0; start < units; start += size - overlap {
+		end := min(start+size, units)
+		out = append(out, span{start: bounds[start], end: bounds[end], length: end - start})
+		if end == units {
+			break
+		}
+	}
+	return out
+}
+
+// CharacterSplitter cuts documents into fixed windows of characters, consecutive chunks overlapping.
+type CharacterSplitter struct {
+	size, overlap int
+}
+
+// NewCharacterSplitter returns a CharacterSplitter cutting every size characters,
+// consecutive chunks sharing overlap characters. Overlap is capped below size.
+func NewCharacterSplitter(size, overlap int) *CharacterSplitter {
+	return &CharacterSplitter{size: size, overlap: overlap}
+}
+
+func (s *CharacterSplitter) Split(doc Document) []Document {
+	bounds := make([]int, 0, utf8.RuneCountInString(doc.Content)+1)
+	for i := range doc.Content {
+		bounds = append(bounds, i)
+	}
+	bounds = append(bounds, len(doc.Content))
+	return chunks(doc, windows(bounds, s.size, s.overlap))
+}
+
+// TokenSplitter cuts documents into fixed windows of tokens, consecutive chunks overlapping.
+type TokenSplitter struct {
+	tokenizer     model.Tokenizer
+	size, overlap int
+}
+
+// NewTokenSplitter returns a TokenSplitter cutting every size tokens of tokenizer,
+// consecutive chunks sharing overlap tokens. Overlap is capped below size.
+func NewTokenSplitter(tokenizer model.Tokenizer, size, overlap int) *TokenSplitter {
+	return &TokenSplitter{tokenizer: tokenizer, size: size, overlap: overlap}
+}
+
+func (s *TokenSplitter) Split(doc Document) []Document {
+	tokens := s.tokenizer.Tokens(doc.Content)
+	bounds := make([]int, 0, len(tokens)+1)
+	offset := 0
+	for _, t := range tokens {
+		bounds = append(bounds, offset)
+		offset += len(t)
+	}
+	bounds = append(bounds, offset)
+	return chunks(doc, windows(bounds, s.size, s.overlap))
+}
+
+// RecursiveSplitter cuts documents on the coarsest separator keeping pieces under the size,
+// falling back to finer separators for oversized pieces, then packs pieces into chunks.
+type RecursiveSplitter struct {
+	size, overlap int
+	separators    []string
+	length        func(string) int
+}
+
+// RecursiveOption configures a [RecursiveSplitter]
+type RecursiveOption func(*RecursiveSplitter)
+
+// WithSeparators sets the separators tried in order. An empty separator splits between characters.
+func WithSeparators(separators ...string) RecursiveOption {
+	return func(s *RecursiveSplitter) {
+		s.separators = separators
+	}
+}
+
+// WithTokenizer measures sizes in tokens of tokenizer instead of characters.
+func WithTokenizer(tokenizer model.Tokenizer) RecursiveOption {
+	return func(s *RecursiveSplitter) {
+		s.length = func(text string) int { return model.CountTokens(tokenizer, text) }
+	}
+}
+
+// NewRecursiveSplitter returns a RecursiveSplitter producing chunks of at most size characters,
+// consecutive chunks sharing up to overlap characters. It splits on [DefaultSeparators] by default.
+func NewRecursiveSplitter(size, overlap int, opts ...RecursiveOption) *RecursiveSplitter {
+	s := &RecursiveSplitter{
+		size:       max(size, 1),
+		overlap:    overlap,
+		separators: DefaultSeparators,
+		length:     utf8.RuneCountInString,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.overlap = min(max(s.overlap, 0), s.size-1)
+	return s
+}
+
+func (s *RecursiveSplitter) Split(doc Document) []Document {
+	pieces := s.pieces(doc.Content, 0, s.separators)
+	return chunks(doc, s.merge(pieces))
+}
+
+// pieces splits text, found at base, into spans no longer than the size when possible.
+// Separators stay attached to the end of the preceding piece.
+func (s *RecursiveSplitter) pieces(text string, base int, separators []string) []span {
+	if l := s.length(text); l <= s.size {
+		return []span{{start: base, end: base + len(text), length: l}}
+	}
+	sep, rest := "", []string(nil)
+	for i, c := range separators {
+		if c == "" || strings.Contains(text, c) {
+			sep, rest = c, separators[i+1:]
+			break
+		}
+	}
+
+	var out []span
+	if sep == "" {
+		for i, r := range text {
+			size := utf8.RuneLen(r)
+			out = append(out, span{start: base + i, end: base + i + size, length: s.length(text[i : i+size])})
+		}
+		return out
+	}
+	for start := 0; start < len(text); {
+		end := strings.Index(text[start:], sep)
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += start + len(sep)
+		}
+		piece := text[start:end]
+		if l := s.length(piece); l <= s.size || len(rest) == 0 {
+			out = append(out, span{start: base + start, end: base + end, length: l})
+		} else {
+			out = append(out, s.pieces(piece, base+start, rest)...)
+		}
+		start = end
+	}
+	return out
+}
+
+// merge packs consecutive pieces into spans of at most size, each starting with
+// up to overlap of the previous span tail.
+func (s *RecursiveSplitter) merge(pieces []span) []span {
+	var (
+		out     []span
+		current []span
+		total   int
+	)
+	for _, p := range pieces {
+		if total+p.length > s.size && len(current) > 0 {
+			out = append(out, span{start: current[0].start, end: current[len(current)-1].end, length: total})
+			for len(current) > 0 && (total > s.overlap || total+p.length > s.size) {
+				total -= current[0].length
+				current = current[1:]
+			}
+		}
+		current = append(current, p)
+		total += p.length
+	}
+	if len(current) > 0 {
+		out = append(out, span{start: current[0].start, end: current[len(current)-1].end, length: total})
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"nyxze/fayth/model"
+)
+
+// Errors
+var (
+	ErrInvalidDataset = errors.New("eval: invalid dataset")
+	ErrInvalidScore   = errors.New("eval: invalid score")
+)
+
+// Example is one dataset entry.
+type Example struct {
+	// ID identifies the example in reports, the line number is used when empty
+	ID string `json:"id"`
+	// Input is the user message sent to the model
+	Input string `json:"input"`
+	// Expected is the reference output, used by scorers comparing against it
+	Expected string `json:"expected,omitempty"`
+	// Metadata holds free-form attributes, such as a category
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// Messages returns the conversation sent to the model for the example by default.
+func (e Example) Messages() []model.Message {
+	return []model.Message{model.NewTextMessage(model.User, e.Input)}
+}
+
+// LoadDataset reads a JSONL dataset file, see [ReadDataset].
+func LoadDataset(path string) ([]Example, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadDataset(f)
+}
+
+// ReadDataset reads one JSON [Example] per line. Blank lines are skipped,
+// and examples without an ID are given their line number.
+func ReadDataset(r io.Reader) ([]Example, error) {
+	var examples []Example
+	seen := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var ex Example
+		if err := json.Unmarshal([]byte(text), &ex); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidDataset, line, err)
+		}
+		if ex.ID == "" {
+			ex.ID = strconv.Itoa(line)
+		}
+		if prev, ok := seen[ex.ID]; ok {
+			return nil, fmt.Errorf("%w: line %d: duplicate id %q of line %d", ErrInvalidDataset, line, ex.ID, prev)
+		}
+		seen[ex.ID] = line
+		examples = append(examples, ex)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return examples, nil
+}
@@ -0,0 +1,143 @@
+// Package eval runs a [model.Model] over a dataset of examples and scores its answers,
+// producing a report that can be compared across prompt or model changes.
+package eval
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"nyxze/fayth/model"
+)
+
+// Trace records the evaluation of one example.
+type Trace struct {
+	Example  Example       `json:"example"`
+	Output   string        `json:"output"`
+	Duration time.Duration `json:"duration"`
+	Scores   []Score       `json:"scores,omitempty"`
+	// Error is set when the generation failed, the example then fails every scorer
+	Error string `json:"error,omitempty"`
+}
+
+// Passed reports whether the example was generated and passed every scorer.
+func (t Trace) Passed() bool {
+	if t.Error != "" {
+		return false
+	}
+	for _, s := range t.Scores {
+		if !s.Pass {
+			return false
+		}
+	}
+	return true
+}
+
+// Runner evaluates a model over datasets.
+type Runner struct {
+	model       model.Model
+	scorers     []Scorer
+	concurrency int
+	prompt      func(Example) ([]model.Message, error)
+	options     []model.ModelOption
+	name        string
+}
+
+// Option configures a [Runner]
+type Option func(*Runner)
+
+// WithConcurrency bounds the number of examples evaluated at once, 4 by default.
+func WithConcurrency(n int) Option {
+	return func(r *Runner) {
+		r.concurrency = max(n, 1)
+	}
+}
+
+// WithPrompt sets how the conversation is built for an example, such as by
+// rendering a prompt template. The default is [Example.Messages].
+func WithPrompt(f func(Example) ([]model.Message, error)) Option {
+	return func(r *Runner) {
+		r.prompt = f
+	}
+}
+
+// WithModelOptions sets the options of the evaluated calls.
+func WithModelOptions(opts ...model.ModelOption) Option {
+	return func(r *Runner) {
+		r.options = append(r.options, opts...)
+	}
+}
+
+// WithName sets the name of the reports, such as the prompt version under test.
+func WithName(name string) Option {
+	return func(r *Runner) {
+		r.name = name
+	}
+}
+
+// New returns a Runner generating with m and grading with scorers.
+func New(m model.Model, scorers []Scorer, opts ...Option) *Runner {
+	r := &Runner{
+		model:       m,
+		scorers:     scorers,
+		concurrency: 4,
+		prompt: func(ex Example) ([]model.Message, error) {
+			return ex.Messages(), nil
+		},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run evaluates every example and returns the report. Generation and scorer failures
+// are recorded in the traces; the error is only set when ctx ends before the run completes,
+// along with the partial report.
+func (r *Runner) Run(ctx context.Context, examples []Example) (*Report, error) {
+	started := time.Now()
+	traces := make([]Trace, len(examples))
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, r.concurrency)
+	)
+	for i, ex := range examples {
+		sem <- struct{}{}
+		if err := ctx.Err(); err != nil {
+			traces[i] = Trace{Example: ex, Error: err.Error()}
+			<-sem
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			traces[i] = r.evaluate(ctx, ex)
+		}()
+	}
+	wg.Wait()
+	return newReport(r.name, r.scorers, traces, started), ctx.Err()
+}
+
+// evaluate generates the output of ex and scores it.
+func (r *Runner) evaluate(ctx context.Context, ex Example) Trace {
+	t := Trace{Example: ex}
+	start := time.Now()
+	messages, err := r.prompt(ex)
+	if err == nil {
+		t.Output, err = model.GenerateText(ctx, r.model, messages, r.options...)
+	}
+	t.Duration = time.Since(start)
+	if err != nil {
+		t.Error = err.Error()
+		return t
+	}
+	for _, scorer := range r.scorers {
+		s, err := scorer.Score(ctx, ex, t.Output)
+		if err != nil {
+			s = Score{Error: err.Error()}
+		}
+		s.Scorer = scorer.Name()
+		t.Scores = append(t.Scores, s)
+	}
+	return t
+}
@@ -0,0 +1,211 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"nyxze/fayth/model"
+)
+
+// echoModel answers each input from a table, failing on unknown inputs
+type echoModel struct {
+	answers map[string]string
+	calls   atomic.Int32
+}
+
+func (m *echoModel) Generate(_ context.Context, messages []model.Message, _ ...model.ModelOption) (*model.Generation, error) {
+	m.calls.Add(1)
+	answer, ok := m.answers[messages[len(messages)-1].Text()]
+	if !ok {
+		return nil, errors.New("no answer")
+	}
+	return model.NewGeneration([]model.Message{model.NewTextMessage(model.Assistant, answer)}), nil
+}
+
+// vectorEmbedder embeds texts from a table
+type vectorEmbedder map[string][]float32
+
+func (e vectorEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		out[i] = e[t]
+	}
+	return out, nil
+}
+
+func TestReadDataset(t *testing.T) {
+	data := `{"id": "capital", "input": "Capital of France?", "expected": "Paris"}
+
+{"input": "2+2?", "expected": "4", "metadata": {"category": "math"}}
+`
+	examples, err := ReadDataset(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadDataset() unexpected error: %v", err)
+	}
+	if len(examples) != 2 || examples[0].ID != "capital" || examples[1].ID != "3" || examples[1].Metadata["category"] != "math" {
+		t.Errorf("ReadDataset() = %+v", examples)
+	}
+
+	for _, bad := range []string{`{"input": `, "{\"id\": \"a\"}\n{\"id\": \"a\"}"} {
+		if _, err := ReadDataset(strings.NewReader(bad)); !errors.Is(err, ErrInvalidDataset) {
+			t.Errorf("ReadDataset(%q) error = %v, want ErrInvalidDataset", bad, err)
+		}
+	}
+}
+
+func TestScorers(t *testing.T) {
+	embedder := vectorEmbedder{"Paris": {1, 0}, "paris, France": {0.9, 0.1}, "Lyon": {0, 1}}
+	tests := []struct {
+		name     string
+		scorer   Scorer
+		expected string
+		output   string
+		value    float64
+		pass     bool
+		err      error
+	}{
+		{"Exact match", ExactMatch(), "Paris", " Paris\n", 1, true, nil},
+		{"Exact mismatch", ExactMatch(), "Paris", "paris", 0, false, nil},
+		{"Regexp", Regexp(regexp.MustCompile(`(?i)\bparis\b`)), "", "It is paris.", 1, true, nil},
+		{"Regexp mismatch", Regexp(regexp.MustCompile(`\d+`)), "", "none", 0, false, nil},
+		{"JSON document", JSONField(), `{"a": 1, "b": [1, 2]}`, "```json\n{\"b\": [1, 2], \"a\": 1}\n```", 1, true, nil},
+		{"JSON fields", JSONField("name", "tags.0"), `{"name": "go", "tags": ["x"]}`, `{"name": "go", "tags": ["y"]}`, 0.5, false, nil},
+		{"JSON missing field", JSONField("name"), `{"name": "go"}`, `{"title": "go"}`, 0, false, nil},
+		{"JSON truncated output", JSONField("name"), `{"name": "go"}`, `{"name": "go`, 1, true, nil},
+		{"JSON invalid expected", JSONField(), `Paris`, `{}`, 0, false, ErrInvalidScore},
+		{"Similar", Similarity(embedder, 0.9), "Paris", "paris, France", 0.9939, true, nil},
+		{"Dissimilar", Similarity(embedder, 0.9), "Paris", "Lyon", 0, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := tt.scorer.Score(context.Background(), Example{ID: "x", Expected: tt.expected}, tt.output)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Score() error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if s.Pass != tt.pass || s.Value < tt.value-0.001 || s.Value > tt.value+0.001 {
+				t.Errorf("Score() = %+v, want value %v and pass %v", s, tt.value, tt.pass)
+			}
+			if !s.Pass && s.Reason == "" {
+				t.Error("Score() failing without a reason")
+			}
+		})
+	}
+}
+
+func TestJudge(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		opts   []JudgeOption
+		value  float64
+		pass   bool
+		err    error
+	}{
+		{"Top grade", `{"reasoning": "correct", "score": 5}`, nil, 1, true, nil},
+		{"Below pass", `{"reasoning": "vague", "score": 4}`, nil, 0.75, false, nil},
+		{"Pass score", `{"reasoning": "vague", "score": 4}`, []JudgeOption{WithPassScore(4)}, 0.75, true, nil},
+		{"Scale", `{"reasoning": "ok", "score": 6}`, []JudgeOption{WithScale(10)}, 5.0 / 9, false, nil},
+		{"Out of scale", `{"score": 9}`, nil, 0, false, ErrInvalidScore},
+		{"Not JSON", `Great answer!`, nil, 0, false, ErrInvalidScore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			judge := NewJudge(&echoModel{answers: map[string]string{
+				"Input:\nCapital of France?\n\nReference answer:\nParis\n\nAnswer to grade:\nParis.": tt.answer,
+			}}, "The answer names the right city.", tt.opts...)
+			s, err := judge.Score(context.Background(), Example{Input: "Capital of France?", Expected: "Paris"}, "Paris.")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Score() error = %v, want %v", err, tt.err)
+			}
+			if err == nil && (s.Pass != tt.pass || s.Value != tt.value || s.Reason == "") {
+				t.Errorf("Score() = %+v, want value %v and pass %v", s, tt.value, tt.pass)
+			}
+		})
+	}
+}
+
+func TestRunner(t *testing.T) {
+	examples := []Example{
+		{ID: "fr", Input: "Capital of France?", Expected: "Paris"},
+		{ID: "de", Input: "Capital of Germany?", Expected: "Berlin"},
+		{ID: "it", Input: "Capital of Italy?", Expected: "Rome"},
+		{ID: "es", Input: "Capital of Spain?", Expected: "Madrid"},
+	}
+	llm := &echoModel{answers: map[string]string{
+		"Capital of France?":  "Paris",
+		"Capital of Germany?": "Berlin",
+		"Capital of Italy?":   "It is | Rome",
+	}}
+	runner := New(llm, []Scorer{ExactMatch(), Regexp(regexp.MustCompile(`Paris|Berlin|Rome|Madrid`))},
+		WithConcurrency(2), WithName("capitals v2"))
+	report, err := runner.Run(context.Background(), examples)
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	if report.Examples != 4 || report.Passed != 2 || report.Errors != 1 || report.PassRate != 0.5 {
+		t.Errorf("Run() report = %+v", report)
+	}
+	want := []Summary{
+		{Scorer: "exact_match", Mean: 0.5, Passed: 2, PassRate: 0.5},
+		{Scorer: "regexp", Mean: 0.75, Passed: 3, PassRate: 0.75},
+	}
+	for i, s := range report.Summary {
+		if s != want[i] {
+			t.Errorf("summary %d = %+v, want %+v", i, s, want[i])
+		}
+	}
+	if report.Traces[2].Example.ID != "it" || report.Traces[2].Output != "It is | Rome" || report.Traces[3].Error == "" {
+		t.Errorf("Run() traces = %+v", report.Traces)
+	}
+
+	var md bytes.Buffer
+	if err := report.WriteMarkdown(&md); err != nil {
+		t.Fatalf("WriteMarkdown() unexpected error: %v", err)
+	}
+	for _, part := range []string{
+		"# Evaluation report: capitals v2",
+		"4 examples, 2 passed (50.0%), 1 errors",
+		"| exact_match | 0.500 | 50.0% |",
+		"| it | FAIL | 0.00 FAIL | 1.00 pass |",
+		"### it",
+		"> It is | Rome",
+		"### es",
+		"**Error**: no answer",
+	} {
+		if !strings.Contains(md.String(), part) {
+			t.Errorf("WriteMarkdown() missing %q in:\n%s", part, md.String())
+		}
+	}
+	if strings.Contains(md.String(), "### fr") {
+		t.Error("WriteMarkdown() details a passing example")
+	}
+
+	var js bytes.Buffer
+	if err := report.WriteJSON(&js); err != nil {
+		t.Fatalf("WriteJSON() unexpected error: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil || len(decoded.Traces) != 4 || decoded.Summary[1] != want[1] {
+		t.Errorf("WriteJSON() round trip = %+v, %v", decoded, err)
+	}
+}
+
+func TestRunner_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	llm := &echoModel{}
+	report, err := New(llm, []Scorer{ExactMatch()}).Run(ctx, []Example{{ID: "a"}, {ID: "b"}})
+	if !errors.Is(err, context.Canceled) || report.Errors != 2 || llm.calls.Load() != 0 {
+		t.Errorf("Run() = %+v, %v after %d calls", report, err, llm.calls.Load())
+	}
+}
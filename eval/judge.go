@@ -0,0 +1,105 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"nyxze/fayth/model"
+	"nyxze/fayth/output"
+)
+
+const judgePrompt = `You are an impartial judge grading the answer of an assistant.
+Grade the answer against the rubric below, on a scale from 1 (worst) to %d (best).
+
+Rubric:
+%s
+
+Reply with a JSON object of the form {"reasoning": "...", "score": 4}.`
+
+// Judge grades outputs by asking a model to apply a rubric.
+type Judge struct {
+	model   model.Model
+	rubric  string
+	scale   int
+	pass    int
+	options []model.ModelOption
+}
+
+// Compile type interface assertion
+var _ Scorer = (*Judge)(nil)
+
+// JudgeOption configures a [Judge]
+type JudgeOption func(*Judge)
+
+// WithScale sets the top of the grading scale, 5 by default.
+func WithScale(n int) JudgeOption {
+	return func(j *Judge) {
+		j.scale = max(n, 2)
+	}
+}
+
+// WithPassScore sets the minimal grade passing, the top of the scale by default.
+func WithPassScore(n int) JudgeOption {
+	return func(j *Judge) {
+		j.pass = n
+	}
+}
+
+// WithJudgeModelOptions sets the options of the judge calls.
+func WithJudgeModelOptions(opts ...model.ModelOption) JudgeOption {
+	return func(j *Judge) {
+		j.options = append(j.options, opts...)
+	}
+}
+
+// NewJudge returns a Judge grading with m against rubric. The judge sees the input,
+// the reference output when the example has one, and the answer.
+func NewJudge(m model.Model, rubric string, opts ...JudgeOption) *Judge {
+	j := &Judge{model: m, rubric: rubric, scale: 5}
+	for _, opt := range opts {
+		opt(j)
+	}
+	if j.pass == 0 {
+		j.pass = j.scale
+	}
+	return j
+}
+
+func (j *Judge) Name() string { return "judge" }
+
+// Score grades output, the value is the grade normalized between 0 and 1.
+func (j *Judge) Score(ctx context.Context, ex Example, out string) (Score, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Input:\n%s\n\n", ex.Input)
+	if ex.Expected != "" {
+		fmt.Fprintf(&sb, "Reference answer:\n%s\n\n", ex.Expected)
+	}
+	fmt.Fprintf(&sb, "Answer to grade:\n%s", out)
+	messages := []model.Message{
+		model.NewTextMessage(model.System, fmt.Sprintf(judgePrompt, j.scale, j.rubric)),
+		model.NewTextMessage(model.User, sb.String()),
+	}
+	opts := append([]model.ModelOption{model.WithJSONMode()}, j.options...)
+	answer, err := model.GenerateText(ctx, j.model, messages, opts...)
+	if err != nil {
+		return Score{}, err
+	}
+
+	var verdict struct {
+		Reasoning string `json:"reasoning"`
+		Score     int    `json:"score"`
+	}
+	if err := json.Unmarshal([]byte(output.Repair(answer)), &verdict); err != nil {
+		return Score{}, fmt.Errorf("%w: judge answer: %v", ErrInvalidScore, err)
+	}
+	if verdict.Score < 1 || verdict.Score > j.scale {
+		return Score{}, fmt.Errorf("%w: judge grade %d outside 1-%d", ErrInvalidScore, verdict.Score, j.scale)
+	}
+	return Score{
+		Value:  float64(verdict.Score-1) / float64(j.scale-1),
+		Pass:   verdict.Score >= j.pass,
+		Reason: verdict.Reasoning,
+	}, nil
+}
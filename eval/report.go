@@ -0,0 +1,165 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Summary aggregates the scores of one scorer over a run.
+// Examples whose generation or scoring failed count as a failing zero.
+type Summary struct {
+	Scorer   string  `json:"scorer"`
+	Mean     float64 `json:"mean"`
+	Passed   int     `json:"passed"`
+	PassRate float64 `json:"pass_rate"`
+}
+
+// Report is the outcome of a [Runner] run.
+type Report struct {
+	Name     string        `json:"name,omitempty"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	Examples int           `json:"examples"`
+	// Errors counts the examples whose generation failed
+	Errors int `json:"errors"`
+	// Passed counts the examples passing every scorer
+	Passed   int       `json:"passed"`
+	PassRate float64   `json:"pass_rate"`
+	Summary  []Summary `json:"summary"`
+	Traces   []Trace   `json:"traces"`
+}
+
+func newReport(name string, scorers []Scorer, traces []Trace, started time.Time) *Report {
+	r := &Report{
+		Name:     name,
+		Started:  started,
+		Duration: time.Since(started),
+		Examples: len(traces),
+		Summary:  make([]Summary, len(scorers)),
+		Traces:   traces,
+	}
+	for i, s := range scorers {
+		r.Summary[i].Scorer = s.Name()
+	}
+	for _, t := range traces {
+		if t.Error != "" {
+			r.Errors++
+		}
+		if t.Passed() {
+			r.Passed++
+		}
+		for i, s := range t.Scores {
+			if s.Error != "" {
+				continue
+			}
+			r.Summary[i].Mean += s.Value
+			if s.Pass {
+				r.Summary[i].Passed++
+			}
+		}
+	}
+	if n := float64(len(traces)); n > 0 {
+		r.PassRate = float64(r.Passed) / n
+		for i := range r.Summary {
+			r.Summary[i].Mean /= n
+			r.Summary[i].PassRate = float64(r.Summary[i].Passed) / n
+		}
+	}
+	return r
+}
+
+// WriteJSON writes the report, traces included, as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteMarkdown writes the report as Markdown: the aggregate scores, a table of
+// every example, and the details of the failing ones.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	title := "Evaluation report"
+	if r.Name != "" {
+		title += ": " + r.Name
+	}
+	fmt.Fprintf(&sb, "# %s\n\n", title)
+	fmt.Fprintf(&sb, "%d examples, %d passed (%.1f%%), %d errors, in %s.\n\n",
+		r.Examples, r.Passed, 100*r.PassRate, r.Errors, r.Duration.Round(time.Millisecond))
+
+	sb.WriteString("| Scorer | Mean | Pass rate |\n|---|---|---|\n")
+	for _, s := range r.Summary {
+		fmt.Fprintf(&sb, "| %s | %.3f | %.1f%% |\n", cell(s.Scorer), s.Mean, 100*s.PassRate)
+	}
+
+	sb.WriteString("\n## Examples\n\n| ID | Pass |")
+	for _, s := range r.Summary {
+		fmt.Fprintf(&sb, " %s |", cell(s.Scorer))
+	}
+	sb.WriteString("\n|---|---|" + strings.Repeat("---|", len(r.Summary)) + "\n")
+	for _, t := range r.Traces {
+		fmt.Fprintf(&sb, "| %s | %s |", cell(t.Example.ID), mark(t.Passed()))
+		for i := range r.Summary {
+			switch {
+			case i >= len(t.Scores):
+				sb.WriteString(" - |")
+			case t.Scores[i].Error != "":
+				sb.WriteString(" error |")
+			default:
+				fmt.Fprintf(&sb, " %.2f %s |", t.Scores[i].Value, mark(t.Scores[i].Pass))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	failed := false
+	for _, t := range r.Traces {
+		if t.Passed() {
+			continue
+		}
+		if !failed {
+			sb.WriteString("\n## Failures\n")
+			failed = true
+		}
+		fmt.Fprintf(&sb, "\n### %s\n\n", t.Example.ID)
+		fmt.Fprintf(&sb, "**Input**\n\n%s\n\n", quote(t.Example.Input))
+		if t.Example.Expected != "" {
+			fmt.Fprintf(&sb, "**Expected**\n\n%s\n\n", quote(t.Example.Expected))
+		}
+		if t.Error != "" {
+			fmt.Fprintf(&sb, "**Error**: %s\n", t.Error)
+			continue
+		}
+		fmt.Fprintf(&sb, "**Output**\n\n%s\n\n", quote(t.Output))
+		for _, s := range t.Scores {
+			switch {
+			case s.Error != "":
+				fmt.Fprintf(&sb, "- %s: error: %s\n", s.Scorer, s.Error)
+			case !s.Pass:
+				fmt.Fprintf(&sb, "- %s: %.2f, %s\n", s.Scorer, s.Value, s.Reason)
+			}
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func mark(pass bool) string {
+	if pass {
+		return "pass"
+	}
+	return "FAIL"
+}
+
+// cell escapes text for a table cell.
+func cell(text string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(text)
+}
+
+// quote formats text as a Markdown block quote.
+func quote(text string) string {
+	return "> " + strings.ReplaceAll(strings.TrimSpace(text), "\n", "\n> ")
+}
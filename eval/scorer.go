@@ -0,0 +1,149 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"nyxze/fayth/model"
+	"nyxze/fayth/output"
+	"nyxze/fayth/vectorstore"
+)
+
+// Score is the result of a [Scorer] on one example.
+type Score struct {
+	// Scorer is the name of the scorer
+	Scorer string `json:"scorer"`
+	// Value ranges from 0 to 1, higher is better
+	Value float64 `json:"value"`
+	// Pass reports whether the output is acceptable
+	Pass bool `json:"pass"`
+	// Reason explains the score, when the scorer gives one
+	Reason string `json:"reason,omitempty"`
+	// Error is set when the scorer failed, the score then counts as a failure
+	Error string `json:"error,omitempty"`
+}
+
+// Scorer grades the output of a model for an example.
+type Scorer interface {
+	Name() string
+	Score(ctx context.Context, ex Example, output string) (Score, error)
+}
+
+// ScorerFunc adapts a function to the [Scorer] interface.
+type ScorerFunc struct {
+	ScorerName string
+	Func       func(ctx context.Context, ex Example, output string) (Score, error)
+}
+
+func (s ScorerFunc) Name() string { return s.ScorerName }
+func (s ScorerFunc) Score(ctx context.Context, ex Example, output string) (Score, error) {
+	return s.Func(ctx, ex, output)
+}
+
+// passFail returns a score of 1 when ok, 0 otherwise.
+func passFail(ok bool, reason string) Score {
+	if ok {
+		return Score{Value: 1, Pass: true}
+	}
+	return Score{Reason: reason}
+}
+
+// ExactMatch passes when the trimmed output equals the expected output.
+func ExactMatch() Scorer {
+	return ScorerFunc{"exact_match", func(_ context.Context, ex Example, out string) (Score, error) {
+		got, want := strings.TrimSpace(out), strings.TrimSpace(ex.Expected)
+		return passFail(got == want, fmt.Sprintf("got %q, want %q", got, want)), nil
+	}}
+}
+
+// Regexp passes when the output matches re.
+func Regexp(re *regexp.Regexp) Scorer {
+	return ScorerFunc{"regexp", func(_ context.Context, _ Example, out string) (Score, error) {
+		return passFail(re.MatchString(out), fmt.Sprintf("no match for %s", re)), nil
+	}}
+}
+
+// JSONField compares the JSON output with the expected JSON at the given dotted paths,
+// such as "user.name" or "items.0", or as whole documents when no path is given.
+// The output is repaired with [output.Repair] first. The value is the share of equal fields,
+// and the score passes when all of them are equal.
+func JSONField(paths ...string) Scorer {
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+	return ScorerFunc{"json_field", func(_ context.Context, ex Example, out string) (Score, error) {
+		var want, got any
+		if err := json.Unmarshal([]byte(ex.Expected), &want); err != nil {
+			return Score{}, fmt.Errorf("%w: expected output of %s is not JSON: %v", ErrInvalidScore, ex.ID, err)
+		}
+		if err := json.Unmarshal([]byte(output.Repair(out)), &got); err != nil {
+			return Score{Reason: fmt.Sprintf("output is not JSON: %v", err)}, nil
+		}
+		var mismatched []string
+		for _, path := range paths {
+			w, _ := lookup(want, path)
+			g, ok := lookup(got, path)
+			if !ok || !reflect.DeepEqual(g, w) {
+				mismatched = append(mismatched, path)
+			}
+		}
+		s := Score{
+			Value: float64(len(paths)-len(mismatched)) / float64(len(paths)),
+			Pass:  len(mismatched) == 0,
+		}
+		if !s.Pass {
+			s.Reason = "mismatched fields: " + strings.Join(mismatched, ", ")
+		}
+		return s, nil
+	}}
+}
+
+// lookup returns the value at a dotted path of a decoded JSON document.
+func lookup(v any, path string) (any, bool) {
+	if path == "" {
+		return v, true
+	}
+	for key := range strings.SplitSeq(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = node[key]; !ok {
+				return nil, false
+			}
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// Similarity scores the cosine similarity between the embeddings of the output
+// and the expected output, passing at or above threshold.
+func Similarity(embedder model.Embedder, threshold float64) Scorer {
+	return ScorerFunc{"similarity", func(ctx context.Context, ex Example, out string) (Score, error) {
+		vectors, err := embedder.Embed(ctx, []string{out, ex.Expected})
+		if err != nil {
+			return Score{}, err
+		}
+		if len(vectors) != 2 {
+			return Score{}, fmt.Errorf("%w: embedder returned %d vectors, want 2", ErrInvalidScore, len(vectors))
+		}
+		sim := float64(vectorstore.CosineSimilarity(vectors[0], vectors[1]))
+		s := Score{Value: max(sim, 0), Pass: sim >= threshold}
+		if !s.Pass {
+			s.Reason = fmt.Sprintf("similarity %.3f below %.3f", sim, threshold)
+		}
+		return s, nil
+	}}
+}
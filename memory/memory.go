@@ -2,57 +2,91 @@ package memory
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
+	"log/slog"
 	"nyxze/fayth/model"
 	"os"
+	"path/filepath"
 )
 
 type Memory interface {
-	Save(msg []model.Message)
+	Save(msg []model.Message) error
 	Load() ([]model.Message, error)
 }
+
+// FileMemory stores the conversation as a JSON array in a file, each Save replacing the previous one.
 type FileMemory struct {
 	fileName string
+	logger   *slog.Logger
+}
+
+// Option configures a [FileMemory]
+type Option func(*FileMemory)
+
+// WithLogger sets the logger used to report save and load failures.
+func WithLogger(logger *slog.Logger) Option {
+	return func(f *FileMemory) {
+		if logger != nil {
+			f.logger = logger
+		}
+	}
 }
 
-func NewFileMemory(name string) *FileMemory {
+func NewFileMemory(name string, opts ...Option) *FileMemory {
 	fm := &FileMemory{
 		fileName: name,
+		logger:   slog.New(slog.DiscardHandler),
+	}
+	for _, opt := range opts {
+		opt(fm)
 	}
 	return fm
 }
 
-func (f *FileMemory) Save(messages []model.Message) {
-	json, err := json.Marshal(&messages)
+// Save replaces the saved conversation. The file is written aside and renamed over
+// the previous one, so an interrupted save keeps the previous conversation.
+func (f *FileMemory) Save(messages []model.Message) error {
+	data, err := json.Marshal(&messages)
 	if err != nil {
-		fmt.Println("failed to save message", err)
-		return
+		f.logger.Error("memory: failed to encode messages", "file", f.fileName, "error", err)
+		return err
 	}
-	w := f.open()
-	n, err := w.Write(json)
-	if err != nil {
-		fmt.Println("writing as failed", err)
-		return
+	if err := f.write(data); err != nil {
+		f.logger.Error("memory: failed to write messages", "file", f.fileName, "error", err)
+		return err
 	}
-	fmt.Printf("%d bytes written ", n)
-
+	f.logger.Debug("memory: messages saved", "file", f.fileName, "messages", len(messages), "bytes", len(data))
+	return nil
 }
-func (f *FileMemory) open() io.ReadWriter {
 
-	file, err := os.OpenFile(f.fileName, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+// write replaces the file with data through a temporary file in the same directory.
+func (f *FileMemory) write(data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(f.fileName), filepath.Base(f.fileName)+".*.tmp")
 	if err != nil {
-		fmt.Println("Failed to create memory file", err)
-		os.Exit(1)
+		return err
 	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.fileName)
+}
 
-	return file
-
+func (f *FileMemory) open() (*os.File, error) {
+	return os.OpenFile(f.fileName, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
 }
 func (f *FileMemory) Load() ([]model.Message, error) {
-	reader := f.open()
+	file, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
 	var message []model.Message
-	data, err := io.ReadAll(reader)
+	data, err := io.ReadAll(file)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"nyxze/fayth/model"
+)
+
+func TestFileMemory_Save(t *testing.T) {
+	dir := t.TempDir()
+	mem := NewFileMemory(filepath.Join(dir, "history.json"))
+
+	history := []model.Message{
+		model.NewTextMessage(model.User, "Hello"),
+		model.NewTextMessage(model.Assistant, "Hi there"),
+	}
+	for _, h := range [][]model.Message{history, history[:1]} {
+		if err := mem.Save(h); err != nil {
+			t.Fatalf("Save() unexpected error: %v", err)
+		}
+		got, err := mem.Load()
+		if err != nil {
+			t.Fatalf("Load() unexpected error: %v", err)
+		}
+		if len(got) != len(h) || got[0].Text() != "Hello" {
+			t.Errorf("Load() = %+v, want the saved %d messages", got, len(h))
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected only the history file left, got %v", entries)
+	}
+
+	missing := NewFileMemory(filepath.Join(dir, "missing", "history.json"))
+	if err := missing.Save(history); err == nil {
+		t.Error("Expected an error saving into a missing directory")
+	}
+}
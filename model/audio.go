@@ -0,0 +1,156 @@
+package model
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Transcriber converts speech into text.
+type Transcriber interface {
+	// Transcribe returns the transcription of the audio, in the format of [TranscriptionOptions].
+	Transcribe(ctx context.Context, audio AudioContent, opts ...TranscriptionOption) (*Transcription, error)
+}
+
+// Synthesizer converts text into speech.
+type Synthesizer interface {
+	// Synthesize returns the spoken text as it is generated, in the format of [SpeechOptions].
+	// The caller must close the returned audio.
+	Synthesize(ctx context.Context, text string, opts ...SpeechOption) (io.ReadCloser, error)
+}
+
+// TranscriptFormat is the format of a transcription.
+type TranscriptFormat string
+
+const (
+	// TranscriptText is the plain text of the speech
+	TranscriptText TranscriptFormat = "text"
+	// TranscriptJSON is the text of the speech, with its language when available
+	TranscriptJSON TranscriptFormat = "json"
+	// TranscriptVerboseJSON adds the duration and timed segments of the speech
+	TranscriptVerboseJSON TranscriptFormat = "verbose_json"
+	// TranscriptSRT is a SubRip subtitles document
+	TranscriptSRT TranscriptFormat = "srt"
+	// TranscriptVTT is a WebVTT subtitles document
+	TranscriptVTT TranscriptFormat = "vtt"
+)
+
+// Transcription is the text of a speech.
+type Transcription struct {
+	// Text is the transcribed text, or the subtitles document in the srt and vtt formats
+	Text     string
+	Language string
+	Duration time.Duration
+	// Segments are the timed parts of the speech, in the verbose_json format
+	Segments []Segment
+}
+
+// Segment is a timed part of a transcribed speech.
+type Segment struct {
+	ID    int
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// TranscriptionOptions configures a transcription.
+type TranscriptionOptions struct {
+	// Model specifies which model transcribes, if empty uses the provider default
+	Model string
+	// Language of the speech in ISO-639-1, such as "en", detected when empty
+	Language string
+	// Prompt guides the style of the transcription, or continues a previous segment
+	Prompt string
+	// Format of the transcription, if empty uses the provider default
+	Format TranscriptFormat
+	// Temperature of the sampling between 0 and 1, if zero uses the provider default
+	Temperature float64
+}
+
+type TranscriptionOption func(*TranscriptionOptions)
+
+// WithTranscriptionModel sets the model transcribing the speech
+func WithTranscriptionModel(model string) TranscriptionOption {
+	return func(o *TranscriptionOptions) {
+		o.Model = model
+	}
+}
+
+// WithLanguage sets the language of the speech
+func WithLanguage(language string) TranscriptionOption {
+	return func(o *TranscriptionOptions) {
+		o.Language = language
+	}
+}
+
+// WithTranscriptionPrompt sets the prompt guiding the transcription
+func WithTranscriptionPrompt(prompt string) TranscriptionOption {
+	return func(o *TranscriptionOptions) {
+		o.Prompt = prompt
+	}
+}
+
+// WithTranscriptFormat sets the format of the transcription
+func WithTranscriptFormat(format TranscriptFormat) TranscriptionOption {
+	return func(o *TranscriptionOptions) {
+		o.Format = format
+	}
+}
+
+// WithTranscriptionTemperature sets the sampling temperature of the transcription
+func WithTranscriptionTemperature(temperature float64) TranscriptionOption {
+	return func(o *TranscriptionOptions) {
+		o.Temperature = temperature
+	}
+}
+
+// SpeechOptions configures a speech synthesis.
+type SpeechOptions struct {
+	// Model specifies which model speaks, if empty uses the provider default
+	Model string
+	// Voice speaking the text, such as "alloy"
+	Voice string
+	// Format is the encoding of the audio, such as "mp3", "wav" or "pcm"
+	Format string
+	// Speed of the speech, 1 is the normal speed
+	Speed float64
+	// Instructions control the tone of the voice, on models supporting them
+	Instructions string
+}
+
+type SpeechOption func(*SpeechOptions)
+
+// WithSpeechModel sets the model speaking the text
+func WithSpeechModel(model string) SpeechOption {
+	return func(o *SpeechOptions) {
+		o.Model = model
+	}
+}
+
+// WithVoice sets the voice speaking the text
+func WithVoice(voice string) SpeechOption {
+	return func(o *SpeechOptions) {
+		o.Voice = voice
+	}
+}
+
+// WithSpeechFormat sets the encoding of the audio
+func WithSpeechFormat(format string) SpeechOption {
+	return func(o *SpeechOptions) {
+		o.Format = format
+	}
+}
+
+// WithSpeed sets the speed of the speech
+func WithSpeed(speed float64) SpeechOption {
+	return func(o *SpeechOptions) {
+		o.Speed = speed
+	}
+}
+
+// WithInstructions sets the instructions controlling the tone of the voice
+func WithInstructions(instructions string) SpeechOption {
+	return func(o *SpeechOptions) {
+		o.Instructions = instructions
+	}
+}
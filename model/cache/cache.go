@@ -0,0 +1,195 @@
+// Package cache provides a [model.Model] wrapper replaying previous generations
+// for identical requests.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+
+	"nyxze/fayth/model"
+)
+
+// Entry is a cached generation.
+// Chunks holds the streamed deltas when the generation was streamed,
+// Messages holds the complete messages.
+type Entry struct {
+	Messages []model.Message `json:"messages,omitempty"`
+	Chunks   []model.Message `json:"chunks,omitempty"`
+}
+
+// Backend stores cache entries by key.
+type Backend interface {
+	// Get returns the entry stored under key, reporting whether it was found.
+	Get(ctx context.Context, key string) (Entry, bool, error)
+	// Set stores e under key, replacing any previous entry.
+	Set(ctx context.Context, key string, e Entry) error
+}
+
+type cachedModel struct {
+	next    model.Model
+	backend Backend
+	// sampled allows caching of calls made with a non-zero temperature
+	sampled bool
+	// defaults are the options next is configured with
+	defaults []model.ModelOption
+	logger   *slog.Logger
+}
+
+// Compile type interface assertion
+var _ model.Model = (*cachedModel)(nil)
+
+// Option configures the caching model
+type Option func(*cachedModel)
+
+// WithSampled enables caching of calls made with a temperature above zero.
+// By default, only deterministic calls are cached.
+func WithSampled(enabled bool) Option {
+	return func(c *cachedModel) {
+		c.sampled = enabled
+	}
+}
+
+// WithDefaults declares the options next is configured with, such as its default model
+// or temperature. Call options apply over them to decide whether a call is sampled and to
+// compute its key, so a model sampling by default is not cached, and models with different
+// defaults do not share entries. A zero temperature is taken as deterministic: declare the
+// temperature of models sampling at their provider default.
+func WithDefaults(opts ...model.ModelOption) Option {
+	return func(c *cachedModel) {
+		c.defaults = opts
+	}
+}
+
+// WithLogger sets the logger reporting cache hits, misses and backend failures.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *cachedModel) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// New returns a [model.Model] serving repeated requests from backend,
+// forwarding cache misses to next.
+func New(next model.Model, backend Backend, opts ...Option) model.Model {
+	c := &cachedModel{
+		next:    next,
+		backend: backend,
+		logger:  slog.New(slog.DiscardHandler),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *cachedModel) Generate(ctx context.Context, m []model.Message, opts ...model.ModelOption) (*model.Generation, error) {
+	options := model.MergeOptions(model.MergeOptions(model.ModelOptions{}, c.defaults...), opts...)
+	if options.Temperature > 0 && !c.sampled {
+		return c.next.Generate(ctx, m, opts...)
+	}
+
+	key, err := Key(m, options)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok, err := c.backend.Get(ctx, key)
+	if err != nil {
+		c.logger.WarnContext(ctx, "cache: lookup failed", "key", key, "error", err)
+	}
+	if ok {
+		c.logger.DebugContext(ctx, "cache: hit", "key", key)
+		return replay(entry, options), nil
+	}
+	c.logger.DebugContext(ctx, "cache: miss", "key", key)
+
+	gen, err := c.next.Generate(ctx, m, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if options.Stream {
+		return record(gen, func(e Entry) { c.store(ctx, key, e) }), nil
+	}
+
+	var messages []model.Message
+	for msg := range gen.Messages() {
+		messages = append(messages, msg)
+	}
+	if gen.Error() != nil {
+		return nil, gen.Error()
+	}
+	c.store(ctx, key, Entry{Messages: messages})
+	out := model.NewGeneration(messages)
+	out.SetUsage(gen.Usage())
+	return out, nil
+}
+
+// record forwards the streamed chunks of gen, passing them to store once the stream completes successfully.
+func record(gen *model.Generation, store func(Entry)) *model.Generation {
+	out := &model.Generation{}
+	out.MsgIter = func(yield func(model.Message) bool) {
+		var chunks []model.Message
+		for msg := range gen.Messages() {
+			chunks = append(chunks, msg)
+			if !yield(msg) {
+				return
+			}
+		}
+		out.SetUsage(gen.Usage())
+		if out.Err = gen.Error(); out.Err != nil {
+			return
+		}
+		store(Entry{Chunks: chunks, Messages: model.MergeChunks(chunks)})
+	}
+	return out
+}
+
+func (c *cachedModel) store(ctx context.Context, key string, e Entry) {
+	if err := c.backend.Set(ctx, key, e); err != nil {
+		c.logger.WarnContext(ctx, "cache: store failed", "key", key, "error", err)
+	}
+}
+
+// replay builds a Generation from a cached entry, streaming it when requested.
+func replay(e Entry, options model.ModelOptions) *model.Generation {
+	if !options.Stream {
+		if len(e.Messages) == 0 {
+			return model.NewGeneration(model.MergeChunks(e.Chunks))
+		}
+		return model.NewGeneration(e.Messages)
+	}
+	chunks := e.Chunks
+	if len(chunks) == 0 {
+		chunks = e.Messages
+	}
+	return model.NewGenerationWithStream(func(yield func(model.Message) bool) {
+		for _, msg := range chunks {
+			for _, h := range options.MessageHandler {
+				h(msg)
+			}
+			if !yield(msg) {
+				return
+			}
+		}
+	})
+}
+
+// Key returns the canonical cache key of a request.
+// Options not affecting the output, such as streaming or the user identifier, are ignored.
+func Key(m []model.Message, options model.ModelOptions) (string, error) {
+	options.Stream = false
+	options.User = ""
+	b, err := json.Marshal(struct {
+		Messages []model.Message    `json:"messages"`
+		Options  model.ModelOptions `json:"options"`
+	}{m, options})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
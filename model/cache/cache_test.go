@@ -0,0 +1,238 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"nyxze/fayth/model"
+)
+
+// countingModel streams its reply word by word and counts calls
+type countingModel struct {
+	calls  int
+	chunks []string
+}
+
+func (c *countingModel) Generate(ctx context.Context, m []model.Message, opts ...model.ModelOption) (*model.Generation, error) {
+	c.calls++
+	options := model.MergeOptions(model.ModelOptions{}, opts...)
+	if !options.Stream {
+		text := ""
+		for _, chunk := range c.chunks {
+			text += chunk
+		}
+		return model.NewGeneration([]model.Message{model.NewTextMessage(model.Assistant, text)}), nil
+	}
+	return model.NewGenerationWithStream(func(yield func(model.Message) bool) {
+		for _, chunk := range c.chunks {
+			if !yield(model.NewTextMessage(model.Assistant, chunk)) {
+				return
+			}
+		}
+	}), nil
+}
+
+func collect(t *testing.T, gen *model.Generation) []string {
+	t.Helper()
+	var out []string
+	for m := range gen.Messages() {
+		out = append(out, m.Text())
+	}
+	if gen.Error() != nil {
+		t.Fatalf("Unexpected generation error: %v", gen.Error())
+	}
+	return out
+}
+
+func TestCache_NonStreaming(t *testing.T) {
+	next := &countingModel{chunks: []string{"Hello", " world"}}
+	cached := New(next, NewLRU(10))
+	input := []model.Message{model.NewTextMessage(model.User, "Hi")}
+
+	for range 3 {
+		gen, err := cached.Generate(context.Background(), input, model.WithModel("gpt-4"))
+		if err != nil {
+			t.Fatalf("Generate() unexpected error: %v", err)
+		}
+		if got := collect(t, gen); len(got) != 1 || got[0] != "Hello world" {
+			t.Errorf("Generate() got %q", got)
+		}
+	}
+	if next.calls != 1 {
+		t.Errorf("Expected 1 underlying call, got %d", next.calls)
+	}
+
+	// A different model is a different key
+	if _, err := cached.Generate(context.Background(), input, model.WithModel("gpt-4o")); err != nil {
+		t.Fatalf("Generate() unexpected error: %v", err)
+	}
+	if next.calls != 2 {
+		t.Errorf("Expected 2 underlying calls, got %d", next.calls)
+	}
+}
+
+func TestCache_StreamingReplay(t *testing.T) {
+	next := &countingModel{chunks: []string{"a", "b", "c"}}
+	cached := New(next, NewLRU(10))
+	input := []model.Message{model.NewTextMessage(model.User, "Hi")}
+
+	first, err := cached.Generate(context.Background(), input, model.WithStream(true))
+	if err != nil {
+		t.Fatalf("Generate() unexpected error: %v", err)
+	}
+	if got := collect(t, first); len(got) != 3 {
+		t.Fatalf("Expected 3 chunks, got %q", got)
+	}
+
+	var handled int
+	second, err := cached.Generate(context.Background(), input, model.WithStream(true, func(model.Message) { handled++ }))
+	if err != nil {
+		t.Fatalf("Generate() unexpected error: %v", err)
+	}
+	if got := collect(t, second); len(got) != 3 || got[0] != "a" || got[2] != "c" {
+		t.Errorf("Expected replayed chunks [a b c], got %q", got)
+	}
+	if handled != 3 {
+		t.Errorf("Expected handler to be called 3 times, got %d", handled)
+	}
+
+	// Same request without streaming, served merged from the streamed entry
+	third, err := cached.Generate(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Generate() unexpected error: %v", err)
+	}
+	if got := collect(t, third); len(got) != 1 || got[0] != "abc" {
+		t.Errorf("Expected merged message [abc], got %q", got)
+	}
+	if next.calls != 1 {
+		t.Errorf("Expected 1 underlying call, got %d", next.calls)
+	}
+}
+
+func TestCache_SkipsSampledCalls(t *testing.T) {
+	input := []model.Message{model.NewTextMessage(model.User, "Hi")}
+	tests := map[string]struct {
+		opts      []Option
+		wantCalls int
+	}{
+		"Sampled calls bypass the cache": {wantCalls: 2},
+		"Sampled calls cached when enabled": {
+			opts:      []Option{WithSampled(true)},
+			wantCalls: 1,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			next := &countingModel{chunks: []string{"x"}}
+			cached := New(next, NewLRU(10), tt.opts...)
+			for range 2 {
+				gen, err := cached.Generate(context.Background(), input, model.WithTemperature(0.7))
+				if err != nil {
+					t.Fatalf("Generate() unexpected error: %v", err)
+				}
+				collect(t, gen)
+			}
+			if next.calls != tt.wantCalls {
+				t.Errorf("Expected %d underlying calls, got %d", tt.wantCalls, next.calls)
+			}
+		})
+	}
+}
+
+func TestCache_Defaults(t *testing.T) {
+	input := []model.Message{model.NewTextMessage(model.User, "Hi")}
+
+	// A model sampling by default is not cached
+	next := &countingModel{chunks: []string{"x"}}
+	cached := New(next, NewLRU(10), WithDefaults(model.WithTemperature(1)))
+	for range 2 {
+		gen, err := cached.Generate(context.Background(), input)
+		if err != nil {
+			t.Fatalf("Generate() unexpected error: %v", err)
+		}
+		collect(t, gen)
+	}
+	if next.calls != 2 {
+		t.Errorf("Expected calls sampled by default to bypass the cache, got %d underlying calls", next.calls)
+	}
+
+	// Models with different defaults do not share entries
+	backend := NewLRU(10)
+	small := &countingModel{chunks: []string{"small"}}
+	large := &countingModel{chunks: []string{"large"}}
+	for _, m := range []model.Model{
+		New(small, backend, WithDefaults(model.WithModel("small"))),
+		New(large, backend, WithDefaults(model.WithModel("large"))),
+	} {
+		gen, err := m.Generate(context.Background(), input)
+		if err != nil {
+			t.Fatalf("Generate() unexpected error: %v", err)
+		}
+		collect(t, gen)
+	}
+	if small.calls != 1 || large.calls != 1 {
+		t.Errorf("Expected one call per default model, got %d and %d", small.calls, large.calls)
+	}
+}
+
+func TestKey_IgnoresStreamAndUser(t *testing.T) {
+	input := []model.Message{model.NewTextMessage(model.User, "Hi")}
+	a, err := Key(input, model.MergeOptions(model.ModelOptions{}, model.WithStream(true), model.WithUser("alice")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Key(input, model.ModelOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Errorf("Expected identical keys, got %s and %s", a, b)
+	}
+}
+
+func TestLRU_Eviction(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(2)
+	lru.Set(ctx, "a", Entry{})
+	lru.Set(ctx, "b", Entry{})
+	lru.Get(ctx, "a") // "b" becomes the least recently used
+	lru.Set(ctx, "c", Entry{})
+
+	if _, ok, _ := lru.Get(ctx, "b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+	for _, k := range []string{"a", "c"} {
+		if _, ok, _ := lru.Get(ctx, k); !ok {
+			t.Errorf("Expected %s to be kept", k)
+		}
+	}
+	if lru.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", lru.Len())
+	}
+}
+
+func TestDisk_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	disk, err := NewDisk(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := disk.Get(ctx, "missing"); ok || err != nil {
+		t.Fatalf("Expected clean miss, got ok=%v err=%v", ok, err)
+	}
+
+	chunks := []model.Message{
+		model.NewTextMessage(model.Assistant, "Hel"),
+		model.NewTextMessage(model.Assistant, "lo"),
+	}
+	if err := disk.Set(ctx, "k", Entry{Chunks: chunks, Messages: model.MergeChunks(chunks)}); err != nil {
+		t.Fatalf("Set() unexpected error: %v", err)
+	}
+	e, ok, err := disk.Get(ctx, "k")
+	if err != nil || !ok {
+		t.Fatalf("Expected hit, got ok=%v err=%v", ok, err)
+	}
+	if len(e.Chunks) != 2 || e.Messages[0].Text() != "Hello" {
+		t.Errorf("Unexpected entry: %+v", e)
+	}
+}
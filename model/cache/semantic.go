@@ -0,0 +1,211 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"nyxze/fayth/model"
+	"nyxze/fayth/vectorstore"
+)
+
+type namespaceKey struct{}
+
+// WithNamespace returns a copy of ctx scoping semantic cache entries to namespace,
+// such as a tenant identifier. Entries are never shared across namespaces.
+func WithNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, namespace)
+}
+
+// Namespace returns the namespace carried by ctx, or an empty string.
+func Namespace(ctx context.Context) string {
+	ns, _ := ctx.Value(namespaceKey{}).(string)
+	return ns
+}
+
+type semanticModel struct {
+	next      model.Model
+	embedder  model.Embedder
+	threshold float32
+	ttl       time.Duration
+	logger    *slog.Logger
+	now       func() time.Time
+	// defaults are the options next is configured with
+	defaults []model.ModelOption
+
+	mu    sync.RWMutex
+	index map[string][]semanticEntry
+}
+
+type semanticEntry struct {
+	vector  []float32
+	entry   Entry
+	expires time.Time
+}
+
+// Compile type interface assertion
+var _ model.Model = (*semanticModel)(nil)
+
+// SemanticOption configures the semantic caching model
+type SemanticOption func(*semanticModel)
+
+// WithThreshold sets the minimum cosine similarity for a cached entry to be returned.
+// The default is 0.95.
+func WithThreshold(threshold float32) SemanticOption {
+	return func(s *semanticModel) {
+		s.threshold = threshold
+	}
+}
+
+// WithSemanticDefaults declares the options next is configured with, such as its default model,
+// so that models with different defaults do not share entries. See [WithDefaults].
+func WithSemanticDefaults(opts ...model.ModelOption) SemanticOption {
+	return func(s *semanticModel) {
+		s.defaults = opts
+	}
+}
+
+// WithTTL sets how long entries stay valid. Entries never expire when ttl is zero.
+func WithTTL(ttl time.Duration) SemanticOption {
+	return func(s *semanticModel) {
+		s.ttl = ttl
+	}
+}
+
+// WithSemanticLogger sets the logger reporting hits, misses and embedding failures.
+func WithSemanticLogger(logger *slog.Logger) SemanticOption {
+	return func(s *semanticModel) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
+// NewSemantic returns a [model.Model] answering from a previous generation when
+// the last user message is similar enough to an earlier one, forwarding other requests to next.
+//
+// Prompts are embedded with embedder and held in an in-memory index, scoped by the namespace
+// carried by the context, by the model options and by the other messages of the conversation,
+// such as the system prompt and earlier turns. Sampled calls, with a temperature above zero
+// or several choices, are never cached.
+func NewSemantic(next model.Model, embedder model.Embedder, opts ...SemanticOption) model.Model {
+	s := &semanticModel{
+		next:      next,
+		embedder:  embedder,
+		threshold: 0.95,
+		logger:    slog.New(slog.DiscardHandler),
+		now:       time.Now,
+		index:     make(map[string][]semanticEntry),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *semanticModel) Generate(ctx context.Context, m []model.Message, opts ...model.ModelOption) (*model.Generation, error) {
+	options := model.MergeOptions(model.MergeOptions(model.ModelOptions{}, s.defaults...), opts...)
+
+	last, ok := lastUser(m)
+	if !ok || options.Temperature > 0 || options.N > 1 {
+		return s.next.Generate(ctx, m, opts...)
+	}
+	prompt := m[last].Text()
+	scope, err := s.scope(ctx, slices.Delete(slices.Clone(m), last, last+1), options)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors, err := s.embedder.Embed(ctx, []string{prompt})
+	if err != nil || len(vectors) != 1 {
+		s.logger.WarnContext(ctx, "cache: embedding failed", "error", err)
+		return s.next.Generate(ctx, m, opts...)
+	}
+	vector := vectors[0]
+
+	if entry, score, ok := s.lookup(scope, vector); ok {
+		s.logger.DebugContext(ctx, "cache: semantic hit", "namespace", Namespace(ctx), "score", score)
+		return replay(entry, options), nil
+	}
+	s.logger.DebugContext(ctx, "cache: semantic miss", "namespace", Namespace(ctx))
+
+	gen, err := s.next.Generate(ctx, m, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if options.Stream {
+		return record(gen, func(e Entry) { s.insert(scope, vector, e) }), nil
+	}
+
+	var messages []model.Message
+	for msg := range gen.Messages() {
+		messages = append(messages, msg)
+	}
+	if gen.Error() != nil {
+		return nil, gen.Error()
+	}
+	s.insert(scope, vector, Entry{Messages: messages})
+	out := model.NewGeneration(messages)
+	out.SetUsage(gen.Usage())
+	return out, nil
+}
+
+// scope identifies the partition of the index a request belongs to,
+// from the messages surrounding its prompt.
+func (s *semanticModel) scope(ctx context.Context, conversation []model.Message, options model.ModelOptions) (string, error) {
+	key, err := Key(conversation, options)
+	if err != nil {
+		return "", err
+	}
+	return Namespace(ctx) + "/" + key, nil
+}
+
+// lookup returns the most similar live entry of scope above the threshold.
+func (s *semanticModel) lookup(scope string, vector []float32) (Entry, float32, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := s.now()
+	var (
+		best  Entry
+		score float32 = -1
+	)
+	for _, e := range s.index[scope] {
+		if !e.expires.IsZero() && now.After(e.expires) {
+			continue
+		}
+		if sim := vectorstore.CosineSimilarity(vector, e.vector); sim > score {
+			best, score = e.entry, sim
+		}
+	}
+	return best, score, score >= s.threshold
+}
+
+// insert adds an entry to scope, dropping expired ones on the way.
+func (s *semanticModel) insert(scope string, vector []float32, e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	entries := s.index[scope][:0]
+	for _, old := range s.index[scope] {
+		if old.expires.IsZero() || !now.After(old.expires) {
+			entries = append(entries, old)
+		}
+	}
+	var expires time.Time
+	if s.ttl > 0 {
+		expires = now.Add(s.ttl)
+	}
+	s.index[scope] = append(entries, semanticEntry{vector: vector, entry: e, expires: expires})
+}
+
+// lastUser returns the index of the last user message, if it holds text.
+func lastUser(m []model.Message) (int, bool) {
+	for i := len(m) - 1; i >= 0; i-- {
+		if m[i].Role == model.User {
+			return i, m[i].Text() != ""
+		}
+	}
+	return 0, false
+}
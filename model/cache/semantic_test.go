@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nyxze/fayth/model"
+)
+
+// tableEmbedder returns fixed vectors for known texts
+type tableEmbedder map[string][]float32
+
+func (e tableEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		out[i] = e[t]
+	}
+	return out, nil
+}
+
+var embeddings = tableEmbedder{
+	"How do I reset my password?":     {1, 0, 0},
+	"how can I reset my password":     {0.98, 0.2, 0},
+	"What are your opening hours?":    {0, 1, 0},
+	"When does the store open today?": {0, 0.9, 0.44},
+}
+
+func ask(t *testing.T, m model.Model, ctx context.Context, prompt string) string {
+	t.Helper()
+	gen, err := m.Generate(ctx, []model.Message{model.NewTextMessage(model.User, prompt)})
+	if err != nil {
+		t.Fatalf("Generate() unexpected error: %v", err)
+	}
+	return collect(t, gen)[0]
+}
+
+func TestSemantic_SimilarPromptHits(t *testing.T) {
+	next := &countingModel{chunks: []string{"Use the reset link."}}
+	cached := NewSemantic(next, embeddings, WithThreshold(0.9))
+	ctx := context.Background()
+
+	ask(t, cached, ctx, "How do I reset my password?")
+	if got := ask(t, cached, ctx, "how can I reset my password"); got != "Use the reset link." {
+		t.Errorf("Expected cached answer, got %q", got)
+	}
+	if next.calls != 1 {
+		t.Errorf("Expected 1 underlying call, got %d", next.calls)
+	}
+
+	ask(t, cached, ctx, "What are your opening hours?")
+	if next.calls != 2 {
+		t.Errorf("Expected dissimilar prompt to miss, got %d calls", next.calls)
+	}
+}
+
+func TestSemantic_NamespaceScoping(t *testing.T) {
+	next := &countingModel{chunks: []string{"answer"}}
+	cached := NewSemantic(next, embeddings)
+
+	ask(t, cached, WithNamespace(context.Background(), "tenant-a"), "How do I reset my password?")
+	ask(t, cached, WithNamespace(context.Background(), "tenant-b"), "How do I reset my password?")
+	ask(t, cached, WithNamespace(context.Background(), "tenant-a"), "How do I reset my password?")
+
+	if next.calls != 2 {
+		t.Errorf("Expected 1 call per namespace, got %d", next.calls)
+	}
+}
+
+func TestSemantic_Conversation(t *testing.T) {
+	next := &countingModel{chunks: []string{"answer"}}
+	cached := NewSemantic(next, embeddings)
+	ctx := context.Background()
+	conversation := func(system string, history ...string) []model.Message {
+		m := []model.Message{model.NewTextMessage(model.System, system)}
+		for _, h := range history {
+			m = append(m, model.NewTextMessage(model.User, h), model.NewTextMessage(model.Assistant, "ok"))
+		}
+		return append(m, model.NewTextMessage(model.User, "How do I reset my password?"))
+	}
+
+	tests := []struct {
+		name      string
+		messages  []model.Message
+		opts      []model.ModelOption
+		wantCalls int
+	}{
+		{"First", conversation("You support Acme."), nil, 1},
+		{"Same conversation", conversation("You support Acme."), nil, 1},
+		{"Other system prompt", conversation("You support Globex."), nil, 2},
+		{"Other history", conversation("You support Acme.", "I am locked out"), nil, 3},
+		{"Same history", conversation("You support Acme.", "I am locked out"), nil, 3},
+		{"Sampled", conversation("You support Acme."), []model.ModelOption{model.WithTemperature(0.7)}, 4},
+		{"Sampled again", conversation("You support Acme."), []model.ModelOption{model.WithTemperature(0.7)}, 5},
+		{"Several choices", conversation("You support Acme."), []model.ModelOption{model.WithN(2)}, 6},
+	}
+	for _, tt := range tests {
+		gen, err := cached.Generate(ctx, tt.messages, tt.opts...)
+		if err != nil {
+			t.Fatalf("%s: Generate() unexpected error: %v", tt.name, err)
+		}
+		collect(t, gen)
+		if next.calls != tt.wantCalls {
+			t.Errorf("%s: expected %d underlying calls, got %d", tt.name, tt.wantCalls, next.calls)
+		}
+	}
+}
+
+func TestSemantic_TTL(t *testing.T) {
+	next := &countingModel{chunks: []string{"answer"}}
+	cached := NewSemantic(next, embeddings, WithTTL(time.Minute)).(*semanticModel)
+	now := time.Now()
+	cached.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	ask(t, cached, ctx, "How do I reset my password?")
+	ask(t, cached, ctx, "How do I reset my password?")
+	if next.calls != 1 {
+		t.Fatalf("Expected hit before expiry, got %d calls", next.calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	ask(t, cached, ctx, "How do I reset my password?")
+	if next.calls != 2 {
+		t.Errorf("Expected miss after expiry, got %d calls", next.calls)
+	}
+}
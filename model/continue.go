@@ -0,0 +1,120 @@
+package model
+
+import (
+	"context"
+	"strings"
+)
+
+// ContinueInstruction is the user message asking a model to resume a truncated message.
+const ContinueInstruction = "Your previous message was cut off. Continue exactly where it stopped, without repeating anything or adding any introduction."
+
+// AutoContinue generates with m and, while the first message stops with [FinishLength],
+// re-issues the request with the partial message and [ContinueInstruction] appended,
+// up to the AutoContinue option rounds. Model implementations call it when the option is set,
+// passing the options effective for the call, merged from their defaults and opts,
+// which decide the number of rounds and whether the generation streams.
+//
+// Without streaming, the pieces are stitched into one message carrying the last finish reason.
+// The usage of the generation is the sum of every round.
+// When streaming, the chunks of every round are forwarded in order, so merging them
+// with [MergeChunks] yields the stitched message.
+func AutoContinue(ctx context.Context, m Model, messages []Message, options ModelOptions, opts ...ModelOption) (*Generation, error) {
+	rounds := options.AutoContinue
+	// Each round is a plain request to m
+	opts = append(opts[:len(opts):len(opts)], WithAutoContinue(0))
+
+	gen, err := m.Generate(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if options.Stream {
+		return continueStream(ctx, m, messages, gen, rounds, opts), nil
+	}
+
+	var out []Message
+	for msg := range gen.Messages() {
+		out = append(out, msg)
+	}
+	if gen.Error() != nil {
+		return nil, gen.Error()
+	}
+	usage := gen.Usage()
+	for round := 0; round < rounds && len(out) > 0 && out[0].FinishReason == FinishLength; round++ {
+		next, err := m.Generate(ctx, continuation(messages, out[0].Text()), opts...)
+		if err != nil {
+			return nil, err
+		}
+		var piece []Message
+		for msg := range next.Messages() {
+			piece = append(piece, msg)
+		}
+		if next.Error() != nil {
+			return nil, next.Error()
+		}
+		usage = usage.Add(next.Usage())
+		if len(piece) == 0 {
+			break
+		}
+		out[0] = stitch(out[0], piece[0])
+	}
+	result := NewGeneration(out)
+	result.SetUsage(usage)
+	return result, nil
+}
+
+// continueStream forwards the chunks of gen, then of the continuation rounds.
+func continueStream(ctx context.Context, m Model, messages []Message, gen *Generation, rounds int, opts []ModelOption) *Generation {
+	out := &Generation{}
+	out.MsgIter = func(yield func(Message) bool) {
+		var text strings.Builder
+		for round := 0; ; round++ {
+			var finish FinishReason
+			for msg := range gen.Messages() {
+				if msg.Index == 0 {
+					text.WriteString(msg.Text())
+					if msg.FinishReason != "" {
+						finish = msg.FinishReason
+					}
+				}
+				if !yield(msg) {
+					return
+				}
+			}
+			out.usage = out.usage.Add(gen.Usage())
+			if out.Err = gen.Error(); out.Err != nil {
+				return
+			}
+			if finish != FinishLength || round >= rounds {
+				return
+			}
+			next, err := m.Generate(ctx, continuation(messages, text.String()), opts...)
+			if err != nil {
+				out.Err = err
+				return
+			}
+			gen = next
+		}
+	}
+	return out
+}
+
+// continuation returns messages followed by the partial answer and the continuation instruction.
+func continuation(messages []Message, partial string) []Message {
+	out := make([]Message, 0, len(messages)+2)
+	out = append(out, messages...)
+	return append(out,
+		NewTextMessage(Assistant, partial),
+		NewTextMessage(User, ContinueInstruction),
+	)
+}
+
+// stitch appends the text of piece to msg, keeping the finish reason of piece.
+func stitch(msg, piece Message) Message {
+	merged := MergeChunks([]Message{
+		{Role: msg.Role, Contents: msg.Contents, Metadata: msg.Metadata},
+		{Role: msg.Role, Contents: piece.Contents, Metadata: piece.Metadata, FinishReason: piece.FinishReason},
+	})[0]
+	merged.Index = msg.Index
+	merged.Properties = msg.Properties
+	return merged
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// truncatingModel answers with one piece per call, stopping with FinishLength until the last one
+type truncatingModel struct {
+	pieces   []string
+	defaults []ModelOption
+	inputs   [][]Message
+	// reasoning precedes each piece with a reasoning part
+	reasoning bool
+}
+
+func (m *truncatingModel) Generate(_ context.Context, msgs []Message, opts ...ModelOption) (*Generation, error) {
+	options := MergeOptions(MergeOptions(ModelOptions{}, m.defaults...), opts...)
+	if options.AutoContinue != 0 {
+		return AutoContinue(context.Background(), m, msgs, options, opts...)
+	}
+	m.inputs = append(m.inputs, msgs)
+	piece := m.pieces[len(m.inputs)-1]
+	finish := FinishLength
+	if len(m.inputs) == len(m.pieces) {
+		finish = FinishStop
+	}
+	usage := Usage{InputTokens: 10, OutputTokens: 2}
+	if !options.Stream {
+		msg := NewTextMessage(Assistant, piece)
+		if m.reasoning {
+			msg.Contents = append([]ContentPart{ReasoningContent{Text: "Counting."}}, msg.Contents...)
+		}
+		msg.FinishReason = finish
+		gen := NewGeneration([]Message{msg})
+		gen.SetUsage(usage)
+		return gen, nil
+	}
+	gen := &Generation{}
+	gen.MsgIter = func(yield func(Message) bool) {
+		if m.reasoning && !yield(NewMessage(Assistant, func(msg *Message) {
+			msg.Contents = append(msg.Contents, ReasoningContent{Text: "Counting."})
+		})) {
+			return
+		}
+		for i, word := range strings.SplitAfter(piece, " ") {
+			chunk := NewTextMessage(Assistant, word)
+			if i == len(strings.SplitAfter(piece, " "))-1 {
+				chunk.FinishReason = finish
+			}
+			for _, h := range options.MessageHandler {
+				h(chunk)
+			}
+			if !yield(chunk) {
+				return
+			}
+		}
+		gen.SetUsage(usage)
+	}
+	return gen, nil
+}
+
+func TestAutoContinue(t *testing.T) {
+	question := []Message{NewTextMessage(User, "Count to six")}
+	tests := []struct {
+		name      string
+		stream    bool
+		rounds    int
+		defaults  bool
+		want      string
+		wantCalls int
+		finish    FinishReason
+	}{
+		{"Stitched", false, 3, false, "one two three four five six", 3, FinishStop},
+		{"Round limit", false, 1, false, "one two three four", 2, FinishLength},
+		{"Disabled", false, 0, false, "one two", 1, FinishLength},
+		{"Streaming", true, 3, false, "one two three four five six", 3, FinishStop},
+		{"Streaming round limit", true, 1, false, "one two three four", 2, FinishLength},
+		{"Model defaults", false, 3, true, "one two three four five six", 3, FinishStop},
+		{"Streaming model defaults", true, 3, true, "one two three four five six", 3, FinishStop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := &truncatingModel{pieces: []string{"one two", " three four", " five six"}}
+			handled := 0
+			opts := []ModelOption{WithAutoContinue(tt.rounds), WithStream(tt.stream, func(Message) { handled++ })}
+			if tt.defaults {
+				llm.defaults, opts = opts, nil
+			}
+			gen, err := llm.Generate(context.Background(), question, opts...)
+			if err != nil {
+				t.Fatalf("Generate() unexpected error: %v", err)
+			}
+			var messages []Message
+			for msg := range gen.Messages() {
+				messages = append(messages, msg)
+			}
+			if gen.Error() != nil {
+				t.Fatalf("Generate() stream error: %v", gen.Error())
+			}
+			if tt.stream {
+				if handled != len(messages) {
+					t.Errorf("handlers called %d times for %d chunks", handled, len(messages))
+				}
+				messages = MergeChunks(messages)
+			}
+			if len(messages) != 1 || messages[0].Text() != tt.want || messages[0].FinishReason != tt.finish {
+				t.Fatalf("Generate() = %+v, want one message %q finished by %s", messages, tt.want, tt.finish)
+			}
+			if len(llm.inputs) != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", len(llm.inputs), tt.wantCalls)
+			}
+			if want := (Usage{InputTokens: 10 * tt.wantCalls, OutputTokens: 2 * tt.wantCalls}); gen.Usage() != want {
+				t.Errorf("Usage() = %+v, want the sum of every round %+v", gen.Usage(), want)
+			}
+			if tt.wantCalls > 2 {
+				last := llm.inputs[2]
+				if len(last) != 3 || last[1].Text() != "one two three four" || last[2].Text() != ContinueInstruction {
+					t.Errorf("continuation request = %+v", last)
+				}
+			}
+		})
+	}
+}
+
+func TestAutoContinue_Reasoning(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		llm := &truncatingModel{pieces: []string{"one two", " three four", " five six"}, reasoning: true}
+		gen, err := llm.Generate(context.Background(), []Message{NewTextMessage(User, "Count to six")},
+			WithAutoContinue(3), WithStream(stream))
+		if err != nil {
+			t.Fatalf("stream=%v: Generate() unexpected error: %v", stream, err)
+		}
+		choices := gen.Choices()
+		if gen.Error() != nil || len(choices) != 1 || choices[0].Text() != "one two three four five six" {
+			t.Errorf("stream=%v: expected every piece stitched, got %+v, %v", stream, choices, gen.Error())
+		}
+		if got := llm.inputs[2][1]; got.Text() != "one two three four" || got.Reasoning() != "" {
+			t.Errorf("stream=%v: expected the text of every piece as the partial answer, got %+v", stream, got)
+		}
+	}
+}
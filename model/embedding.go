@@ -0,0 +1,11 @@
+package model
+
+import "context"
+
+// Embedder converts texts into embedding vectors.
+//
+// Embed returns one vector per input text, in the same order.
+// All vectors produced by a given Embedder share the same dimension.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
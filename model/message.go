@@ -1,8 +1,12 @@
 package model
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 )
 
 type Role string
@@ -13,9 +17,35 @@ const (
 	Tool      Role = "tool"
 	System    Role = "system"
 )
+
+// FinishReason tells why a model stopped generating a message.
+type FinishReason string
+
 const (
-	TextKind  string = "text"
-	ImageKind string = "image"
+	// FinishStop is a natural stop or a stop sequence
+	FinishStop FinishReason = "stop"
+	// FinishLength is a stop at the maximum number of tokens, the message is truncated
+	FinishLength FinishReason = "length"
+	// FinishToolCalls is a stop to call tools
+	FinishToolCalls FinishReason = "tool_calls"
+	// FinishContentFilter is a stop caused by a content filter
+	FinishContentFilter FinishReason = "content_filter"
+)
+
+const (
+	TextKind      string = "text"
+	ImageKind     string = "image"
+	ReasoningKind string = "reasoning"
+	RefusalKind   string = "refusal"
+	AudioKind     string = "audio"
+)
+
+// Errors
+var (
+	// ErrRefusal matches the refusal of every refused message, see [Message.Refusal]
+	ErrRefusal = errors.New("model: refused to answer")
+	// ErrContentFilter matches the refusals of messages stopped by a content filter
+	ErrContentFilter = errors.New("model: content filtered")
 )
 
 // ContentPart represents a generic content element of a message.
@@ -34,6 +64,8 @@ type Message struct {
 	Index      int               `json:"index"`
 	Metadata   map[string]string `json:"metadata,omitempty"`
 	Properties map[string]any    `json:"properties,omitempty"`
+	// FinishReason is set on complete messages, and on the last streamed chunk of a message
+	FinishReason FinishReason `json:"finish_reason,omitempty"`
 }
 
 // Combine content of msg to m
@@ -56,6 +88,88 @@ func (m *Message) Combine(msg Message) {
 	}
 }
 
+// MergeChunks merges streamed chunks into one message per choice index,
+// ordered by first appearance. Consecutive text deltas are concatenated,
+// other content parts are kept in order.
+func MergeChunks(chunks []Message) []Message {
+	var out []Message
+	pos := map[int]int{}
+	for _, c := range chunks {
+		i, ok := pos[c.Index]
+		if !ok {
+			i = len(out)
+			pos[c.Index] = i
+			out = append(out, Message{Role: c.Role, Index: c.Index})
+		}
+		msg := &out[i]
+		if c.FinishReason != "" {
+			msg.FinishReason = c.FinishReason
+		}
+		for k, v := range c.Metadata {
+			if msg.Metadata == nil {
+				msg.Metadata = map[string]string{}
+			}
+			msg.Metadata[k] = v
+		}
+		for _, part := range c.Contents {
+			// Empty text deltas, such as the role-only chunk opening a stream, would split the text
+			if t, ok := part.(TextContent); ok && t.Text == "" {
+				continue
+			}
+			last := len(msg.Contents) - 1
+			if last >= 0 {
+				if merged, ok := mergeDelta(msg.Contents[last], part); ok {
+					msg.Contents[last] = merged
+					continue
+				}
+			}
+			msg.Contents = append(msg.Contents, part)
+		}
+	}
+	return out
+}
+
+// mergeDelta concatenates consecutive text, reasoning, refusal or audio deltas.
+func mergeDelta(prev, part ContentPart) (ContentPart, bool) {
+	switch p := part.(type) {
+	case TextContent:
+		if t, ok := prev.(TextContent); ok {
+			return TextContent{Text: t.Text + p.Text}, true
+		}
+	case ReasoningContent:
+		if r, ok := prev.(ReasoningContent); ok {
+			return ReasoningContent{Text: r.Text + p.Text, Signature: r.Signature + p.Signature}, true
+		}
+	case RefusalContent:
+		if r, ok := prev.(RefusalContent); ok {
+			return RefusalContent{Text: r.Text + p.Text, Filtered: r.Filtered || p.Filtered}, true
+		}
+	case AudioContent:
+		if a, ok := prev.(AudioContent); ok && (p.ID == "" || a.ID == "" || p.ID == a.ID) {
+			return AudioContent{
+				ID:         cmp.Or(a.ID, p.ID),
+				Format:     cmp.Or(a.Format, p.Format),
+				Data:       append(slices.Clip(a.Data), p.Data...),
+				Transcript: a.Transcript + p.Transcript,
+			}, true
+		}
+	}
+	return nil, false
+}
+
+// StripReasoning returns copies of messages without their reasoning contents,
+// such as before re-sending a history to a provider that does not accept them back.
+func StripReasoning(messages []Message) []Message {
+	out := make([]Message, len(messages))
+	for i, msg := range messages {
+		out[i] = msg
+		out[i].Contents = slices.DeleteFunc(slices.Clone(msg.Contents), func(c ContentPart) bool {
+			return c.Kind() == ReasoningKind
+		})
+	}
+	return out
+}
+
 // Convinient function for creating a new Message
 func NewMessage(role Role, contents ...ContentFunc) Message {
 	msg := Message{
@@ -73,13 +187,21 @@ func NewTextMessage(role Role, texts ...string) Message {
 
 func (m *Message) UnmarshalJSON(b []byte) error {
 	var schema struct {
-		Role     Role              `json:"role"`
-		Contents []json.RawMessage `json:"contents"`
+		Role         Role              `json:"role"`
+		Contents     []json.RawMessage `json:"contents"`
+		Index        int               `json:"index"`
+		Metadata     map[string]string `json:"metadata"`
+		Properties   map[string]any    `json:"properties"`
+		FinishReason FinishReason      `json:"finish_reason"`
 	}
 	if err := json.Unmarshal(b, &schema); err != nil {
 		return err
 	}
 	m.Role = schema.Role
+	m.Index = schema.Index
+	m.Metadata = schema.Metadata
+	m.Properties = schema.Properties
+	m.FinishReason = schema.FinishReason
 	size := len(schema.Contents)
 	m.Contents = make([]ContentPart, 0, size)
 	for i := range size {
@@ -92,14 +214,62 @@ func (m *Message) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-// Return all first TextContent
+// Text returns the concatenated text contents of the message.
 func (m Message) Text() string {
+	var sb strings.Builder
 	for _, c := range m.Contents {
 		if t, ok := c.(TextContent); ok {
-			return t.Text
+			sb.WriteString(t.Text)
+		}
+	}
+	return sb.String()
+}
+
+// Reasoning returns the concatenated reasoning contents of the message.
+func (m Message) Reasoning() string {
+	var sb strings.Builder
+	for _, c := range m.Contents {
+		if r, ok := c.(ReasoningContent); ok {
+			sb.WriteString(r.Text)
+		}
+	}
+	return sb.String()
+}
+
+// Transcript returns the concatenated transcripts of the audio contents of the message.
+func (m Message) Transcript() string {
+	var sb strings.Builder
+	for _, c := range m.Contents {
+		if a, ok := c.(AudioContent); ok {
+			sb.WriteString(a.Transcript)
 		}
 	}
-	return ""
+	return sb.String()
+}
+
+// Refusal returns an error matching [ErrRefusal] when the model refused to answer,
+// and also [ErrContentFilter] when a content filter stopped the message. It returns nil otherwise.
+func (m Message) Refusal() error {
+	var refusal *RefusalContent
+	for _, c := range m.Contents {
+		if r, ok := c.(RefusalContent); ok {
+			if refusal == nil {
+				refusal = &RefusalContent{}
+			}
+			refusal.Text += r.Text
+			refusal.Filtered = refusal.Filtered || r.Filtered
+		}
+	}
+	if m.FinishReason == FinishContentFilter {
+		if refusal == nil {
+			refusal = &RefusalContent{}
+		}
+		refusal.Filtered = true
+	}
+	if refusal == nil {
+		return nil
+	}
+	return &RefusalError{Text: refusal.Text, Filtered: refusal.Filtered}
 }
 
 // Represent plain text content in a message
@@ -120,6 +290,14 @@ func (tc TextContent) MarshalJSON() ([]byte, error) {
 // Kind returns the type of content, which is "text" for TextContent.
 func (TextContent) Kind() string { return TextKind }
 
+// Image source types
+const (
+	// ImageSourceBase64 holds the raw image bytes in Data
+	ImageSourceBase64 = "base64"
+	// ImageSourceURL holds the image URL in Data
+	ImageSourceURL = "url"
+)
+
 // Represent image content, either using base64 or form an url
 type ImageContent struct {
 	SourceType string `json:"source_type"`
@@ -127,8 +305,108 @@ type ImageContent struct {
 	Data       []byte `json:"data"`
 }
 
+func (ic ImageContent) MarshalJSON() ([]byte, error) {
+	type alias ImageContent
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		alias
+	}{
+		Type:  ic.Kind(),
+		alias: alias(ic),
+	})
+}
+
 func (ImageContent) Kind() string { return ImageKind }
 
+// ReasoningContent is the thinking of a reasoning model before its answer.
+// It is streamed as its own deltas and is not part of [Message.Text].
+type ReasoningContent struct {
+	Text string `json:"text"`
+	// Signature is opaque provider data verifying the reasoning when it is sent back
+	Signature string `json:"signature,omitempty"`
+}
+
+func (rc ReasoningContent) MarshalJSON() ([]byte, error) {
+	type alias ReasoningContent
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		alias
+	}{
+		Type:  rc.Kind(),
+		alias: alias(rc),
+	})
+}
+
+func (ReasoningContent) Kind() string { return ReasoningKind }
+
+// RefusalContent is the explanation of a model refusing to answer.
+// It is not part of [Message.Text].
+type RefusalContent struct {
+	Text string `json:"text,omitempty"`
+	// Filtered is set when a provider content filter stopped the message
+	Filtered bool `json:"filtered,omitempty"`
+}
+
+func (rc RefusalContent) MarshalJSON() ([]byte, error) {
+	type alias RefusalContent
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		alias
+	}{
+		Type:  rc.Kind(),
+		alias: alias(rc),
+	})
+}
+
+func (RefusalContent) Kind() string { return RefusalKind }
+
+// AudioContent is spoken audio, sent to or generated by audio models.
+// It is not part of [Message.Text], see [Message.Transcript].
+type AudioContent struct {
+	// Format is the encoding of Data, such as "wav", "mp3" or "pcm16"
+	Format string `json:"format"`
+	Data   []byte `json:"data,omitempty"`
+	// Transcript is the text of generated audio
+	Transcript string `json:"transcript,omitempty"`
+	// ID references generated audio kept by the provider, to send it back in a history
+	ID string `json:"id,omitempty"`
+}
+
+func (ac AudioContent) MarshalJSON() ([]byte, error) {
+	type alias AudioContent
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		alias
+	}{
+		Type:  ac.Kind(),
+		alias: alias(ac),
+	})
+}
+
+func (AudioContent) Kind() string { return AudioKind }
+
+// RefusalError reports a refused message, see [Message.Refusal].
+type RefusalError struct {
+	// Text is the explanation given by the model, if any
+	Text     string
+	Filtered bool
+}
+
+func (e *RefusalError) Error() string {
+	msg := ErrRefusal.Error()
+	if e.Filtered {
+		msg = ErrContentFilter.Error()
+	}
+	if e.Text != "" {
+		msg += ": " + e.Text
+	}
+	return msg
+}
+
+func (e *RefusalError) Is(target error) bool {
+	return target == ErrRefusal || (e.Filtered && target == ErrContentFilter)
+}
+
 func unmarshalContentPart(data []byte) (ContentPart, error) {
 	var probe struct {
 		Type string `json:"type"`
@@ -151,6 +429,24 @@ func unmarshalContentPart(data []byte) (ContentPart, error) {
 			return nil, err
 		}
 		return i, nil
+	case ReasoningKind:
+		var r ReasoningContent
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, err
+		}
+		return r, nil
+	case RefusalKind:
+		var r RefusalContent
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, err
+		}
+		return r, nil
+	case AudioKind:
+		var a AudioContent
+		if err := json.Unmarshal(data, &a); err != nil {
+			return nil, err
+		}
+		return a, nil
 	default:
 		return nil, fmt.Errorf("unknown content kind: %s", probe.Type)
 	}
@@ -10,3 +10,31 @@ func WithTextContent(content ...string) ContentFunc {
 		}
 	}
 }
+
+// Appends new ImageContent holding the raw image data to the message's contents
+func WithImageContent(mimeType string, data []byte) ContentFunc {
+	return func(m *Message) {
+		m.Contents = append(m.Contents, ImageContent{
+			SourceType: ImageSourceBase64,
+			MIMEType:   mimeType,
+			Data:       data,
+		})
+	}
+}
+
+// Appends new ImageContent pointing to the image url to the message's contents
+func WithImageURL(url string) ContentFunc {
+	return func(m *Message) {
+		m.Contents = append(m.Contents, ImageContent{
+			SourceType: ImageSourceURL,
+			Data:       []byte(url),
+		})
+	}
+}
+
+// Appends new AudioContent holding the raw audio data, encoded in format such as "wav" or "mp3", to the message's contents
+func WithAudioContent(format string, data []byte) ContentFunc {
+	return func(m *Message) {
+		m.Contents = append(m.Contents, AudioContent{Format: format, Data: data})
+	}
+}
@@ -1,7 +1,9 @@
 package model
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"testing"
 )
 
@@ -32,3 +34,210 @@ func TestUnmarshal_Message(t *testing.T) {
 		t.Fatalf("Unexpected len of content, expected : %v, go %v", 1, len(c))
 	}
 }
+
+func TestMergeChunks(t *testing.T) {
+	chunks := []Message{
+		{Role: Assistant, Index: 0, Contents: []ContentPart{TextContent{Text: "Hel"}}},
+		{Role: Assistant, Index: 1, Contents: []ContentPart{TextContent{Text: "Bon"}}},
+		{Role: Assistant, Index: 0, Contents: []ContentPart{TextContent{Text: "lo"}}},
+		{Role: Assistant, Index: 1, Contents: []ContentPart{TextContent{Text: "jour"}}},
+	}
+	merged := MergeChunks(chunks)
+	if len(merged) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(merged))
+	}
+	if merged[0].Text() != "Hello" || merged[0].Index != 0 {
+		t.Errorf("Unexpected first message: %+v", merged[0])
+	}
+	if merged[1].Text() != "Bonjour" || merged[1].Index != 1 {
+		t.Errorf("Unexpected second message: %+v", merged[1])
+	}
+
+	// A role-only chunk opens the stream, the text is split by reasoning
+	merged = MergeChunks([]Message{
+		{Role: Assistant, Contents: []ContentPart{TextContent{}}},
+		{Contents: []ContentPart{ReasoningContent{Text: "Think"}}},
+		{Contents: []ContentPart{TextContent{Text: "4"}}},
+		{Contents: []ContentPart{ReasoningContent{Text: "Again"}}},
+		{Contents: []ContentPart{TextContent{Text: "2"}}},
+	})
+	if len(merged) != 1 || len(merged[0].Contents) != 4 || merged[0].Role != Assistant {
+		t.Fatalf("Expected the empty text dropped, got %+v", merged)
+	}
+	if merged[0].Text() != "42" {
+		t.Errorf("Expected every text part in Text(), got %q", merged[0].Text())
+	}
+}
+
+func TestApproxTokenizer(t *testing.T) {
+	tests := map[string]struct {
+		text string
+		want []string
+	}{
+		"Empty":       {text: "", want: nil},
+		"Words":       {text: "Hello world", want: []string{"Hell", "o", " worl", "d"}},
+		"Punctuation": {text: "Hi, you!", want: []string{"Hi", ",", " you", "!"}},
+		"Trailing":    {text: "ok  ", want: []string{"ok", "  "}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := ApproxTokenizer{}.Tokens(tt.text)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %q, got %q", tt.want, got)
+			}
+			joined := ""
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Token %d: expected %q, got %q", i, tt.want[i], got[i])
+				}
+				joined += got[i]
+			}
+			if joined != tt.text {
+				t.Errorf("Expected tokens to reproduce %q, got %q", tt.text, joined)
+			}
+		})
+	}
+}
+
+func TestMarshal_ImageContent(t *testing.T) {
+	msg := NewMessage(User, WithTextContent("What is this?"), WithImageContent("image/png", []byte{0x89, 'P'}), WithImageURL("https://example.com/a.jpg"))
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal() unexpected error: %v", err)
+	}
+	var got Message
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal(%s) unexpected error: %v", data, err)
+	}
+	if len(got.Contents) != 3 {
+		t.Fatalf("Expected 3 contents, got %d", len(got.Contents))
+	}
+	img, ok := got.Contents[1].(ImageContent)
+	if !ok || img.SourceType != ImageSourceBase64 || img.MIMEType != "image/png" || string(img.Data) != "\x89P" {
+		t.Errorf("Unexpected image content: %+v", got.Contents[1])
+	}
+	if url := got.Contents[2].(ImageContent); url.SourceType != ImageSourceURL || string(url.Data) != "https://example.com/a.jpg" {
+		t.Errorf("Unexpected image url content: %+v", url)
+	}
+}
+
+func TestReasoningContent(t *testing.T) {
+	chunks := []Message{
+		{Role: Assistant, Contents: []ContentPart{ReasoningContent{Text: "6 x 7 "}}},
+		{Role: Assistant, Contents: []ContentPart{ReasoningContent{Text: "is 42", Signature: "sig"}}},
+		{Role: Assistant, Contents: []ContentPart{TextContent{Text: "The answer "}}},
+		{Role: Assistant, Contents: []ContentPart{TextContent{Text: "is 42."}}},
+	}
+	merged := MergeChunks(chunks)
+	if len(merged) != 1 || len(merged[0].Contents) != 2 {
+		t.Fatalf("Expected one message with a reasoning and a text part, got %+v", merged)
+	}
+	msg := merged[0]
+	if msg.Text() != "The answer is 42." || msg.Reasoning() != "6 x 7 is 42" {
+		t.Errorf("Unexpected text %q and reasoning %q", msg.Text(), msg.Reasoning())
+	}
+
+	data, err := json.Marshal([]Message{msg})
+	if err != nil {
+		t.Fatalf("Marshal() unexpected error: %v", err)
+	}
+	var decoded []Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal(%s) unexpected error: %v", data, err)
+	}
+	if r, ok := decoded[0].Contents[0].(ReasoningContent); !ok || r.Text != "6 x 7 is 42" || r.Signature != "sig" {
+		t.Errorf("Expected the reasoning to round trip, got %+v", decoded[0].Contents)
+	}
+
+	history := []Message{NewTextMessage(User, "What is 6 x 7?"), msg}
+	stripped := StripReasoning(history)
+	if len(stripped) != 2 || len(stripped[1].Contents) != 1 || stripped[1].Text() != "The answer is 42." || stripped[1].Reasoning() != "" {
+		t.Errorf("Unexpected stripped history: %+v", stripped)
+	}
+	if history[1].Reasoning() == "" {
+		t.Error("StripReasoning() modified the original history")
+	}
+}
+
+func TestRefusal(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      Message
+		refused  bool
+		filtered bool
+		text     string
+	}{
+		{"Answer", NewTextMessage(Assistant, "Sure"), false, false, ""},
+		{"Refusal", NewMessage(Assistant, WithTextContent(""), func(m *Message) {
+			m.Contents = append(m.Contents, RefusalContent{Text: "I can't help with that."})
+		}), true, false, "I can't help with that."},
+		{"Content filter", Message{Role: Assistant, Contents: []ContentPart{TextContent{Text: "Partial"}}, FinishReason: FinishContentFilter}, true, true, ""},
+		{"Merged deltas", MergeChunks([]Message{
+			{Role: Assistant, Contents: []ContentPart{RefusalContent{Text: "I can't "}}},
+			{Role: Assistant, Contents: []ContentPart{RefusalContent{Text: "help.", Filtered: true}}},
+		})[0], true, true, "I can't help."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.msg.Refusal()
+			if errors.Is(err, ErrRefusal) != tt.refused || errors.Is(err, ErrContentFilter) != tt.filtered {
+				t.Fatalf("Refusal() = %v, want refused %v and filtered %v", err, tt.refused, tt.filtered)
+			}
+			var refusal *RefusalError
+			if tt.refused && (!errors.As(err, &refusal) || refusal.Text != tt.text) {
+				t.Errorf("Refusal() = %#v, want text %q", err, tt.text)
+			}
+			if tt.msg.Text() == tt.text && tt.text != "" {
+				t.Errorf("Text() = %q includes the refusal", tt.msg.Text())
+			}
+		})
+	}
+
+	data, err := json.Marshal(tests[3].msg)
+	if err != nil {
+		t.Fatalf("Marshal() unexpected error: %v", err)
+	}
+	var decoded Message
+	if err := json.Unmarshal(data, &decoded); err != nil || !errors.Is(decoded.Refusal(), ErrContentFilter) {
+		t.Errorf("Unmarshal(%s) = %+v, %v, want the refusal to round trip", data, decoded, err)
+	}
+}
+
+func TestAudioContent(t *testing.T) {
+	chunks := []Message{
+		{Role: Assistant, Contents: []ContentPart{AudioContent{ID: "audio_1", Format: "pcm16", Transcript: "Hel"}}},
+		{Role: Assistant, Contents: []ContentPart{AudioContent{Format: "pcm16", Data: []byte{0, 1}, Transcript: "lo"}}},
+		{Role: Assistant, Contents: []ContentPart{AudioContent{Format: "pcm16", Data: []byte{2}}}},
+	}
+	merged := MergeChunks(chunks)
+	if len(merged) != 1 || len(merged[0].Contents) != 1 {
+		t.Fatalf("Expected one message with one audio part, got %+v", merged)
+	}
+	msg := merged[0]
+	audio, ok := msg.Contents[0].(AudioContent)
+	if !ok || audio.ID != "audio_1" || !bytes.Equal(audio.Data, []byte{0, 1, 2}) || msg.Transcript() != "Hello" {
+		t.Errorf("Unexpected merged audio %+v", msg.Contents)
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal() unexpected error: %v", err)
+	}
+	var decoded Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal(%s) unexpected error: %v", data, err)
+	}
+	if a, ok := decoded.Contents[0].(AudioContent); !ok || a.ID != audio.ID || a.Format != "pcm16" || !bytes.Equal(a.Data, audio.Data) || a.Transcript != "Hello" {
+		t.Errorf("Expected the audio to round trip, got %+v", decoded.Contents)
+	}
+
+	// Audio of different generations are kept apart
+	other := MergeChunks([]Message{
+		NewMessage(User, WithAudioContent("wav", []byte("a"))),
+		{Role: User, Contents: []ContentPart{AudioContent{ID: "audio_2", Format: "wav"}}},
+		{Role: User, Contents: []ContentPart{AudioContent{ID: "audio_3", Format: "wav"}}},
+	})
+	if len(other[0].Contents) != 2 {
+		t.Errorf("Expected distinct audio ids not merged, got %+v", other[0].Contents)
+	}
+}
@@ -2,8 +2,11 @@
 package model
 
 import (
+	"cmp"
 	"context"
 	"iter"
+	"slices"
+	"strings"
 )
 
 // Generation represents a complete or partial language model response.
@@ -19,6 +22,9 @@ type Generation struct {
 
 	// err stores any error that occurred during streaming.
 	Err error
+
+	// usage is the token usage reported by the provider.
+	usage Usage
 }
 
 // MessageIter is an alias for an iterator that yields Message values.
@@ -60,6 +66,17 @@ func (g *Generation) Error() error {
 	return g.Err
 }
 
+// Usage returns the tokens billed for the generation, once the stream is consumed when streaming.
+// It is zero when the provider does not report usage.
+func (g *Generation) Usage() Usage {
+	return g.usage
+}
+
+// SetUsage records the usage reported by the provider.
+func (g *Generation) SetUsage(u Usage) {
+	g.usage = u
+}
+
 // iterStream returns a one-time iterator that consumes the underlying stream.
 // As each message is received, it is appended to Messages.
 // This function is only called once; subsequent calls to All will yield from the populated Messages slice.
@@ -69,6 +86,7 @@ func (g *Generation) iterStream() MessageIter {
 		g.messages = make([]Message, 0, 1)
 		g.MsgIter = nil
 		for v := range seq {
+			g.messages = append(g.messages, v)
 			// Emit
 			if !yield(v) {
 				return
@@ -77,6 +95,49 @@ func (g *Generation) iterStream() MessageIter {
 	}
 }
 
+// Choices returns one complete message per generated choice, ordered by Index.
+// Streamed deltas are merged with [MergeChunks]. It consumes the stream,
+// and [Generation.Error] should be checked afterward.
+func (g *Generation) Choices() []Message {
+	var all []Message
+	for msg := range g.Messages() {
+		all = append(all, msg)
+	}
+	choices := MergeChunks(all)
+	slices.SortStableFunc(choices, func(a, b Message) int {
+		return cmp.Compare(a.Index, b.Index)
+	})
+	return choices
+}
+
+// Choice returns an iterator over the messages, or streamed deltas, of the choice with the given index.
+// Messages of other choices consumed along the way are kept, so every choice can be iterated in turn.
+// Breaking out of a choice still reads the rest of a stream, so the other choices remain complete.
+func (g *Generation) Choice(index int) MessageIter {
+	return func(yield func(Message) bool) {
+		done := false
+		for msg := range g.Messages() {
+			if !done && msg.Index == index {
+				done = !yield(msg)
+			}
+		}
+	}
+}
+
+// GenerateText returns the text of the first choice generated by m for messages,
+// joining its deltas when streaming.
+func GenerateText(ctx context.Context, m Model, messages []Message, opts ...ModelOption) (string, error) {
+	gen, err := m.Generate(ctx, messages, opts...)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for msg := range gen.Choice(0) {
+		sb.WriteString(msg.Text())
+	}
+	return sb.String(), gen.Error()
+}
+
 // MessageHandler defines a function that processes a single Message.
 // This is typically used for handling streamed responses in real-time.
 type MessageHandler func(Message)
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func chunk(index int, text string) Message {
+	msg := NewTextMessage(Assistant, text)
+	msg.Index = index
+	return msg
+}
+
+func texts(it MessageIter) []string {
+	var out []string
+	for msg := range it {
+		out = append(out, msg.Text())
+	}
+	return out
+}
+
+func TestGeneration_Choices(t *testing.T) {
+	interleaved := []Message{chunk(1, "B"), chunk(0, "A"), chunk(1, "b"), chunk(0, "a"), chunk(2, "C")}
+
+	tests := []struct {
+		name string
+		gen  func() *Generation
+	}{
+		{"Static", func() *Generation {
+			return NewGeneration([]Message{chunk(1, "Bb"), chunk(0, "Aa"), chunk(2, "C")})
+		}},
+		{"Stream", func() *Generation {
+			return NewGenerationWithStream(slices.Values(interleaved))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			choices := tt.gen().Choices()
+			var got []string
+			for i, c := range choices {
+				if c.Index != i {
+					t.Errorf("choice %d has index %d", i, c.Index)
+				}
+				got = append(got, c.Text())
+			}
+			if want := []string{"Aa", "Bb", "C"}; !slices.Equal(got, want) {
+				t.Errorf("Choices() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestGeneration_Choice(t *testing.T) {
+	gen := NewGenerationWithStream(slices.Values([]Message{chunk(1, "B"), chunk(0, "A"), chunk(1, "b"), chunk(0, "a")}))
+
+	if got := texts(gen.Choice(0)); !slices.Equal(got, []string{"A", "a"}) {
+		t.Errorf("Choice(0) = %q, want [A a]", got)
+	}
+	// The stream is consumed, other choices are replayed
+	if got := texts(gen.Choice(1)); !slices.Equal(got, []string{"B", "b"}) {
+		t.Errorf("Choice(1) = %q, want [B b]", got)
+	}
+	if got := texts(gen.Choice(2)); len(got) != 0 {
+		t.Errorf("Choice(2) = %q, want none", got)
+	}
+	if got := texts(gen.Messages()); len(got) != 4 {
+		t.Errorf("Messages() after consumption = %q, want every chunk", got)
+	}
+
+	// Breaking out of a choice keeps the other choices complete
+	gen = NewGenerationWithStream(slices.Values([]Message{chunk(0, "A"), chunk(1, "B"), chunk(0, "a"), chunk(1, "b")}))
+	for msg := range gen.Choice(0) {
+		if msg.Text() != "A" {
+			t.Errorf("Choice(0) first chunk = %q, want A", msg.Text())
+		}
+		break
+	}
+	if got := texts(gen.Choice(1)); !slices.Equal(got, []string{"B", "b"}) {
+		t.Errorf("Choice(1) after a partial Choice(0) = %q, want [B b]", got)
+	}
+	if got := texts(gen.Choice(0)); !slices.Equal(got, []string{"A", "a"}) {
+		t.Errorf("Choice(0) replayed = %q, want [A a]", got)
+	}
+}
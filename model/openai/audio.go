@@ -0,0 +1,147 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"nyxze/fayth/model"
+	"nyxze/fayth/model/openai/internal"
+)
+
+// Default audio options
+var (
+	DEFAULT_TRANSCRIPTION_OPTIONS = model.TranscriptionOptions{
+		Model: TranscriptionModelWhisper1,
+	}
+	DEFAULT_SPEECH_OPTIONS = model.SpeechOptions{
+		Model: SpeechModelTTS1,
+		Voice: "alloy",
+	}
+)
+
+// Compile type interface assertion
+var (
+	_ model.Transcriber = (*llm)(nil)
+	_ model.Synthesizer = (*llm)(nil)
+)
+
+// Transcribe implements the [model.Transcriber] interface with the Transcriptions API.
+// The audio format names the uploaded file, such as "mp3", "wav" or "m4a".
+func (m llm) Transcribe(ctx context.Context, audio model.AudioContent, opts ...model.TranscriptionOption) (*model.Transcription, error) {
+	options := DEFAULT_TRANSCRIPTION_OPTIONS
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if err := validateTranscription(options, audio); err != nil {
+		return nil, err
+	}
+
+	resp, err := m.client.Transcriptions.Create(ctx, internal.TranscriptionRequest{
+		File:           bytes.NewReader(audio.Data),
+		FileName:       "audio." + audio.Format,
+		Model:          options.Model,
+		Language:       options.Language,
+		Prompt:         options.Prompt,
+		ResponseFormat: string(options.Format),
+		Temperature:    options.Temperature,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t := &model.Transcription{
+		Text:     resp.Text,
+		Language: resp.Language,
+		Duration: seconds(resp.Duration),
+	}
+	for _, s := range resp.Segments {
+		t.Segments = append(t.Segments, model.Segment{
+			ID:    s.ID,
+			Start: seconds(s.Start),
+			End:   seconds(s.End),
+			Text:  s.Text,
+		})
+	}
+	return t, nil
+}
+
+// Synthesize implements the [model.Synthesizer] interface with the Speech API,
+// the audio is streamed as it is generated.
+func (m llm) Synthesize(ctx context.Context, text string, opts ...model.SpeechOption) (io.ReadCloser, error) {
+	options := DEFAULT_SPEECH_OPTIONS
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if err := validateSpeech(options, text); err != nil {
+		return nil, err
+	}
+	return m.client.Speech.Create(ctx, internal.SpeechRequest{
+		Model:          options.Model,
+		Input:          text,
+		Voice:          options.Voice,
+		Instructions:   options.Instructions,
+		ResponseFormat: options.Format,
+		Speed:          options.Speed,
+	})
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// validateTranscription checks the audio and the format requested
+func validateTranscription(options model.TranscriptionOptions, audio model.AudioContent) error {
+	if options.Model == "" {
+		return errors.New("no model provided")
+	}
+	if len(audio.Data) == 0 {
+		return errors.New("empty audio")
+	}
+	if audio.Format == "" {
+		return errors.New("audio format cannot be empty")
+	}
+	if options.Temperature < 0 || options.Temperature > 1 {
+		return errors.New("temperature must be between 0 and 1")
+	}
+	switch options.Format {
+	case "", model.TranscriptText, model.TranscriptJSON:
+	case model.TranscriptVerboseJSON, model.TranscriptSRT, model.TranscriptVTT:
+		// Only whisper produces timed transcriptions
+		if slices.Contains([]string{TranscriptionModelGPT4oTranscribe, TranscriptionModelGPT4oMiniTranscribe}, options.Model) {
+			return fmt.Errorf("%w: %s does not produce %s transcriptions", ErrUnsupported, options.Model, options.Format)
+		}
+	default:
+		return errors.New("transcript format must be 'text', 'json', 'verbose_json', 'srt' or 'vtt'")
+	}
+	return nil
+}
+
+// validateSpeech checks the text and the speech options
+func validateSpeech(options model.SpeechOptions, text string) error {
+	if options.Model == "" {
+		return errors.New("no model provided")
+	}
+	if text == "" {
+		return errors.New("empty text")
+	}
+	if options.Voice == "" {
+		return errors.New("no voice provided")
+	}
+	if options.Speed != 0 && (options.Speed < 0.25 || options.Speed > 4.0) {
+		return errors.New("speed must be between 0.25 and 4.0")
+	}
+	switch options.Format {
+	case "", "mp3", "opus", "aac", "flac", "wav", "pcm":
+	default:
+		return errors.New("speech format must be 'mp3', 'opus', 'aac', 'flac', 'wav' or 'pcm'")
+	}
+	if options.Instructions != "" && (options.Model == SpeechModelTTS1 || options.Model == SpeechModelTTS1HD) {
+		return fmt.Errorf("%w: %s does not accept instructions", ErrUnsupported, options.Model)
+	}
+	return nil
+}
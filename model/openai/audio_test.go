@@ -0,0 +1,182 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"nyxze/fayth/model"
+	"nyxze/fayth/model/openai/internal"
+)
+
+func TestOpenAI_Transcribe(t *testing.T) {
+	var form map[string]string
+	var respond func() *http.Response
+	mock := &mockRoundTripper{}
+	mock.responseFunc = func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("Invalid multipart request: %v", err)
+		}
+		form = map[string]string{"path": req.URL.Path}
+		for k, v := range req.MultipartForm.Value {
+			form[k] = v[0]
+		}
+		for k, files := range req.MultipartForm.File {
+			f, _ := files[0].Open()
+			data, _ := io.ReadAll(f)
+			form[k] = files[0].Filename + ":" + string(data)
+		}
+		return respond(), nil
+	}
+	llm, err := New(WithAPIKey("test-key"), WithHTTPClient(&http.Client{Transport: mock}))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	audio := model.AudioContent{Format: "mp3", Data: []byte("ID3")}
+
+	tests := []struct {
+		name     string
+		opts     []model.TranscriptionOption
+		response string
+		want     model.Transcription
+		form     map[string]string
+	}{
+		{
+			name:     "Default",
+			response: `{"text":"Hello world"}`,
+			want:     model.Transcription{Text: "Hello world"},
+			form:     map[string]string{"path": "/v1/audio/transcriptions", "file": "audio.mp3:ID3", "model": "whisper-1", "temperature": ""},
+		},
+		{
+			name: "Verbose",
+			opts: []model.TranscriptionOption{
+				model.WithTranscriptFormat(model.TranscriptVerboseJSON),
+				model.WithLanguage("en"),
+				model.WithTranscriptionPrompt("Greetings"),
+				model.WithTranscriptionTemperature(0.2),
+			},
+			response: `{"task":"transcribe","language":"english","duration":2.5,"text":"Hello world","segments":[{"id":0,"start":0.0,"end":1.2,"text":"Hello"},{"id":1,"start":1.2,"end":2.5,"text":" world"}]}`,
+			want: model.Transcription{Text: "Hello world", Language: "english", Duration: 2500 * time.Millisecond, Segments: []model.Segment{
+				{ID: 0, End: 1200 * time.Millisecond, Text: "Hello"},
+				{ID: 1, Start: 1200 * time.Millisecond, End: 2500 * time.Millisecond, Text: " world"},
+			}},
+			form: map[string]string{"response_format": "verbose_json", "language": "en", "prompt": "Greetings", "temperature": "0.2"},
+		},
+		{
+			name:     "Subtitles",
+			opts:     []model.TranscriptionOption{model.WithTranscriptFormat(model.TranscriptSRT)},
+			response: "1\n00:00:00,000 --> 00:00:02,500\nHello world\n",
+			want:     model.Transcription{Text: "1\n00:00:00,000 --> 00:00:02,500\nHello world\n"},
+			form:     map[string]string{"response_format": "srt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			respond = func() *http.Response { return mockResponse(http.StatusOK, tt.response) }
+			got, err := llm.Transcribe(context.Background(), audio, tt.opts...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("Expected transcription %s, got %s", wantJSON, gotJSON)
+			}
+			for k, v := range tt.form {
+				if form[k] != v {
+					t.Errorf("Expected form field %s = %q, got %q", k, v, form[k])
+				}
+			}
+		})
+	}
+
+	respond = func() *http.Response {
+		return mockResponse(http.StatusBadRequest, `{"error":{"message":"Invalid file format.","type":"invalid_request_error"}}`)
+	}
+	var apiErr internal.ApiError
+	if _, err := llm.Transcribe(context.Background(), audio); !errors.As(err, &apiErr) || apiErr.Message != "Invalid file format." {
+		t.Errorf("Expected an API error, got %v", err)
+	}
+	if _, err := llm.Transcribe(context.Background(), audio,
+		model.WithTranscriptionModel(TranscriptionModelGPT4oTranscribe),
+		model.WithTranscriptFormat(model.TranscriptVTT)); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported for vtt with gpt-4o-transcribe, got %v", err)
+	}
+	if _, err := llm.Transcribe(context.Background(), audio, model.WithTranscriptionTemperature(1.5)); err == nil {
+		t.Error("Expected an error for a temperature above 1")
+	}
+	if _, err := llm.Transcribe(context.Background(), model.AudioContent{Data: []byte("ID3")}); err == nil {
+		t.Error("Expected an error for audio without format")
+	}
+}
+
+func TestOpenAI_Synthesize(t *testing.T) {
+	var req map[string]any
+	mock := &mockRoundTripper{}
+	mock.responseFunc = func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v1/audio/speech" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Invalid request body: %v", err)
+		}
+		resp := mockResponse(http.StatusOK, "")
+		resp.Body = io.NopCloser(strings.NewReader("audio bytes"))
+		resp.Header.Set("Content-Type", "audio/mpeg")
+		return resp, nil
+	}
+	llm, err := New(WithAPIKey("test-key"), WithHTTPClient(&http.Client{Transport: mock}))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+
+	audio, err := llm.Synthesize(context.Background(), "Hello world",
+		model.WithSpeechModel(SpeechModelGPT4oMiniTTS),
+		model.WithVoice("coral"),
+		model.WithSpeechFormat("wav"),
+		model.WithSpeed(1.5),
+		model.WithInstructions("Speak cheerfully"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer audio.Close()
+	data, err := io.ReadAll(audio)
+	if err != nil || string(data) != "audio bytes" {
+		t.Errorf("Expected the streamed audio, got %q, %v", data, err)
+	}
+	want := map[string]any{"model": "gpt-4o-mini-tts", "input": "Hello world", "voice": "coral", "response_format": "wav", "speed": 1.5, "instructions": "Speak cheerfully"}
+	for k, v := range want {
+		if req[k] != v {
+			t.Errorf("Expected request %s = %v, got %v", k, v, req[k])
+		}
+	}
+
+	if _, err := llm.Synthesize(context.Background(), "Hello"); err != nil || req["model"] != "tts-1" || req["voice"] != "alloy" {
+		t.Errorf("Expected the default model and voice, got %v, %v", req, err)
+	}
+
+	tests := []struct {
+		name          string
+		text          string
+		opts          []model.SpeechOption
+		expectedError string
+	}{
+		{"empty text", "", nil, "empty text"},
+		{"invalid speed", "Hello", []model.SpeechOption{model.WithSpeed(5)}, "speed must be between 0.25 and 4.0"},
+		{"invalid format", "Hello", []model.SpeechOption{model.WithSpeechFormat("ogg")}, "speech format must be"},
+		{"instructions on tts-1", "Hello", []model.SpeechOption{model.WithInstructions("Whisper")}, "tts-1 does not accept instructions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := llm.Synthesize(context.Background(), tt.text, tt.opts...)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package openai
 
 import (
+	"log/slog"
 	"net/http"
 	"nyxze/fayth/model"
 	"nyxze/fayth/model/openai/internal"
+	"nyxze/fayth/trace"
 )
 
 type ClientOption func(*clientOptions) error
@@ -12,6 +14,8 @@ type ClientOption func(*clientOptions) error
 type clientOptions struct {
 	modelOpts    []model.ModelOption
 	internalOpts []internal.CallOption
+	logger       *slog.Logger
+	tracer       trace.Tracer
 }
 
 // WithAPIKey sets the API key to authenticate requests.
@@ -54,3 +58,23 @@ func WithHTTPClient(client *http.Client) ClientOption {
 		return nil
 	}
 }
+
+// WithLogger sets the structured logger used for request diagnostics.
+// Nothing is logged when no logger is provided.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(opts *clientOptions) error {
+		opts.logger = logger
+		opts.internalOpts = append(opts.internalOpts, internal.WithLogger(logger))
+		return nil
+	}
+}
+
+// WithTracer sets the tracer recording spans for generations and HTTP attempts.
+// Spans are propagated to OpenAI through the W3C traceparent header.
+func WithTracer(tracer trace.Tracer) ClientOption {
+	return func(opts *clientOptions) error {
+		opts.tracer = tracer
+		opts.internalOpts = append(opts.internalOpts, internal.WithTracer(tracer))
+		return nil
+	}
+}
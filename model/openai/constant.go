@@ -68,3 +68,20 @@ const (
 	ChatModelGPT3_5Turbo0125                  ChatModel = "gpt-3.5-turbo-0125"
 	ChatModelGPT3_5Turbo16k0613               ChatModel = "gpt-3.5-turbo-16k-0613"
 )
+
+type TranscriptionModel = string
+type SpeechModel = string
+
+// List of Transcription model exposed by OpenAI
+const (
+	TranscriptionModelWhisper1            TranscriptionModel = "whisper-1"
+	TranscriptionModelGPT4oTranscribe     TranscriptionModel = "gpt-4o-transcribe"
+	TranscriptionModelGPT4oMiniTranscribe TranscriptionModel = "gpt-4o-mini-transcribe"
+)
+
+// List of Speech model exposed by OpenAI
+const (
+	SpeechModelTTS1         SpeechModel = "tts-1"
+	SpeechModelTTS1HD       SpeechModel = "tts-1-hd"
+	SpeechModelGPT4oMiniTTS SpeechModel = "gpt-4o-mini-tts"
+)
@@ -0,0 +1,245 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"nyxze/choco-go"
+	choco_json "nyxze/choco-go/json"
+)
+
+const (
+	transcriptionsAPI = "audio/transcriptions"
+	speechAPI         = "audio/speech"
+)
+
+// TranscriptionRequest represents a request to the OpenAI Transcriptions API
+type TranscriptionRequest struct {
+	File     io.Reader // Audio to transcribe
+	FileName string    // Name of the audio file, its extension tells the audio format
+
+	Model          string  // Model to use for transcription
+	Language       string  // Language of the audio in ISO-639-1
+	Prompt         string  // Text guiding the style of the transcription
+	ResponseFormat string  // "json", "text", "srt", "verbose_json" or "vtt"
+	Temperature    float64 // Sampling temperature (0.0 to 1.0)
+}
+
+// TranscriptionResponse represents the response of the Transcriptions API.
+// Only Text is set for the text, srt and vtt formats, holding the response as is.
+type TranscriptionResponse struct {
+	Text     string                 `json:"text"`
+	Language string                 `json:"language,omitempty"`
+	Duration float64                `json:"duration,omitempty"` // Duration of the audio in seconds
+	Segments []TranscriptionSegment `json:"segments,omitempty"` // Timed segments, in the verbose_json format
+}
+
+// TranscriptionSegment is a timed part of a transcription
+type TranscriptionSegment struct {
+	ID    int     `json:"id"`
+	Start float64 `json:"start"` // Start time of the segment in seconds
+	End   float64 `json:"end"`   // End time of the segment in seconds
+	Text  string  `json:"text"`
+}
+
+// SpeechRequest represents a request to the OpenAI Speech API
+type SpeechRequest struct {
+	Model          string  `json:"model"`                    // Model to use for speech
+	Input          string  `json:"input"`                    // Text to speak
+	Voice          string  `json:"voice"`                    // Voice speaking the input, such as "alloy"
+	Instructions   string  `json:"instructions,omitzero"`    // Tone of the voice, not supported by tts-1 models
+	ResponseFormat string  `json:"response_format,omitzero"` // "mp3", "opus", "aac", "flac", "wav" or "pcm"
+	Speed          float64 `json:"speed,omitzero"`           // Speed of the speech (0.25 to 4.0)
+}
+
+type TranscriptionService struct {
+	// CallOption at Service layer
+	// See CallOption documentation
+	Options []CallOption
+}
+
+func NewTranscriptionService(opts ...CallOption) TranscriptionService {
+	return TranscriptionService{
+		Options: opts,
+	}
+}
+
+// OpenAI Transcriptions API
+// https://platform.openai.com/docs/api-reference/audio/createTranscription
+// Endpoint
+// https://api.openai.com/v1/audio/transcriptions
+func (s *TranscriptionService) Create(ctx context.Context, tReq TranscriptionRequest, opts ...CallOption) (*TranscriptionResponse, error) {
+	config, err := newConfig(slices.Concat(s.Options, opts))
+	if err != nil {
+		return nil, err
+	}
+	logger := config.logger().With(
+		"endpoint", transcriptionsAPI,
+		"model", tReq.Model,
+	)
+
+	req, err := newTranscriptionRequest(ctx, tReq)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	logger.InfoContext(ctx, "openai: transcription started")
+	res, err := send(req, config)
+	if err != nil {
+		logger.ErrorContext(ctx, "openai: transcription failed", "latency", time.Since(start), "error", err)
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	logBody(ctx, logger, "openai: transcription response body", b)
+
+	var tRes TranscriptionResponse
+	switch tReq.ResponseFormat {
+	case "text", "srt", "vtt":
+		tRes.Text = string(b)
+	default:
+		if err := json.Unmarshal(b, &tRes); err != nil {
+			return nil, err
+		}
+	}
+	logger.InfoContext(ctx, "openai: transcription finished", "latency", time.Since(start))
+	return &tRes, nil
+}
+
+// newTranscriptionRequest encodes the request as a multipart form
+func newTranscriptionRequest(ctx context.Context, tReq TranscriptionRequest) (*choco.Request, error) {
+	req, err := choco.NewRequest(ctx, http.MethodPost, transcriptionsAPI)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", tReq.FileName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(file, tReq.File); err != nil {
+		return nil, err
+	}
+	fields := [][2]string{
+		{"model", tReq.Model},
+		{"language", tReq.Language},
+		{"prompt", tReq.Prompt},
+		{"response_format", tReq.ResponseFormat},
+	}
+	if tReq.Temperature != 0 {
+		fields = append(fields, [2]string{"temperature", strconv.FormatFloat(tReq.Temperature, 'f', -1, 64)})
+	}
+	for _, f := range fields {
+		if f[1] == "" {
+			continue
+		}
+		if err := form.WriteField(f[0], f[1]); err != nil {
+			return nil, err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	b := body.Bytes()
+	raw := req.Raw()
+	raw.Body = io.NopCloser(bytes.NewReader(b))
+	raw.ContentLength = int64(len(b))
+	raw.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(b)), nil }
+	raw.Header.Set("Content-Type", form.FormDataContentType())
+	return req, nil
+}
+
+type SpeechService struct {
+	// CallOption at Service layer
+	// See CallOption documentation
+	Options []CallOption
+}
+
+func NewSpeechService(opts ...CallOption) SpeechService {
+	return SpeechService{
+		Options: opts,
+	}
+}
+
+// OpenAI Speech API, the returned audio is streamed as it is generated
+// and must be closed by the caller.
+// https://platform.openai.com/docs/api-reference/audio/createSpeech
+// Endpoint
+// https://api.openai.com/v1/audio/speech
+func (s *SpeechService) Create(ctx context.Context, sReq SpeechRequest, opts ...CallOption) (io.ReadCloser, error) {
+	config, err := newConfig(slices.Concat(s.Options, opts))
+	if err != nil {
+		return nil, err
+	}
+	logger := config.logger().With(
+		"endpoint", speechAPI,
+		"model", sReq.Model,
+	)
+
+	req, err := choco.NewRequest(ctx, http.MethodPost, speechAPI)
+	if err != nil {
+		return nil, err
+	}
+	if err := choco_json.MarshalAsJSON(req, sReq); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	logger.InfoContext(ctx, "openai: speech started")
+	logBody(ctx, logger, "openai: speech request body", sReq)
+	res, err := send(req, config)
+	if err != nil {
+		logger.ErrorContext(ctx, "openai: speech failed", "latency", time.Since(start), "error", err)
+		return nil, err
+	}
+	logger.InfoContext(ctx, "openai: speech stream opened",
+		"status", res.StatusCode,
+		"latency", time.Since(start),
+		"content_type", res.Header.Get("Content-Type"),
+	)
+	return res.Body, nil
+}
+
+// newConfig resolves the call options, which must hold an API key
+func newConfig(opts []CallOption) (*CallConfig, error) {
+	config := &CallConfig{}
+	for _, opt := range opts {
+		if err := opt(config); err != nil {
+			return nil, err
+		}
+	}
+	if config.APIKey == "" {
+		return nil, ErrMissingToken
+	}
+	return config, nil
+}
+
+// send sends the request, converting error statuses to an [ApiError]
+func send(req *choco.Request, config *CallConfig) (*http.Response, error) {
+	res, err := sendRequest(req, config)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 400 {
+		apiError := NewErrorFromResponse(res)
+		apiError.Request = req.Raw()
+		res.Body.Close()
+		return nil, apiError
+	}
+	return res, nil
+}
@@ -2,9 +2,12 @@ package internal
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
+
+	"nyxze/fayth/trace"
 )
 
 // CallOption represents a functional option that modifies the behavior of an OpenAI API call.
@@ -27,6 +30,24 @@ type CallConfig struct {
 	Project      string
 	APIKey       string
 	HTTPClient   *http.Client
+	Logger       *slog.Logger
+	Tracer       trace.Tracer
+}
+
+// logger returns the configured logger, or one discarding every record.
+func (c *CallConfig) logger() *slog.Logger {
+	if c.Logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return c.Logger
+}
+
+// tracer returns the configured tracer, or a no-op one.
+func (c *CallConfig) tracer() trace.Tracer {
+	if c.Tracer == nil {
+		return trace.Noop()
+	}
+	return c.Tracer
 }
 
 func (c *CallConfig) IsValid() error {
@@ -76,3 +97,21 @@ func WithHTTPClient(client *http.Client) CallOption {
 		return nil
 	}
 }
+
+// WithLogger sets the logger used to report request diagnostics.
+// Request and response bodies are only emitted at debug level.
+func WithLogger(logger *slog.Logger) CallOption {
+	return func(cc *CallConfig) error {
+		cc.Logger = logger
+		return nil
+	}
+}
+
+// WithTracer sets the tracer recording a span for each HTTP attempt.
+// The span identifiers are propagated in the traceparent header.
+func WithTracer(tracer trace.Tracer) CallOption {
+	return func(cc *CallConfig) error {
+		cc.Tracer = tracer
+		return nil
+	}
+}
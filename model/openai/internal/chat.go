@@ -4,11 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"iter"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"time"
 
 	"nyxze/choco-go"
 	choco_json "nyxze/choco-go/json"
@@ -56,13 +57,27 @@ func (c *ChatService) Completion(ctx context.Context, chatRequest ChatCompletion
 		return nil, ErrMissingToken
 	}
 
+	logger := config.logger().With(
+		"endpoint", completionsAPI,
+		"model", chatRequest.Model,
+		"stream", chatRequest.Stream,
+	)
+
 	req, err := newRequest(ctx, http.MethodPost, chatRequest)
 	if err != nil {
 		return nil, err
 	}
 
+	start := time.Now()
+	logger.InfoContext(ctx, "openai: chat completion started")
+	logBody(ctx, logger, "openai: chat completion request body", chatRequest)
+
 	res, err := sendRequest(req, config)
 	if err != nil {
+		logger.ErrorContext(ctx, "openai: chat completion failed",
+			"latency", time.Since(start),
+			"error", err,
+		)
 		return nil, err
 	}
 
@@ -70,6 +85,12 @@ func (c *ChatService) Completion(ctx context.Context, chatRequest ChatCompletion
 	if res.StatusCode >= 400 {
 		apiError := NewErrorFromResponse(res)
 		apiError.Request = req.Raw()
+		logger.ErrorContext(ctx, "openai: chat completion failed",
+			"status", res.StatusCode,
+			"latency", time.Since(start),
+			"error_type", apiError.Type,
+			"error_code", apiError.Code,
+		)
 		return nil, apiError
 	}
 	// Stream path
@@ -80,14 +101,24 @@ func (c *ChatService) Completion(ctx context.Context, chatRequest ChatCompletion
 		if err != nil {
 			return nil, err
 		}
+		logBody(ctx, logger, "openai: chat completion response body", b)
 		err = json.NewDecoder(bytes.NewReader(b)).Decode(&chatResponse)
 		if err != nil {
 			return nil, err
 		}
+		logger.InfoContext(ctx, "openai: chat completion finished",
+			"status", res.StatusCode,
+			"latency", time.Since(start),
+			usageAttr(chatResponse.Usage),
+		)
 		return &ChatResponse{Response: &chatResponse}, nil
 	}
+	logger.InfoContext(ctx, "openai: chat completion stream opened",
+		"status", res.StatusCode,
+		"latency", time.Since(start),
+	)
 	return &ChatResponse{
-		StreamIter: readChunk(ctx, res.Body),
+		StreamIter: readChunk(ctx, res.Body, logger, start),
 	}, nil
 }
 
@@ -96,17 +127,36 @@ type Event struct {
 	Event string `sse:"event"`
 }
 
-func readChunk(ctx context.Context, r io.ReadCloser) iter.Seq[ChatCompletionChunk] {
+func readChunk(ctx context.Context, r io.ReadCloser, logger *slog.Logger, start time.Time) iter.Seq[ChatCompletionChunk] {
 	return func(yield func(ChatCompletionChunk) bool) {
+		var (
+			chunks  int
+			dropped int
+			usage   CompletionUsage
+		)
+		defer func() {
+			logger.InfoContext(ctx, "openai: chat completion stream finished",
+				"latency", time.Since(start),
+				"chunks", chunks,
+				"dropped_chunks", dropped,
+				usageAttr(usage),
+			)
+		}()
 		sseIter := sse.NewSSEIter[Event](r, "[DONE]")
 		for evt := range seqio.Range(ctx, sseIter) {
 			var value ChatCompletionChunk
 			// Parse JSON chunk into ChatCompletionChunk struct
 			if err := json.Unmarshal([]byte(evt.Data), &value); err != nil {
 				// Skip failing parsing
-				fmt.Println("Failed to parse to ChatCompletion chunk")
+				dropped++
+				logger.WarnContext(ctx, "openai: dropped malformed chat completion chunk", "error", err)
+				logBody(ctx, logger, "openai: dropped chunk body", evt.Data)
 				continue
 			}
+			chunks++
+			if value.Usage.TotalTokens > 0 {
+				usage = value.Usage
+			}
 			if !yield(value) {
 				return
 			}
@@ -138,6 +188,9 @@ func sendRequest(req *choco.Request, config *CallConfig) (*http.Response, error)
 		funcs = append(funcs, applyBaseUrl(config.BaseUrl))
 	}
 
+	// Trace and log the final request, once headers and URL are resolved
+	funcs = append(funcs, applyTracing(config.tracer()), applyLogging(config.logger()))
+
 	// Create pipeline with custom client if provided
 	opts := []choco.PipelineOption{
 		choco.WithStepFuncs(funcs...),
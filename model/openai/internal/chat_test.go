@@ -1,9 +1,11 @@
 package internal
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
 	"testing"
@@ -470,3 +472,28 @@ func CreateTestChatService(t *mockTransport) ChatService {
 
 	return service
 }
+
+func TestReadChunk_LogsDroppedChunks(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	body := `data: {"id":"1","choices":[{"index":0,"delta":{"content":"ok"}}]}
+
+data: {not json}
+
+data: [DONE]
+`
+	var received int
+	for range readChunk(context.Background(), io.NopCloser(strings.NewReader(body)), logger, time.Now()) {
+		received++
+	}
+	if received != 1 {
+		t.Fatalf("Expected 1 chunk, got %d", received)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "openai: dropped malformed chat completion chunk") {
+		t.Errorf("Expected dropped chunk record, got %s", out)
+	}
+	if !strings.Contains(out, `"dropped_chunks":1`) {
+		t.Errorf("Expected dropped_chunks count, got %s", out)
+	}
+}
@@ -29,10 +29,14 @@ type ChatCompletionChunkChoice struct {
 type ChatCompletionDelta struct {
 	// The contents of the message.
 	Content string `json:"content"`
+	// The reasoning preceding the contents, sent by OpenAI-compatible reasoning providers such as DeepSeek.
+	ReasoningContent string `json:"reasoning_content,omitempty"`
 	// The refusal message generated by the model.
 	Refusal string `json:"refusal"`
 	// The role of the author of this message.
 	Role Role `json:"role"`
+	// The partial audio response, when the audio output modality is requested.
+	Audio *ChatCompletionAudio `json:"audio,omitempty"`
 	// Deprecated
 	FunctionCall ToolFunction `json:"function_call"`
 	// The tool calls generated by the model, such as function calls.
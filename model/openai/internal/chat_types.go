@@ -2,9 +2,11 @@ package internal
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"nyxze/fayth/model"
+	"slices"
 )
 
 // Generated from https://platform.openai.com/docs/api-reference/chat/object
@@ -41,6 +43,8 @@ type ChatCompletionChoice struct {
 type ChatCompletionMessage struct {
 	// The contents of the message.
 	Content string `json:"content"`
+	// The reasoning preceding the contents, sent by OpenAI-compatible reasoning providers such as DeepSeek.
+	ReasoningContent string `json:"reasoning_content,omitempty"`
 	// The refusal message generated by the model.
 	Refusal string `json:"refusal"`
 	// The role of the author of this message.
@@ -51,11 +55,19 @@ type ChatCompletionMessage struct {
 	// If the audio output modality is requested, this object contains data about the
 	// audio response from the model.
 	// [Learn more](https://platform.openai.com/docs/guides/audio).
-	// Audio ChatCompletionAudio `json:"audio,nullable"`
+	Audio *ChatCompletionAudio `json:"audio,omitempty"`
 	// The tool calls generated by the model, such as function calls.
 	ToolCalls []ChatCompletionToolCall `json:"tool_calls"`
 }
 
+// ChatCompletionAudio is the spoken response of an audio model, streamed in partial deltas.
+type ChatCompletionAudio struct {
+	ID         string `json:"id,omitempty"`         // Identifier referencing the audio in later turns.
+	Data       string `json:"data,omitempty"`       // Base64 encoded audio, in the requested format.
+	ExpiresAt  int64  `json:"expires_at,omitempty"` // Unix timestamp after which the audio can no longer be referenced.
+	Transcript string `json:"transcript,omitempty"` // Transcript of the audio.
+}
+
 type ChatCompletionToolCall struct {
 	Id       string       `json:"id"`
 	Type     string       `json:"type"`
@@ -138,6 +150,11 @@ type ChatCompletionRequest struct {
 	Temperature float64 `json:"temperature,omitzero"` // Controls randomness (0.0 to 2.0)
 	TopP        float64 `json:"top_p,omitzero"`       // Nucleus sampling parameter (0.0 to 1.0)
 	MaxTokens   int     `json:"max_tokens,omitzero"`  // Maximum tokens to generate
+	N           int     `json:"n,omitzero"`           // Number of choices to generate
+
+	// Reasoning parameters
+	MaxCompletionTokens int    `json:"max_completion_tokens,omitzero"` // Maximum tokens to generate, reasoning included
+	ReasoningEffort     string `json:"reasoning_effort,omitzero"`      // Effort of reasoning models: low, medium or high
 
 	// Penalty parameters
 	FrequencyPenalty float64 `json:"frequency_penalty,omitzero"` // Frequency penalty (-2.0 to 2.0)
@@ -151,10 +168,26 @@ type ChatCompletionRequest struct {
 	// Response format
 	ResponseFormat ResponseFormat `json:"response_format,omitzero"` // Response format specification
 
+	// Audio parameters
+	Modalities []string     `json:"modalities,omitzero"` // Output types to generate: "text", "audio"
+	Audio      *AudioParams `json:"audio,omitempty"`     // Voice and format of the audio output
+
 	// Streaming and logging
-	Stream      bool `json:"stream,omitzero"`       // Enable streaming responses
-	LogProbs    bool `json:"logprobs,omitzero"`     // Include log probabilities
-	TopLogProbs int  `json:"top_logprobs,omitzero"` // Number of top log probabilities (0-20)
+	Stream        bool           `json:"stream,omitzero"`          // Enable streaming responses
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"` // Options of streaming responses
+	LogProbs      bool           `json:"logprobs,omitzero"`        // Include log probabilities
+	TopLogProbs   int            `json:"top_logprobs,omitzero"`    // Number of top log probabilities (0-20)
+}
+
+// StreamOptions configures streaming responses
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"` // Send the usage in a last chunk without choices
+}
+
+// AudioParams configures the audio output
+type AudioParams struct {
+	Voice  string `json:"voice"`  // Voice speaking the response, such as "alloy"
+	Format string `json:"format"` // "wav", "mp3", "flac", "opus" or "pcm16"
 }
 
 // ResponseFormat specifies the format of the model's output
@@ -173,6 +206,14 @@ type ChatMessage struct {
 
 	// Contents of the message
 	Contents []ChatContent `json:"-"`
+
+	// Audio previously generated by the model, referenced in assistant messages
+	Audio *ChatMessageAudio `json:"audio,omitempty"`
+}
+
+// ChatMessageAudio references a generated audio by its identifier
+type ChatMessageAudio struct {
+	ID string `json:"id"`
 }
 
 func (c *ChatMessage) UnmarshalJSON(data []byte) error {
@@ -224,28 +265,31 @@ func (c ChatMessage) MarshalJSON() ([]byte, error) {
 
 	// Multi content
 	return json.Marshal(struct {
-		Role    Role          `json:"role"`
-		Name    string        `json:"name,omitempty"`
-		Content []ChatContent `json:"content"`
+		Role    Role              `json:"role"`
+		Name    string            `json:"name,omitempty"`
+		Content []ChatContent     `json:"content"`
+		Audio   *ChatMessageAudio `json:"audio,omitempty"`
 	}{
 		Role:    c.Role,
 		Name:    c.Name,
 		Content: c.Contents,
+		Audio:   c.Audio,
 	})
 }
 
 // Represent any kind of content that ChatCompletion can produce
 // Field to read depend of the Type fied (e.g: Text field for type of "Text")
 type ChatContent struct {
-	Type  ContentType `json:"type"`
-	Text  string      `json:"text,omitempty"`
-	Audio struct {
+	Type    ContentType `json:"type"`
+	Text    string      `json:"text,omitempty"`
+	Refusal string      `json:"refusal,omitempty"`
+	Audio   struct {
 		Data   string `json:"data"`
 		Format string `json:"format"`
 	} `json:"input_audio,omitzero"`
 	Image struct {
 		Url    string `json:"url"`
-		Detail string `json:"detail"`
+		Detail string `json:"detail,omitempty"`
 	} `json:"image_url,omitzero"`
 }
 
@@ -263,6 +307,30 @@ func (c ChatContent) MarshalJSON() ([]byte, error) {
 			base: base{Type: c.Type},
 			Text: c.Text,
 		})
+	case RefusalContent:
+		return json.Marshal(struct {
+			base
+			Refusal string `json:"refusal"`
+		}{
+			base:    base{Type: c.Type},
+			Refusal: c.Refusal,
+		})
+	case AudioInputContent:
+		return json.Marshal(struct {
+			base
+			Audio any `json:"input_audio"`
+		}{
+			base:  base{Type: c.Type},
+			Audio: c.Audio,
+		})
+	case ImageURLContent:
+		return json.Marshal(struct {
+			base
+			Image any `json:"image_url"`
+		}{
+			base:  base{Type: c.Type},
+			Image: c.Image,
+		})
 	default:
 		return nil, fmt.Errorf("unsupported content type: %s", c.Type)
 	}
@@ -292,7 +360,44 @@ func ToChatContent(contents []model.ContentPart) []ChatContent {
 				Type: TextContent,
 				Text: c.(model.TextContent).Text,
 			})
+		case model.RefusalKind:
+			// Only explained refusals are sent back, filtered messages have nothing to send
+			if text := c.(model.RefusalContent).Text; text != "" {
+				parts = append(parts, ChatContent{Type: RefusalContent, Refusal: text})
+			}
+		case model.AudioKind:
+			// Generated audio is referenced by its identifier, see [ToChatAudio]
+			audio := c.(model.AudioContent)
+			if audio.ID != "" {
+				continue
+			}
+			part := ChatContent{Type: AudioInputContent}
+			part.Audio.Data = base64.StdEncoding.EncodeToString(audio.Data)
+			part.Audio.Format = audio.Format
+			parts = append(parts, part)
+		case model.ImageKind:
+			part := ChatContent{Type: ImageURLContent}
+			part.Image.Url = imageURL(c.(model.ImageContent))
+			parts = append(parts, part)
 		}
 	}
 	return parts
 }
+
+// ToChatAudio returns the reference to the last generated audio of contents, if any
+func ToChatAudio(contents []model.ContentPart) *ChatMessageAudio {
+	for _, c := range slices.Backward(contents) {
+		if audio, ok := c.(model.AudioContent); ok && audio.ID != "" {
+			return &ChatMessageAudio{ID: audio.ID}
+		}
+	}
+	return nil
+}
+
+// imageURL returns the url of an image, inlining raw data as a data url
+func imageURL(img model.ImageContent) string {
+	if img.SourceType == model.ImageSourceURL {
+		return string(img.Data)
+	}
+	return "data:" + img.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(img.Data)
+}
@@ -15,13 +15,17 @@ var (
 // Each subclients correspond to a given service, rather than providing all operations on a single client.
 // See github.com/openai/openai-go for references
 type Client struct {
-	Options []CallOption
-	Chat    ChatService
+	Options        []CallOption
+	Chat           ChatService
+	Transcriptions TranscriptionService
+	Speech         SpeechService
 }
 
 func NewClient(opts ...CallOption) (client Client) {
 	opts = append(DefaultClientOptions(), opts...)
 	client.Chat = NewChatService(opts...)
+	client.Transcriptions = NewTranscriptionService(opts...)
+	client.Speech = NewSpeechService(opts...)
 	return
 }
 
@@ -24,15 +24,17 @@ type ContentType string
 
 const (
 	TextContent       ContentType = "text"
+	ImageURLContent   ContentType = "image_url"
 	AudioInputContent ContentType = "input_audio"
-	ResusalContent    ContentType = "refusal"
+	RefusalContent    ContentType = "refusal"
 )
 
 type FinishReason string
 
 const (
-	STOP          = "stop"
-	LENGTH        = "length"
-	TOOL_CALL     = "tool_calls"
-	FUNCTION_CALL = "function_call"
+	STOP           = "stop"
+	LENGTH         = "length"
+	TOOL_CALL      = "tool_calls"
+	FUNCTION_CALL  = "function_call"
+	CONTENT_FILTER = "content_filter"
 )
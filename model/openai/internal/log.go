@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"nyxze/choco-go"
+)
+
+const redacted = "[REDACTED]"
+
+// Headers carrying credentials, never written to logs in clear
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Api-Key",
+	"OpenAI-Organization",
+	"OpenAI-Project",
+}
+
+// redactHeaders returns a copy of h with credential values replaced.
+func redactHeaders(h http.Header) http.Header {
+	clone := h.Clone()
+	for _, k := range sensitiveHeaders {
+		if clone.Get(k) != "" {
+			clone.Set(k, redacted)
+		}
+	}
+	return clone
+}
+
+// usageAttr groups token usage statistics under a single "usage" key.
+func usageAttr(u CompletionUsage) slog.Attr {
+	return slog.Group("usage",
+		slog.Int("prompt_tokens", u.PromptTokens),
+		slog.Int("completion_tokens", u.CompletionTokens),
+		slog.Int("total_tokens", u.TotalTokens),
+	)
+}
+
+// logBody emits v as JSON at debug level, skipping the encoding when debug is disabled.
+func logBody(ctx context.Context, logger *slog.Logger, msg string, v any) {
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	var body string
+	switch b := v.(type) {
+	case []byte:
+		body = string(b)
+	case string:
+		body = b
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return
+		}
+		body = string(raw)
+	}
+	logger.DebugContext(ctx, msg, "body", body)
+}
+
+// applyLogging reports each HTTP attempt sent through the pipeline.
+func applyLogging(logger *slog.Logger) choco.PipelineStepFunc {
+	return func(req *choco.Request, next choco.RequestHandlerFunc) (*http.Response, error) {
+		raw := req.Raw()
+		ctx := raw.Context()
+		logger.DebugContext(ctx, "openai: sending http request",
+			"method", raw.Method,
+			"url", raw.URL.String(),
+			"headers", redactHeaders(raw.Header),
+		)
+		start := time.Now()
+		res, err := next(req)
+		if err != nil {
+			logger.WarnContext(ctx, "openai: http request failed",
+				"latency", time.Since(start),
+				"error", err,
+			)
+			return res, err
+		}
+		logger.DebugContext(ctx, "openai: received http response",
+			"status", res.StatusCode,
+			"latency", time.Since(start),
+		)
+		return res, nil
+	}
+}
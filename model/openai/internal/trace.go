@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+
+	"nyxze/choco-go"
+	"nyxze/fayth/trace"
+)
+
+const traceParentHeader = "traceparent"
+
+// applyTracing records a span around each HTTP attempt and propagates
+// the W3C trace context to OpenAI through the traceparent header.
+func applyTracing(tracer trace.Tracer) choco.PipelineStepFunc {
+	return func(req *choco.Request, next choco.RequestHandlerFunc) (*http.Response, error) {
+		raw := req.Raw()
+		ctx, span := tracer.Start(raw.Context(), "HTTP "+raw.Method,
+			trace.String("http.request.method", raw.Method),
+			trace.String("server.address", raw.URL.Host),
+			trace.String("url.path", raw.URL.Path),
+		)
+		defer span.End()
+
+		// Fallback to the caller span when the tracer does not create one
+		sc := span.SpanContext()
+		if !sc.IsValid() {
+			sc = trace.SpanFromContext(ctx).SpanContext()
+		}
+		if tp := sc.TraceParent(); tp != "" {
+			raw.Header.Set(traceParentHeader, tp)
+		}
+
+		res, err := next(req)
+		if err != nil {
+			span.RecordError(err)
+			return res, err
+		}
+		span.SetAttributes(trace.Int("http.response.status_code", res.StatusCode))
+		if res.StatusCode >= 400 {
+			span.RecordError(fmt.Errorf("http status %d", res.StatusCode))
+		}
+		return res, nil
+	}
+}
@@ -2,11 +2,18 @@ package openai
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
 
 	"nyxze/fayth/model"
 	"nyxze/fayth/model/openai/internal"
+	"nyxze/fayth/trace"
 )
 
 // Errors
@@ -14,8 +21,12 @@ var (
 	ErrNoContentInResponse = errors.New("no content in generation response")
 	ErrModelGen            = errors.New("failed to convert to generation type")
 	ErrInvalidMimeType     = errors.New("invalid mime type on content")
+	ErrUnsupported         = errors.New("unsupported by model")
 )
 
+// System name reported in traces
+const systemName = "openai"
+
 // Default options
 var DEFAULT_OPTIONS = model.ModelOptions{
 	Model: ChatModelGPT4,
@@ -31,6 +42,12 @@ type llm struct {
 
 	// Global options
 	options model.ModelOptions
+
+	// Logger for diagnostics outside of the HTTP layer
+	logger *slog.Logger
+
+	// Tracer recording a span per generation
+	tracer trace.Tracer
 }
 
 // Compile type interface assertion
@@ -49,9 +66,21 @@ func New(opts ...ClientOption) (*llm, error) {
 	}
 	client := internal.NewClient(options.internalOpts...)
 
+	logger := options.logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	tracer := options.tracer
+	if tracer == nil {
+		tracer = trace.Noop()
+	}
+
 	model := &llm{
 		client:  &client,
 		options: DEFAULT_OPTIONS,
+		logger:  logger,
+		tracer:  tracer,
 	}
 
 	for _, opt := range options.modelOpts {
@@ -67,9 +96,27 @@ func (m llm) Generate(ctx context.Context, messages []model.Message, opts ...mod
 	}
 
 	options := model.MergeOptions(m.options, opts...)
+	if options.AutoContinue > 0 {
+		return model.AutoContinue(ctx, m, messages, options, opts...)
+	}
 
+	ctx, span := m.tracer.Start(ctx, trace.OperationChat+" "+options.Model,
+		trace.RequestAttributes(systemName, trace.OperationChat, options)...)
+
+	gen, err := m.generate(ctx, span, messages, options)
+	if err != nil {
+		span.RecordError(err)
+		span.End()
+		return nil, err
+	}
+	return gen, nil
+}
+
+// generate performs the request, the span is ended by the caller on error,
+// at the end of the stream when streaming, or here otherwise.
+func (m llm) generate(ctx context.Context, span trace.Span, messages []model.Message, options model.ModelOptions) (*model.Generation, error) {
 	// Validate options
-	if err := validateOptions(options); err != nil {
+	if err := validateOptions(options, messages); err != nil {
 		return nil, err
 	}
 
@@ -89,6 +136,7 @@ func (m llm) Generate(ctx context.Context, messages []model.Message, opts ...mod
 		Temperature:      options.Temperature,
 		TopP:             options.TopP,
 		MaxTokens:        options.MaxTokens,
+		N:                options.N,
 		FrequencyPenalty: options.FrequencyPenalty,
 		PresencePenalty:  options.PresencePenalty,
 		Stop:             options.Stop,
@@ -98,6 +146,18 @@ func (m llm) Generate(ctx context.Context, messages []model.Message, opts ...mod
 		LogProbs:         options.LogProbs,
 		TopLogProbs:      options.TopLogProbs,
 		Stream:           options.Stream,
+		ReasoningEffort:  string(options.ReasoningEffort),
+	}
+	for _, m := range options.Modalities {
+		req.Modalities = append(req.Modalities, string(m))
+	}
+	if options.Audio != (model.AudioOptions{}) {
+		req.Audio = &internal.AudioParams{Voice: options.Audio.Voice, Format: options.Audio.Format}
+	}
+	shapeRequest(&req)
+
+	if req.Stream {
+		req.StreamOptions = &internal.StreamOptions{IncludeUsage: true}
 	}
 
 	resp, err := m.client.Chat.Completion(ctx, req)
@@ -105,24 +165,72 @@ func (m llm) Generate(ctx context.Context, messages []model.Message, opts ...mod
 		return nil, err
 	}
 	if req.Stream {
-		stream := toMessageIter(resp, options.MessageHandler...)
-		return model.NewGenerationWithStream(stream), nil
+		gen := &model.Generation{}
+		gen.MsgIter = toMessageIter(ctx, m.logger, span, resp, gen, options.Audio.Format, options.MessageHandler...)
+		return gen, nil
+	}
+	span.SetAttributes(responseTrace(resp.Response).Attributes()...)
+	span.End()
+	return toGeneration(resp.Response, options.Audio.Format)
+}
+
+// shapeRequest adapts a request to reasoning models: the token limit is sent as
+// max_completion_tokens, and system instructions as developer messages, or as user
+// messages for the early models accepting neither.
+func shapeRequest(req *internal.ChatCompletionRequest) {
+	info, ok := Models.Lookup(req.Model)
+	if !ok || !info.Reasoning {
+		return
+	}
+	req.MaxCompletionTokens, req.MaxTokens = req.MaxTokens, 0
+	for i, msg := range req.Messages {
+		if msg.Role != internal.SystemRole {
+			continue
+		}
+		if info.DeveloperRole {
+			req.Messages[i].Role = internal.DevRole
+		} else {
+			req.Messages[i].Role = internal.UserRole
+		}
 	}
-	return toGeneration(resp.Response)
 }
 
 func (c *llm) String() string {
 	return "OpenAI"
 }
 
-func toMessageIter(r *internal.ChatResponse, handlers ...model.MessageHandler) model.MessageIter {
+// toMessageIter forwards the messages of a streamed response, recording the usage of the last chunk on gen.
+// Audio deltas are in audioFormat, the format requested.
+//
+// The span ends with the stream, when the caller stops iterating, when ctx is done,
+// or when gen is garbage collected without being read.
+func toMessageIter(ctx context.Context, logger *slog.Logger, span trace.Span, r *internal.ChatResponse, gen *model.Generation, audioFormat string, handlers ...model.MessageHandler) model.MessageIter {
+	s := &streamSpan{span: span}
+	stop := context.AfterFunc(ctx, func() { s.end(ctx.Err()) })
+	runtime.AddCleanup(gen, func(s *streamSpan) { s.end(nil) }, s)
 	return func(yield func(model.Message) bool) {
+		defer func() {
+			stop()
+			s.end(nil)
+		}()
 		if r.StreamIter == nil {
-			fmt.Println("ChatResponse stream is nil")
+			logger.ErrorContext(ctx, "openai: chat response stream is nil")
+			s.end(errors.New("nil chat response stream"))
 			return
 		}
 		for chunk := range r.StreamIter {
-			for _, msg := range fromChunk(chunk) {
+			s.chunk(chunk)
+			if chunk.Usage.TotalTokens > 0 {
+				gen.SetUsage(toUsage(chunk.Usage))
+			}
+			messages, err := fromChunk(chunk, audioFormat)
+			if err != nil {
+				logger.ErrorContext(ctx, "openai: invalid chunk", "error", err)
+				s.end(err)
+				gen.Err = err
+				return
+			}
+			for _, msg := range messages {
 
 				// Raise message
 				handleMessage(msg, handlers)
@@ -136,42 +244,184 @@ func toMessageIter(r *internal.ChatResponse, handlers ...model.MessageHandler) m
 	}
 }
 
+// streamSpan ends the span of a stream exactly once, with the summary of the chunks received.
+type streamSpan struct {
+	mu      sync.Mutex
+	span    trace.Span
+	summary trace.Response
+	ended   bool
+}
+
+// chunk records a chunk of the stream, unless the span already ended.
+func (s *streamSpan) chunk(chunk internal.ChatCompletionChunk) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.span.AddEvent("gen_ai.chunk", trace.Int("gen_ai.chunk.choices", len(chunk.Choices)))
+	traceChunk(&s.summary, chunk)
+}
+
+// end ends the span, recording err if any, the first time it is called.
+func (s *streamSpan) end(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.ended = true
+	if err != nil {
+		s.span.RecordError(err)
+	}
+	s.span.SetAttributes(s.summary.Attributes()...)
+	s.span.End()
+}
+
+// responseTrace summarizes a completion response for tracing.
+func responseTrace(resp *internal.ChatCompletionResponse) trace.Response {
+	r := trace.Response{
+		ID:           resp.ID,
+		Model:        resp.Model,
+		InputTokens:  resp.Usage.PromptTokens,
+		OutputTokens: resp.Usage.CompletionTokens,
+	}
+	for _, c := range resp.Choices {
+		r.FinishReasons = append(r.FinishReasons, string(c.FinishReason))
+	}
+	return r
+}
+
+// traceChunk accumulates the tracing summary of a stream from one of its chunks.
+func traceChunk(r *trace.Response, chunk internal.ChatCompletionChunk) {
+	if chunk.ID != "" {
+		r.ID = chunk.ID
+	}
+	if chunk.Model != "" {
+		r.Model = chunk.Model
+	}
+	if chunk.Usage.TotalTokens > 0 {
+		r.InputTokens = chunk.Usage.PromptTokens
+		r.OutputTokens = chunk.Usage.CompletionTokens
+	}
+	for _, c := range chunk.Choices {
+		if c.FinishReason != "" {
+			r.FinishReasons = append(r.FinishReasons, string(c.FinishReason))
+		}
+	}
+}
+
 func handleMessage(msg model.Message, handlers []model.MessageHandler) {
 	for _, h := range handlers {
 		h(msg)
 	}
 }
-func toGeneration(resp *internal.ChatCompletionResponse) (*model.Generation, error) {
+func toGeneration(resp *internal.ChatCompletionResponse, audioFormat string) (*model.Generation, error) {
 	messages := make([]model.Message, 0, len(resp.Choices))
 	for _, v := range resp.Choices {
+		audio, err := toAudio(v.Message.Audio, audioFormat)
+		if err != nil {
+			return nil, err
+		}
 		role := internal.ToModelRole(v.Message.Role)
-		msg := model.NewTextMessage(role, v.Message.Content)
+		msg := model.NewMessage(role,
+			withReasoning(v.Message.ReasoningContent),
+			model.WithTextContent(v.Message.Content),
+			withAudio(audio),
+			withRefusal(v.Message.Refusal, v.FinishReason == internal.CONTENT_FILTER))
+		msg.Index = v.Index
+		msg.FinishReason = model.FinishReason(v.FinishReason)
 		messages = append(messages, msg)
 	}
-	return model.NewGeneration(messages), nil
+	gen := model.NewGeneration(messages)
+	gen.SetUsage(toUsage(resp.Usage))
+	return gen, nil
+}
+
+func toUsage(u internal.CompletionUsage) model.Usage {
+	return model.Usage{
+		InputTokens:       u.PromptTokens,
+		CachedInputTokens: u.PromptTokensDetails.CachedTokens,
+		OutputTokens:      u.CompletionTokens,
+		ReasoningTokens:   u.CompletionTokensDetails.ReasoningTokens,
+	}
 }
 
 func toOpenAIMessages(message model.Message) ChatMessage {
-	return internal.ChatMessage{
+	msg := internal.ChatMessage{
 		Role:     internal.ToOpenAIRole(message.Role),
 		Contents: internal.ToChatContent(message.Contents),
 	}
+	// Only assistant messages reference the audio generated previously
+	if message.Role == model.Assistant {
+		msg.Audio = internal.ToChatAudio(message.Contents)
+	}
+	return msg
 }
 
-func fromChunk(c internal.ChatCompletionChunk) []model.Message {
+func fromChunk(c internal.ChatCompletionChunk, audioFormat string) ([]model.Message, error) {
 	// Get the first choice from the chunk
 	if len(c.Choices) == 0 {
-		return nil
+		return nil, nil
 	}
 	messages := make([]model.Message, 0, len(c.Choices))
 	for _, c := range c.Choices {
+		audio, err := toAudio(c.Delta.Audio, audioFormat)
+		if err != nil {
+			return nil, err
+		}
 		role := internal.ToModelRole(c.Delta.Role)
-		content := c.Delta.Content
-		msg := model.NewTextMessage(role, content)
+		// Reasoning, audio and refusal deltas are forwarded as their own parts, without an empty text
+		contents := []model.ContentFunc{withReasoning(c.Delta.ReasoningContent)}
+		if c.Delta.Content != "" || (c.Delta.ReasoningContent == "" && c.Delta.Refusal == "" && audio == nil) {
+			contents = append(contents, model.WithTextContent(c.Delta.Content))
+		}
+		contents = append(contents, withAudio(audio), withRefusal(c.Delta.Refusal, c.FinishReason == internal.CONTENT_FILTER))
+		msg := model.NewMessage(role, contents...)
 		msg.Index = c.Index
+		msg.FinishReason = model.FinishReason(c.FinishReason)
 		messages = append(messages, msg)
 	}
-	return messages
+	return messages, nil
+}
+
+// toAudio decodes the generated audio, nil when there is none
+func toAudio(audio *internal.ChatCompletionAudio, format string) (*model.AudioContent, error) {
+	if audio == nil {
+		return nil, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(audio.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decoding audio: %w", ErrModelGen, err)
+	}
+	return &model.AudioContent{ID: audio.ID, Format: format, Data: data, Transcript: audio.Transcript}, nil
+}
+
+// withAudio appends the audio content, if any
+func withAudio(audio *model.AudioContent) model.ContentFunc {
+	return func(m *model.Message) {
+		if audio != nil {
+			m.Contents = append(m.Contents, *audio)
+		}
+	}
+}
+
+// withReasoning appends the reasoning content, if any
+func withReasoning(text string) model.ContentFunc {
+	return func(m *model.Message) {
+		if text != "" {
+			m.Contents = append(m.Contents, model.ReasoningContent{Text: text})
+		}
+	}
+}
+
+// withRefusal appends the refusal content, if the model refused or a content filter stopped it
+func withRefusal(text string, filtered bool) model.ContentFunc {
+	return func(m *model.Message) {
+		if text != "" || filtered {
+			m.Contents = append(m.Contents, model.RefusalContent{Text: text, Filtered: filtered})
+		}
+	}
 }
 
 func validateChatMessage(msg ChatMessage) error {
@@ -185,12 +435,21 @@ func validateChatMessage(msg ChatMessage) error {
 		return errors.New("message contents cannot be nil")
 	}
 
-	// Additional validation could be added here based on content type
+	// Inlined images must carry an image mime type
+	for _, c := range msg.Contents {
+		if c.Type == internal.ImageURLContent && strings.HasPrefix(c.Image.Url, "data:") && !strings.HasPrefix(c.Image.Url, "data:image/") {
+			return ErrInvalidMimeType
+		}
+		if c.Type == internal.AudioInputContent && c.Audio.Format != "wav" && c.Audio.Format != "mp3" {
+			return fmt.Errorf("%w: input audio format %q, want wav or mp3", ErrUnsupported, c.Audio.Format)
+		}
+	}
 	return nil
 }
 
-// validateOptions validates the model options to ensure they're within acceptable ranges
-func validateOptions(options model.ModelOptions) error {
+// validateOptions validates the model options to ensure they're within acceptable ranges,
+// and supported by the model with the given messages when it is described in [Models]
+func validateOptions(options model.ModelOptions, messages []model.Message) error {
 	// Required fields
 	if options.Model == "" {
 		return errors.New("no model provided")
@@ -211,6 +470,11 @@ func validateOptions(options model.ModelOptions) error {
 		return errors.New("max_tokens must be positive")
 	}
 
+	// N validation (must be positive if set)
+	if options.N < 0 {
+		return errors.New("n must be positive")
+	}
+
 	// FrequencyPenalty validation (-2.0 to 2.0) - only validate if non-zero
 	if options.FrequencyPenalty != 0 && (options.FrequencyPenalty < -2.0 || options.FrequencyPenalty > 2.0) {
 		return errors.New("frequency_penalty must be between -2.0 and 2.0")
@@ -233,10 +497,96 @@ func validateOptions(options model.ModelOptions) error {
 		}
 	}
 
+	// ReasoningEffort validation - only validate if set
+	switch options.ReasoningEffort {
+	case "", model.ReasoningLow, model.ReasoningMedium, model.ReasoningHigh:
+	default:
+		return errors.New("reasoning_effort must be 'low', 'medium' or 'high'")
+	}
+
+	// Modalities validation - audio output needs a voice and a format
+	for _, m := range options.Modalities {
+		if m != model.ModalityText && m != model.ModalityAudio {
+			return errors.New("modalities must be 'text' or 'audio'")
+		}
+	}
+	if slices.Contains(options.Modalities, model.ModalityAudio) && (options.Audio.Voice == "" || options.Audio.Format == "") {
+		return errors.New("audio output requires a voice and a format")
+	}
+	switch options.Audio.Format {
+	case "", "wav", "mp3", "flac", "opus", "pcm16":
+	default:
+		return errors.New("audio format must be 'wav', 'mp3', 'flac', 'opus' or 'pcm16'")
+	}
+	if options.Stream && slices.Contains(options.Modalities, model.ModalityAudio) && options.Audio.Format != "pcm16" {
+		return errors.New("streamed audio format must be 'pcm16'")
+	}
+
 	// Stop sequences validation (max 4 sequences)
 	if len(options.Stop) > 4 {
 		return errors.New("maximum of 4 stop sequences allowed")
 	}
 
+	return validateCapabilities(options, messages)
+}
+
+// validateCapabilities rejects options and contents the model does not support.
+// Unknown models are not checked.
+func validateCapabilities(options model.ModelOptions, messages []model.Message) error {
+	info, ok := Models.Lookup(options.Model)
+	if !ok {
+		return nil
+	}
+
+	// Sampling parameters
+	if !info.Temperature && (options.Temperature != 0 || options.TopP != 0 ||
+		options.FrequencyPenalty != 0 || options.PresencePenalty != 0 || options.LogProbs) {
+		return fmt.Errorf("%w: %s does not accept sampling parameters such as temperature", ErrUnsupported, options.Model)
+	}
+
+	if !info.Reasoning && options.ReasoningEffort != "" {
+		return fmt.Errorf("%w: %s does not accept a reasoning effort", ErrUnsupported, options.Model)
+	}
+
+	// Token limits
+	if options.MaxTokens > info.MaxOutputTokens {
+		return fmt.Errorf("%w: max_tokens %d exceeds the %d output tokens of %s", ErrUnsupported, options.MaxTokens, info.MaxOutputTokens, options.Model)
+	}
+	if prompt := promptTokens(messages); info.ContextWindow > 0 && prompt+options.MaxTokens > info.ContextWindow {
+		return fmt.Errorf("%w: about %d prompt tokens and max_tokens %d exceed the %d tokens context window of %s",
+			ErrUnsupported, prompt, options.MaxTokens, info.ContextWindow, options.Model)
+	}
+
+	// Contents
+	if !info.Vision && hasContent(messages, model.ImageKind) {
+		return fmt.Errorf("%w: %s does not accept images", ErrUnsupported, options.Model)
+	}
+	if !info.Audio && hasContent(messages, model.AudioKind) {
+		return fmt.Errorf("%w: %s does not accept audio", ErrUnsupported, options.Model)
+	}
+	if !info.Audio && slices.Contains(options.Modalities, model.ModalityAudio) {
+		return fmt.Errorf("%w: %s does not generate audio", ErrUnsupported, options.Model)
+	}
 	return nil
 }
+
+// promptTokens estimates the text tokens of messages.
+func promptTokens(messages []model.Message) int {
+	n := 0
+	for _, msg := range messages {
+		n += model.CountTokens(model.ApproxTokenizer{}, msg.Text())
+	}
+	return n
+}
+
+// hasContent reports whether any message holds a content of the given kind.
+func hasContent(messages []model.Message, kind string) bool {
+	for _, msg := range messages {
+		for _, c := range msg.Contents {
+			if c.Kind() == kind {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -4,14 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"nyxze/fayth/model"
 	"nyxze/fayth/model/openai/internal"
+	"nyxze/fayth/trace"
 )
 
 // mockRoundTripper implements http.RoundTripper for testing
@@ -376,6 +382,96 @@ func TestOpenAI_ValidationErrors(t *testing.T) {
 			},
 			expectedError: "no model provided",
 		},
+		{
+			name: "temperature on reasoning model",
+			input: []model.Message{
+				model.NewTextMessage(model.User, "Hello"),
+			},
+			options: []model.ModelOption{
+				model.WithModel(ChatModelO1), model.WithTemperature(0.7),
+			},
+			expectedError: "o1 does not accept sampling parameters",
+		},
+		{
+			name: "images on text model",
+			input: []model.Message{
+				model.NewMessage(model.User, model.WithTextContent("What is this?"), model.WithImageURL("https://example.com/a.jpg")),
+			},
+			options: []model.ModelOption{
+				model.WithModel(ChatModelGPT3_5Turbo0125),
+			},
+			expectedError: "gpt-3.5-turbo-0125 does not accept images",
+		},
+		{
+			name: "max tokens above model output",
+			input: []model.Message{
+				model.NewTextMessage(model.User, "Hello"),
+			},
+			options: []model.ModelOption{
+				model.WithModel(ChatModelGPT4o), model.WithMaxTokens(20_000),
+			},
+			expectedError: "max_tokens 20000 exceeds the 16384 output tokens of gpt-4o",
+		},
+		{
+			name: "prompt and max tokens above context window",
+			input: []model.Message{
+				model.NewTextMessage(model.User, strings.Repeat("Hello ", 2_000)),
+			},
+			options: []model.ModelOption{
+				model.WithModel(ChatModelGPT4), model.WithMaxTokens(8_000),
+			},
+			expectedError: "exceed the 8192 tokens context window of gpt-4",
+		},
+		{
+			name: "audio on text model",
+			input: []model.Message{
+				model.NewMessage(model.User, model.WithAudioContent("wav", []byte("RIFF"))),
+			},
+			options: []model.ModelOption{
+				model.WithModel(ChatModelGPT4o),
+			},
+			expectedError: "gpt-4o does not accept audio",
+		},
+		{
+			name: "audio output on text model",
+			input: []model.Message{
+				model.NewTextMessage(model.User, "Hello"),
+			},
+			options: []model.ModelOption{
+				model.WithModel(ChatModelGPT4o), model.WithAudioOutput("alloy", "wav"),
+			},
+			expectedError: "gpt-4o does not generate audio",
+		},
+		{
+			name: "audio output without voice",
+			input: []model.Message{
+				model.NewTextMessage(model.User, "Hello"),
+			},
+			options: []model.ModelOption{
+				model.WithModel(ChatModelGPT4oAudioPreview), model.WithModalities(model.ModalityText, model.ModalityAudio),
+			},
+			expectedError: "audio output requires a voice and a format",
+		},
+		{
+			name: "streamed audio not in pcm16",
+			input: []model.Message{
+				model.NewTextMessage(model.User, "Hello"),
+			},
+			options: []model.ModelOption{
+				model.WithModel(ChatModelGPT4oAudioPreview), model.WithAudioOutput("alloy", "wav"), model.WithStream(true),
+			},
+			expectedError: "streamed audio format must be 'pcm16'",
+		},
+		{
+			name: "unsupported input audio format",
+			input: []model.Message{
+				model.NewMessage(model.User, model.WithAudioContent("ogg", []byte("OggS"))),
+			},
+			options: []model.ModelOption{
+				model.WithModel(ChatModelGPT4oAudioPreview),
+			},
+			expectedError: `input audio format "ogg", want wav or mp3`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -397,3 +493,739 @@ func TestOpenAI_ValidationErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestOpenAI_Logging(t *testing.T) {
+	const apiKey = "sk-secret-key"
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	mock := &mockRoundTripper{
+		response: mockResponse(http.StatusOK, `{
+			"id": "test-id",
+			"object": "chat.completion",
+			"model": "gpt-4",
+			"choices": [{"index": 0, "message": {"role": "assistant", "content": "Hi"}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 3, "completion_tokens": 1, "total_tokens": 4}
+		}`),
+	}
+	llm, err := New(
+		WithAPIKey(apiKey),
+		WithHTTPClient(&http.Client{Transport: mock}),
+		WithLogger(logger),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+
+	if _, err := llm.Generate(context.Background(), []model.Message{model.NewTextMessage(model.User, "Hello")}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, apiKey) {
+		t.Errorf("API key leaked into logs: %s", out)
+	}
+	for _, want := range []string{
+		"openai: chat completion started",
+		"openai: chat completion finished",
+		`"total_tokens":4`,
+		`"status":200`,
+		"[REDACTED]",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected logs to contain %q, got %s", want, out)
+		}
+	}
+}
+
+// recordingTracer keeps every started span for inspection
+type recordingTracer struct {
+	spans []*recordingSpan
+}
+
+type recordingSpan struct {
+	name  string
+	sc    trace.SpanContext
+	attrs map[string]any
+	// ended and errs are set from the goroutine ending the span, read them with isEnded and errors
+	mu    sync.Mutex
+	ended bool
+	errs  []error
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, attrs ...trace.Attribute) (context.Context, trace.Span) {
+	span := &recordingSpan{name: name, attrs: map[string]any{}}
+	span.sc.TraceID[0], span.sc.SpanID[0] = 1, byte(len(r.spans)+1)
+	span.SetAttributes(attrs...)
+	r.spans = append(r.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...trace.Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+func (s *recordingSpan) AddEvent(string, ...trace.Attribute) {}
+func (s *recordingSpan) SpanContext() trace.SpanContext      { return s.sc }
+func (s *recordingSpan) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs = append(s.errs, err)
+}
+func (s *recordingSpan) End() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ended = true
+}
+
+func (s *recordingSpan) isEnded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ended
+}
+
+func (s *recordingSpan) errors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.errs)
+}
+
+func TestOpenAI_Tracing(t *testing.T) {
+	tracer := &recordingTracer{}
+	mock := &mockRoundTripper{
+		response: mockStreamResponse([]string{
+			`{"id":"1","model":"gpt-4","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"},"finish_reason":null}]}`,
+			`{"id":"1","model":"gpt-4","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`,
+		}),
+	}
+	llm, err := New(
+		WithAPIKey("fake"),
+		WithHTTPClient(&http.Client{Transport: mock}),
+		WithTracer(tracer),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+
+	gen, err := llm.Generate(context.Background(), []model.Message{model.NewTextMessage(model.User, "Hello")}, model.WithStream(true))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for range gen.Messages() {
+	}
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("Expected generation and HTTP spans, got %d", len(tracer.spans))
+	}
+	genSpan, httpSpan := tracer.spans[0], tracer.spans[1]
+	if !genSpan.ended || !httpSpan.ended {
+		t.Error("Expected all spans to be ended")
+	}
+	if genSpan.attrs[trace.GenAIRequestModel] != ChatModelGPT4 {
+		t.Errorf("Expected request model attribute, got %v", genSpan.attrs[trace.GenAIRequestModel])
+	}
+	if genSpan.attrs[trace.GenAIUsageOutputTokens] != 2 {
+		t.Errorf("Expected output tokens attribute, got %v", genSpan.attrs[trace.GenAIUsageOutputTokens])
+	}
+	if reasons, _ := genSpan.attrs[trace.GenAIResponseFinish].([]string); len(reasons) != 1 || reasons[0] != "stop" {
+		t.Errorf("Expected finish reasons [stop], got %v", genSpan.attrs[trace.GenAIResponseFinish])
+	}
+
+	got := mock.requests[0].Header.Get("traceparent")
+	if got != httpSpan.sc.TraceParent() {
+		t.Errorf("Expected traceparent %q, got %q", httpSpan.sc.TraceParent(), got)
+	}
+}
+
+func TestOpenAI_TracingAbandonedStream(t *testing.T) {
+	chunks := []string{
+		`{"id":"1","model":"gpt-4","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"},"finish_reason":null}]}`,
+		`{"id":"1","model":"gpt-4","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"!"},"finish_reason":"stop"}]}`,
+	}
+	tracer := &recordingTracer{}
+	mock := &mockRoundTripper{
+		responseFunc: func(*http.Request) (*http.Response, error) { return mockStreamResponse(chunks), nil },
+	}
+	llm, err := New(WithAPIKey("fake"), WithHTTPClient(&http.Client{Transport: mock}), WithTracer(tracer))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	messages := []model.Message{model.NewTextMessage(model.User, "Hello")}
+
+	// Early break
+	gen, err := llm.Generate(context.Background(), messages, model.WithStream(true))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for range gen.Messages() {
+		break
+	}
+	if span := tracer.spans[0]; !span.isEnded() || span.attrs[trace.GenAIResponseID] != "1" {
+		t.Errorf("Expected the span ended with the chunks received, got ended %v and %v", span.isEnded(), span.attrs)
+	}
+
+	// Never read, with the context canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := llm.Generate(ctx, messages, model.WithStream(true)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	span := tracer.spans[2]
+	if span.isEnded() {
+		t.Fatal("Expected the span open until the context is canceled")
+	}
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for !span.isEnded() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !span.isEnded() {
+		t.Error("Expected the span ended once the context is canceled")
+	}
+
+	// Invalid chunk
+	chunks = []string{`{"id":"2","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"audio":{"id":"a","data":"!"}},"finish_reason":null}]}`}
+	gen, err = llm.Generate(context.Background(), messages, model.WithStream(true))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for range gen.Messages() {
+	}
+	// The chat span precedes the span of its HTTP request
+	span = tracer.spans[len(tracer.spans)-2]
+	if errs := span.errors(); gen.Error() == nil || !span.isEnded() || len(errs) != 1 || errs[0] != gen.Error() {
+		t.Errorf("Expected the span ended with the chunk error %v, got ended %v with %v", gen.Error(), span.isEnded(), errs)
+	}
+}
+
+func TestOpenAI_AutoContinue(t *testing.T) {
+	completion := func(content, finish string) *http.Response {
+		return mockResponse(http.StatusOK, fmt.Sprintf(`{
+			"id": "test-id",
+			"object": "chat.completion",
+			"model": "gpt-4",
+			"choices": [{"index": 0, "message": {"role": "assistant", "content": %q}, "finish_reason": %q}]
+		}`, content, finish))
+	}
+	chunks := func(content, finish string) *http.Response {
+		return mockStreamResponse([]string{
+			fmt.Sprintf(`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","content":%q},"finish_reason":null}]}`, content),
+			fmt.Sprintf(`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{},"finish_reason":%q}]}`, finish),
+		})
+	}
+
+	tests := []struct {
+		name      string
+		stream    bool
+		responses []*http.Response
+	}{
+		{"NonStreaming", false, []*http.Response{completion("Once upon", "length"), completion(" a time", "stop")}},
+		{"Streaming", true, []*http.Response{chunks("Once upon", "length"), chunks(" a time", "stop")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []internal.ChatCompletionRequest
+			mock := &mockRoundTripper{}
+			mock.responseFunc = func(req *http.Request) (*http.Response, error) {
+				var body internal.ChatCompletionRequest
+				if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+					t.Errorf("invalid request body: %v", err)
+				}
+				bodies = append(bodies, body)
+				return tt.responses[len(bodies)-1], nil
+			}
+			llm, err := New(WithAPIKey("test-key"), WithHTTPClient(&http.Client{Transport: mock}))
+			if err != nil {
+				t.Fatalf("Failed to create model: %v", err)
+			}
+
+			gen, err := llm.Generate(context.Background(),
+				[]model.Message{model.NewTextMessage(model.User, "Tell a story")},
+				model.WithMaxTokens(2), model.WithStream(tt.stream), model.WithAutoContinue(3))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var messages []model.Message
+			for msg := range gen.Messages() {
+				messages = append(messages, msg)
+			}
+			if gen.Error() != nil {
+				t.Fatalf("Unexpected generation error: %v", gen.Error())
+			}
+			merged := model.MergeChunks(messages)
+			if len(merged) != 1 || merged[0].Text() != "Once upon a time" || merged[0].FinishReason != model.FinishStop {
+				t.Errorf("Expected one stitched message, got %+v", merged)
+			}
+
+			if len(bodies) != 2 {
+				t.Fatalf("Expected 2 requests, got %d", len(bodies))
+			}
+			if n := len(bodies[1].Messages); n != 3 || bodies[1].Messages[1].Role != internal.AssistantRole {
+				t.Errorf("Expected the continuation request to carry the partial answer, got %+v", bodies[1].Messages)
+			}
+		})
+	}
+}
+
+func TestOpenAI_Choices(t *testing.T) {
+	var body internal.ChatCompletionRequest
+	mock := &mockRoundTripper{}
+	mock.responseFunc = func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		return mockStreamResponse([]string{
+			`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","content":"Heads"},"finish_reason":null}]}`,
+			`{"id":"1","object":"chat.completion.chunk","choices":[{"index":1,"delta":{"role":"assistant","content":"Tails"},"finish_reason":null}]}`,
+			`{"id":"1","object":"chat.completion.chunk","choices":[{"index":1,"delta":{"content":"!"},"finish_reason":"stop"}]}`,
+			`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"."},"finish_reason":"stop"}]}`,
+		}), nil
+	}
+	llm, err := New(WithAPIKey("test-key"), WithHTTPClient(&http.Client{Transport: mock}))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+
+	gen, err := llm.Generate(context.Background(),
+		[]model.Message{model.NewTextMessage(model.User, "Flip a coin")},
+		model.WithN(2), model.WithStream(true))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	choices := gen.Choices()
+	if gen.Error() != nil {
+		t.Fatalf("Unexpected generation error: %v", gen.Error())
+	}
+	if body.N != 2 {
+		t.Errorf("Expected n=2 in the request, got %d", body.N)
+	}
+	if len(choices) != 2 || choices[0].Text() != "Heads." || choices[1].Text() != "Tails!" {
+		t.Errorf("Expected two demultiplexed choices, got %+v", choices)
+	}
+	for _, c := range choices {
+		if c.FinishReason != model.FinishStop {
+			t.Errorf("Expected choice %d to be finished, got %q", c.Index, c.FinishReason)
+		}
+	}
+
+	if _, err := llm.Generate(context.Background(),
+		[]model.Message{model.NewTextMessage(model.User, "Flip a coin")}, model.WithN(-1)); err == nil {
+		t.Error("Expected an error for a negative n")
+	}
+}
+
+func TestOpenAI_Images(t *testing.T) {
+	var body internal.ChatCompletionRequest
+	mock := &mockRoundTripper{}
+	mock.responseFunc = func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		return mockResponse(http.StatusOK, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Two cats."},"finish_reason":"stop"}]}`), nil
+	}
+	llm, err := New(WithAPIKey("test-key"), WithHTTPClient(&http.Client{Transport: mock}))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+
+	msg := model.NewMessage(model.User,
+		model.WithTextContent("What is in these pictures?"),
+		model.WithImageContent("image/png", []byte("png")),
+		model.WithImageURL("https://example.com/cats.jpg"))
+	gen, err := llm.Generate(context.Background(), []model.Message{msg}, model.WithModel(ChatModelGPT4o))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if choices := gen.Choices(); len(choices) != 1 || choices[0].Text() != "Two cats." {
+		t.Errorf("Unexpected choices: %+v", choices)
+	}
+
+	contents := body.Messages[0].Contents
+	if len(contents) != 3 || contents[1].Type != internal.ImageURLContent || contents[2].Type != internal.ImageURLContent {
+		t.Fatalf("Expected a text and two image parts, got %+v", contents)
+	}
+	if contents[1].Image.Url != "data:image/png;base64,cG5n" {
+		t.Errorf("Expected an inlined data url, got %q", contents[1].Image.Url)
+	}
+	if contents[2].Image.Url != "https://example.com/cats.jpg" {
+		t.Errorf("Expected the image url, got %q", contents[2].Image.Url)
+	}
+
+	invalid := model.NewMessage(model.User, model.WithImageContent("text/plain", []byte("hi")))
+	if _, err := llm.Generate(context.Background(), []model.Message{invalid}, model.WithModel(ChatModelGPT4o)); !errors.Is(err, ErrInvalidMimeType) {
+		t.Errorf("Expected ErrInvalidMimeType, got %v", err)
+	}
+}
+
+func TestOpenAI_Usage(t *testing.T) {
+	var body map[string]any
+	mock := &mockRoundTripper{}
+	mock.responseFunc = func(req *http.Request) (*http.Response, error) {
+		body = nil
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		if body["stream"] == true {
+			return mockStreamResponse([]string{
+				`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`,
+				`{"id":"1","object":"chat.completion.chunk","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`,
+			}), nil
+		}
+		return mockResponse(http.StatusOK, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],
+			"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15,"prompt_tokens_details":{"cached_tokens":8}}}`), nil
+	}
+	llm, err := New(WithAPIKey("test-key"), WithHTTPClient(&http.Client{Transport: mock}))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	messages := []model.Message{model.NewTextMessage(model.User, "Hello")}
+
+	gen, err := llm.Generate(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := (model.Usage{InputTokens: 12, CachedInputTokens: 8, OutputTokens: 3}); gen.Usage() != want {
+		t.Errorf("Expected usage %+v, got %+v", want, gen.Usage())
+	}
+	if _, ok := body["stream_options"]; ok {
+		t.Errorf("Expected no stream options without streaming, got %v", body["stream_options"])
+	}
+
+	gen, err = llm.Generate(context.Background(), messages, model.WithStream(true))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if gen.Choices()[0].Text() != "Hi" {
+		t.Errorf("Unexpected choices: %+v", gen.Choices())
+	}
+	if want := (model.Usage{InputTokens: 12, OutputTokens: 3}); gen.Usage() != want {
+		t.Errorf("Expected streamed usage %+v, got %+v", want, gen.Usage())
+	}
+	if opts, _ := body["stream_options"].(map[string]any); opts["include_usage"] != true {
+		t.Errorf("Expected the stream to include usage, got %v", body["stream_options"])
+	}
+}
+
+func TestOpenAI_ReasoningModels(t *testing.T) {
+	var body map[string]any
+	mock := &mockRoundTripper{}
+	mock.responseFunc = func(req *http.Request) (*http.Response, error) {
+		body = nil
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		return mockResponse(http.StatusOK, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"42"},"finish_reason":"stop"}],
+			"usage":{"prompt_tokens":20,"completion_tokens":300,"total_tokens":320,"completion_tokens_details":{"reasoning_tokens":298}}}`), nil
+	}
+	llm, err := New(WithAPIKey("test-key"), WithHTTPClient(&http.Client{Transport: mock}))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	messages := []model.Message{
+		model.NewTextMessage(model.System, "Answer with a number"),
+		model.NewTextMessage(model.User, "What is 6 x 7?"),
+	}
+
+	tests := []struct {
+		name       string
+		options    []model.ModelOption
+		role       string
+		maxTokens  string
+		effort     any
+		reasoning  int
+		wantAbsent string
+	}{
+		{"Developer role", []model.ModelOption{model.WithModel(ChatModelO3Mini), model.WithReasoningEffort(model.ReasoningHigh)}, "developer", "max_completion_tokens", "high", 298, "max_tokens"},
+		{"Snapshot", []model.ModelOption{model.WithModel(ChatModelO4Mini2025_04_16)}, "developer", "max_completion_tokens", nil, 298, "max_tokens"},
+		{"No system role", []model.ModelOption{model.WithModel(ChatModelO1Mini)}, "user", "max_completion_tokens", nil, 298, "max_tokens"},
+		{"Chat model", []model.ModelOption{model.WithModel(ChatModelGPT4o)}, "system", "max_tokens", nil, 298, "max_completion_tokens"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := llm.Generate(context.Background(), messages, append(tt.options, model.WithMaxTokens(500))...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			first := body["messages"].([]any)[0].(map[string]any)
+			if first["role"] != tt.role {
+				t.Errorf("Expected system instructions as %q, got %v", tt.role, first["role"])
+			}
+			if body[tt.maxTokens] != 500.0 {
+				t.Errorf("Expected %s of 500, got %v", tt.maxTokens, body[tt.maxTokens])
+			}
+			if _, ok := body[tt.wantAbsent]; ok {
+				t.Errorf("Expected no %s, got %v", tt.wantAbsent, body[tt.wantAbsent])
+			}
+			if body["reasoning_effort"] != tt.effort {
+				t.Errorf("Expected reasoning_effort %v, got %v", tt.effort, body["reasoning_effort"])
+			}
+			if gen.Usage().ReasoningTokens != tt.reasoning || gen.Usage().OutputTokens != 300 {
+				t.Errorf("Expected reasoning tokens in usage, got %+v", gen.Usage())
+			}
+		})
+	}
+
+	for _, opts := range [][]model.ModelOption{
+		{model.WithModel(ChatModelO3), model.WithReasoningEffort("extreme")},
+		{model.WithModel(ChatModelGPT4o), model.WithReasoningEffort(model.ReasoningLow)},
+		{model.WithModel(ChatModelO4Mini), model.WithTemperature(0.5)},
+	} {
+		if _, err := llm.Generate(context.Background(), messages, opts...); err == nil {
+			t.Errorf("Expected an error for options %+v", model.MergeOptions(model.ModelOptions{}, opts...))
+		}
+	}
+}
+
+func TestOpenAI_ReasoningContent(t *testing.T) {
+	var raw []byte
+	mock := &mockRoundTripper{}
+	mock.responseFunc = func(req *http.Request) (*http.Response, error) {
+		raw, _ = io.ReadAll(req.Body)
+		var body internal.ChatCompletionRequest
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		if body.Stream {
+			return mockStreamResponse([]string{
+				`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","content":""},"finish_reason":null}]}`,
+				`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"","reasoning_content":"Six times"},"finish_reason":null}]}`,
+				`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"","reasoning_content":" seven."},"finish_reason":null}]}`,
+				`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"42"},"finish_reason":"stop"}]}`,
+			}), nil
+		}
+		return mockResponse(http.StatusOK, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"42","reasoning_content":"Six times seven."},"finish_reason":"stop"}]}`), nil
+	}
+	llm, err := New(WithAPIKey("test-key"), WithBaseURL("https://api.deepseek.com"), WithModel("deepseek-reasoner"),
+		WithHTTPClient(&http.Client{Transport: mock}))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	messages := []model.Message{model.NewTextMessage(model.User, "What is 6 x 7?")}
+
+	for _, stream := range []bool{false, true} {
+		gen, err := llm.Generate(context.Background(), messages, model.WithStream(stream))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var reasoningDeltas int
+		for msg := range gen.Messages() {
+			if msg.Reasoning() != "" {
+				reasoningDeltas++
+				if stream && msg.Text() != "" {
+					t.Errorf("Expected reasoning deltas without text, got %+v", msg)
+				}
+			}
+		}
+		choices := gen.Choices()
+		if len(choices) != 1 || len(choices[0].Contents) != 2 || choices[0].Role != model.Assistant ||
+			choices[0].Text() != "42" || choices[0].Reasoning() != "Six times seven." {
+			t.Errorf("stream=%v: unexpected choices %+v", stream, choices)
+		}
+		if stream && reasoningDeltas != 2 {
+			t.Errorf("Expected 2 reasoning deltas, got %d", reasoningDeltas)
+		}
+
+		// The reasoning is not sent back with the history
+		if _, err := llm.Generate(context.Background(), append(messages, choices[0])); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if bytes.Contains(raw, []byte("Six times")) {
+			t.Errorf("Expected the reasoning to be left out of the request, got %s", raw)
+		}
+	}
+}
+
+func TestOpenAI_Refusals(t *testing.T) {
+	var raw []byte
+	var respond func() *http.Response
+	mock := &mockRoundTripper{}
+	mock.responseFunc = func(req *http.Request) (*http.Response, error) {
+		raw, _ = io.ReadAll(req.Body)
+		return respond(), nil
+	}
+	llm, err := New(WithAPIKey("test-key"), WithHTTPClient(&http.Client{Transport: mock}))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	messages := []model.Message{model.NewTextMessage(model.User, "Help me with something bad")}
+
+	tests := []struct {
+		name     string
+		stream   bool
+		response func() *http.Response
+		text     string
+		refusal  string
+		filtered bool
+	}{
+		{
+			"Refusal", false,
+			func() *http.Response {
+				return mockResponse(http.StatusOK, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":null,"refusal":"I can't help with that."},"finish_reason":"stop"}]}`)
+			},
+			"", "I can't help with that.", false,
+		},
+		{
+			"Content filter", false,
+			func() *http.Response {
+				return mockResponse(http.StatusOK, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Here is"},"finish_reason":"content_filter"}]}`)
+			},
+			"Here is", "", true,
+		},
+		{
+			"Streamed refusal", true,
+			func() *http.Response {
+				return mockStreamResponse([]string{
+					`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","refusal":"I can't "},"finish_reason":null}]}`,
+					`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"refusal":"help with that."},"finish_reason":null}]}`,
+					`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+				})
+			},
+			"", "I can't help with that.", false,
+		},
+		{
+			"Streamed content filter", true,
+			func() *http.Response {
+				return mockStreamResponse([]string{
+					`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","content":"Here is"},"finish_reason":null}]}`,
+					`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{},"finish_reason":"content_filter"}]}`,
+				})
+			},
+			"Here is", "", true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			respond = tt.response
+			gen, err := llm.Generate(context.Background(), messages, model.WithStream(tt.stream))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			choices := gen.Choices()
+			if gen.Error() != nil || len(choices) != 1 {
+				t.Fatalf("Unexpected choices %+v, error %v", choices, gen.Error())
+			}
+			msg := choices[0]
+			err = msg.Refusal()
+			var refusal *model.RefusalError
+			if !errors.Is(err, model.ErrRefusal) || !errors.As(err, &refusal) || refusal.Text != tt.refusal {
+				t.Errorf("Expected a refusal %q, got %v", tt.refusal, err)
+			}
+			if errors.Is(err, model.ErrContentFilter) != tt.filtered {
+				t.Errorf("Expected content filtered %v, got %v", tt.filtered, err)
+			}
+			if msg.Text() != tt.text {
+				t.Errorf("Expected text %q, got %q", tt.text, msg.Text())
+			}
+
+			// Explained refusals are sent back with the history
+			respond = func() *http.Response {
+				return mockResponse(http.StatusOK, `{"id":"2","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Ok"},"finish_reason":"stop"}]}`)
+			}
+			if _, err := llm.Generate(context.Background(), append(messages, msg, model.NewTextMessage(model.User, "Why?"))); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if sent := bytes.Contains(raw, []byte(`{"type":"refusal","refusal":"I can't help with that."}`)); sent != (tt.refusal != "") {
+				t.Errorf("Expected the refusal sent back %v, got %s", tt.refusal != "", raw)
+			}
+		})
+	}
+}
+
+func TestOpenAI_Audio(t *testing.T) {
+	var raw []byte
+	var respond func() *http.Response
+	mock := &mockRoundTripper{}
+	mock.responseFunc = func(req *http.Request) (*http.Response, error) {
+		raw, _ = io.ReadAll(req.Body)
+		return respond(), nil
+	}
+	llm, err := New(WithAPIKey("test-key"), WithHTTPClient(&http.Client{Transport: mock}), WithModel(ChatModelGPT4oAudioPreview))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	messages := []model.Message{model.NewMessage(model.User,
+		model.WithTextContent("Answer this"),
+		model.WithAudioContent("wav", []byte("RIFF")))}
+
+	tests := []struct {
+		name     string
+		format   string
+		stream   bool
+		response func() *http.Response
+	}{
+		{
+			"Spoken answer", "wav", false,
+			func() *http.Response {
+				return mockResponse(http.StatusOK, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":null,"audio":{"id":"audio_1","data":"AAECAwQF","expires_at":1729018505,"transcript":"Hello"}},"finish_reason":"stop"}]}`)
+			},
+		},
+		{
+			"Streamed spoken answer", "pcm16", true,
+			func() *http.Response {
+				return mockStreamResponse([]string{
+					`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","audio":{"id":"audio_1","transcript":"Hel"}},"finish_reason":null}]}`,
+					`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"audio":{"data":"AAEC","transcript":"lo"}},"finish_reason":null}]}`,
+					`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"audio":{"data":"AwQF","expires_at":1729018505}},"finish_reason":null}]}`,
+					`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+				})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			respond = tt.response
+			gen, err := llm.Generate(context.Background(), messages, model.WithAudioOutput("alloy", tt.format), model.WithStream(tt.stream))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			choices := gen.Choices()
+			if gen.Error() != nil || len(choices) != 1 {
+				t.Fatalf("Unexpected choices %+v, error %v", choices, gen.Error())
+			}
+
+			var req map[string]any
+			if err := json.Unmarshal(raw, &req); err != nil {
+				t.Fatalf("Invalid request body %s: %v", raw, err)
+			}
+			if fmt.Sprint(req["modalities"]) != "[text audio]" || fmt.Sprint(req["audio"]) != fmt.Sprintf("map[format:%s voice:alloy]", tt.format) {
+				t.Errorf("Expected the audio output requested, got %s", raw)
+			}
+			if !bytes.Contains(raw, []byte(`{"type":"input_audio","input_audio":{"data":"UklGRg==","format":"wav"}}`)) {
+				t.Errorf("Expected the input audio sent, got %s", raw)
+			}
+
+			msg := choices[0]
+			var audio model.AudioContent
+			for _, c := range msg.Contents {
+				if a, ok := c.(model.AudioContent); ok {
+					audio = a
+				}
+			}
+			want := model.AudioContent{ID: "audio_1", Format: tt.format, Data: []byte{0, 1, 2, 3, 4, 5}, Transcript: "Hello"}
+			if audio.ID != want.ID || audio.Format != want.Format || !bytes.Equal(audio.Data, want.Data) || audio.Transcript != want.Transcript {
+				t.Errorf("Expected audio %+v, got %+v", want, audio)
+			}
+			if msg.Transcript() != "Hello" || msg.Text() != "" {
+				t.Errorf("Unexpected transcript %q and text %q", msg.Transcript(), msg.Text())
+			}
+
+			// Generated audio is referenced by its identifier in the history
+			respond = func() *http.Response {
+				return mockResponse(http.StatusOK, `{"id":"2","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Ok"},"finish_reason":"stop"}]}`)
+			}
+			if _, err := llm.Generate(context.Background(), append(messages, msg, model.NewTextMessage(model.User, "Again"))); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !bytes.Contains(raw, []byte(`"audio":{"id":"audio_1"}`)) || bytes.Contains(raw, []byte("AAECAwQF")) {
+				t.Errorf("Expected the generated audio referenced, got %s", raw)
+			}
+		})
+	}
+
+	respond = func() *http.Response {
+		return mockResponse(http.StatusOK, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","audio":{"id":"audio_1","data":"not base64!"}},"finish_reason":"stop"}]}`)
+	}
+	if _, err := llm.Generate(context.Background(), messages, model.WithAudioOutput("alloy", "wav")); !errors.Is(err, ErrModelGen) {
+		t.Errorf("Expected ErrModelGen for invalid audio data, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package openai
+
+import "nyxze/fayth/model"
+
+// Models describes the OpenAI models, used to validate requests before they are sent.
+// Register descriptions for models missing here, requests to unknown models are not checked.
+var Models = model.NewRegistry(
+	// GPT-4.1
+	gpt(ChatModelGPT4_1, 1_047_576, 32_768, model.Pricing{Input: 2, CachedInput: 0.5, Output: 8}),
+	gpt(ChatModelGPT4_1Mini, 1_047_576, 32_768, model.Pricing{Input: 0.4, CachedInput: 0.1, Output: 1.6}),
+	gpt(ChatModelGPT4_1Nano, 1_047_576, 32_768, model.Pricing{Input: 0.1, CachedInput: 0.025, Output: 0.4}),
+
+	// Reasoning
+	reasoning(ChatModelO4Mini, 200_000, 100_000, true, model.Pricing{Input: 1.1, CachedInput: 0.275, Output: 4.4}),
+	reasoning(ChatModelO3, 200_000, 100_000, true, model.Pricing{Input: 2, CachedInput: 0.5, Output: 8}),
+	reasoning(ChatModelO3Mini, 200_000, 100_000, false, model.Pricing{Input: 1.1, CachedInput: 0.55, Output: 4.4}),
+	reasoning(ChatModelO1, 200_000, 100_000, true, model.Pricing{Input: 15, CachedInput: 7.5, Output: 60}),
+	reasoning(ResponsesModelO1Pro, 200_000, 100_000, true, model.Pricing{Input: 150, Output: 600}),
+	reasoning(ChatModelCodexMiniLatest, 200_000, 100_000, true, model.Pricing{Input: 1.5, CachedInput: 0.375, Output: 6}),
+	model.Info{
+		Name: ChatModelO1Preview, ContextWindow: 128_000, MaxOutputTokens: 32_768, Reasoning: true,
+		Pricing: model.Pricing{Input: 15, CachedInput: 7.5, Output: 60},
+	},
+	model.Info{
+		Name: ChatModelO1Mini, ContextWindow: 128_000, MaxOutputTokens: 65_536, Reasoning: true,
+		Pricing: model.Pricing{Input: 1.1, CachedInput: 0.55, Output: 4.4},
+	},
+
+	// GPT-4o
+	gpt(ChatModelGPT4o, 128_000, 16_384, model.Pricing{Input: 2.5, CachedInput: 1.25, Output: 10}),
+	gpt(ChatModelGPT4oMini, 128_000, 16_384, model.Pricing{Input: 0.15, CachedInput: 0.075, Output: 0.6}),
+	model.Info{
+		Name: ChatModelGPT4o2024_05_13, ContextWindow: 128_000, MaxOutputTokens: 4_096,
+		Vision: true, Temperature: true,
+		Pricing: model.Pricing{Input: 5, Output: 15},
+	},
+	model.Info{
+		Name: ChatModelChatgpt4oLatest, ContextWindow: 128_000, MaxOutputTokens: 16_384,
+		Vision: true, Temperature: true,
+		Pricing: model.Pricing{Input: 5, Output: 15},
+	},
+	audio(ChatModelGPT4oAudioPreview, model.Pricing{Input: 2.5, Output: 10}),
+	audio(ChatModelGPT4oMiniAudioPreview, model.Pricing{Input: 0.15, Output: 0.6}),
+	search(ChatModelGPT4oSearchPreview, model.Pricing{Input: 2.5, Output: 10}),
+	search(ChatModelGPT4oMiniSearchPreview, model.Pricing{Input: 0.15, Output: 0.6}),
+
+	// GPT-4
+	model.Info{
+		Name: ChatModelGPT4Turbo, ContextWindow: 128_000, MaxOutputTokens: 4_096,
+		Vision: true, Temperature: true,
+		Pricing: model.Pricing{Input: 10, Output: 30},
+	},
+	legacy(ChatModelGPT4TurboPreview, 128_000, 4_096, model.Pricing{Input: 10, Output: 30}),
+	legacy(ChatModelGPT4_0125Preview, 128_000, 4_096, model.Pricing{Input: 10, Output: 30}),
+	legacy(ChatModelGPT4_1106Preview, 128_000, 4_096, model.Pricing{Input: 10, Output: 30}),
+	model.Info{
+		Name: ChatModelGPT4VisionPreview, ContextWindow: 128_000, MaxOutputTokens: 4_096,
+		Vision: true, Temperature: true,
+		Pricing: model.Pricing{Input: 10, Output: 30},
+	},
+	legacy(ChatModelGPT4, 8_192, 8_192, model.Pricing{Input: 30, Output: 60}),
+	legacy(ChatModelGPT4_0314, 8_192, 8_192, model.Pricing{Input: 30, Output: 60}),
+	legacy(ChatModelGPT4_32k, 32_768, 32_768, model.Pricing{Input: 60, Output: 120}),
+	legacy(ChatModelGPT4_32k0314, 32_768, 32_768, model.Pricing{Input: 60, Output: 120}),
+
+	// GPT-3.5
+	legacy(ChatModelGPT3_5Turbo, 16_385, 4_096, model.Pricing{Input: 0.5, Output: 1.5}),
+	legacy(ChatModelGPT3_5Turbo1106, 16_385, 4_096, model.Pricing{Input: 1, Output: 2}),
+	legacy(ChatModelGPT3_5Turbo16k, 16_385, 4_096, model.Pricing{Input: 3, Output: 4}),
+	legacy(ChatModelGPT3_5Turbo0613, 4_096, 4_096, model.Pricing{Input: 1.5, Output: 2}),
+	legacy(ChatModelGPT3_5Turbo0301, 4_096, 4_096, model.Pricing{Input: 1.5, Output: 2}),
+)
+
+// gpt describes a current general purpose model.
+func gpt(name string, context, output int, pricing model.Pricing) model.Info {
+	return model.Info{
+		Name: name, ContextWindow: context, MaxOutputTokens: output,
+		Vision: true, Temperature: true,
+		Pricing: pricing,
+	}
+}
+
+// reasoning describes a reasoning model, which does not accept sampling parameters
+// and takes system instructions as developer messages.
+func reasoning(name string, context, output int, vision bool, pricing model.Pricing) model.Info {
+	return model.Info{
+		Name: name, ContextWindow: context, MaxOutputTokens: output,
+		Vision: vision, Reasoning: true, DeveloperRole: true,
+		Pricing: pricing,
+	}
+}
+
+// audio describes an audio preview model, priced here for text tokens.
+func audio(name string, pricing model.Pricing) model.Info {
+	return model.Info{
+		Name: name, ContextWindow: 128_000, MaxOutputTokens: 16_384,
+		Audio: true, Temperature: true,
+		Pricing: pricing,
+	}
+}
+
+// search describes a web search preview model, which does not accept sampling parameters.
+func search(name string, pricing model.Pricing) model.Info {
+	return model.Info{
+		Name: name, ContextWindow: 128_000, MaxOutputTokens: 16_384,
+		Pricing: pricing,
+	}
+}
+
+// legacy describes a text only model predating structured outputs.
+func legacy(name string, context, output int, pricing model.Pricing) model.Info {
+	return model.Info{
+		Name: name, ContextWindow: context, MaxOutputTokens: output,
+		Temperature: true,
+		Pricing:     pricing,
+	}
+}
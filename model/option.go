@@ -29,6 +29,10 @@ type ModelOptions struct {
 	// If 0, uses the model's default maximum
 	MaxTokens int `json:"max_tokens,omitzero"`
 
+	// N is the number of choices to generate, distinguished by their [Message] Index
+	// If 0, uses the model's default of one choice
+	N int `json:"n,omitzero"`
+
 	// TopP controls nucleus sampling (0.0 to 1.0)
 	// Alternative to temperature for controlling randomness
 	TopP float64 `json:"top_p,omitzero"`
@@ -60,6 +64,21 @@ type ModelOptions struct {
 	// TopLogProbs specifies number of top log probabilities to return (0-20)
 	TopLogProbs int `json:"top_logprobs,omitzero"`
 
+	// ReasoningEffort bounds the reasoning of reasoning models before they answer
+	// If empty, uses the model's default effort
+	ReasoningEffort ReasoningEffort `json:"reasoning_effort,omitzero"`
+
+	// Modalities are the kinds of output to generate, such as text and audio
+	// If empty, uses the model's default of text
+	Modalities []Modality `json:"modalities,omitzero"`
+
+	// Audio configures the generated audio, required with the audio modality
+	Audio AudioOptions `json:"audio,omitzero"`
+
+	// AutoContinue is the maximum number of follow-up requests made to complete
+	// a message truncated at the token limit. Zero disables continuation.
+	AutoContinue int `json:"auto_continue,omitzero"`
+
 	// MessageHandler is called with each message chunk when streaming
 	// If nil, streaming is disabled
 	MessageHandler []MessageHandler `json:"-"`
@@ -70,6 +89,32 @@ type ResponseFormat struct {
 	Type string `json:"type,omitzero"` // "text" or "json_object"
 }
 
+// ReasoningEffort is how much a reasoning model thinks before answering.
+// Lower efforts answer faster and spend fewer reasoning tokens.
+type ReasoningEffort string
+
+const (
+	ReasoningLow    ReasoningEffort = "low"
+	ReasoningMedium ReasoningEffort = "medium"
+	ReasoningHigh   ReasoningEffort = "high"
+)
+
+// Modality is a kind of content generated by a model.
+type Modality string
+
+const (
+	ModalityText  Modality = "text"
+	ModalityAudio Modality = "audio"
+)
+
+// AudioOptions configures the audio generated by audio models.
+type AudioOptions struct {
+	// Voice speaking the answer, such as "alloy"
+	Voice string `json:"voice,omitzero"`
+	// Format is the encoding of the audio, such as "wav", "mp3" or "pcm16"
+	Format string `json:"format,omitzero"`
+}
+
 type ModelOption func(*ModelOptions)
 
 // WithModel sets the model to use
@@ -93,6 +138,13 @@ func WithMaxTokens(maxTokens int) ModelOption {
 	}
 }
 
+// WithN sets the number of choices to generate, see [Generation.Choices]
+func WithN(n int) ModelOption {
+	return func(mo *ModelOptions) {
+		mo.N = n
+	}
+}
+
 // WithStream enables or disables streaming and optionally sets a message handler.
 //
 // The first argument 's' determines whether streaming is enabled.
@@ -176,6 +228,39 @@ func WithTopLogProbs(count int) ModelOption {
 	}
 }
 
+// WithReasoningEffort sets the reasoning effort of reasoning models
+func WithReasoningEffort(effort ReasoningEffort) ModelOption {
+	return func(mo *ModelOptions) {
+		mo.ReasoningEffort = effort
+	}
+}
+
+// WithModalities sets the kinds of output to generate
+func WithModalities(modalities ...Modality) ModelOption {
+	return func(mo *ModelOptions) {
+		mo.Modalities = modalities
+	}
+}
+
+// WithAudioOutput asks for a spoken answer along its text transcript,
+// in the voice and audio format given
+func WithAudioOutput(voice, format string) ModelOption {
+	return func(mo *ModelOptions) {
+		mo.Modalities = []Modality{ModalityText, ModalityAudio}
+		mo.Audio = AudioOptions{Voice: voice, Format: format}
+	}
+}
+
+// WithAutoContinue resumes messages truncated at the token limit, up to maxRounds
+// follow-up requests, and stitches the pieces into one message. See [AutoContinue].
+// It applies to models calling AutoContinue from Generate, such as the OpenAI model,
+// and is ignored by the others.
+func WithAutoContinue(maxRounds int) ModelOption {
+	return func(mo *ModelOptions) {
+		mo.AutoContinue = maxRounds
+	}
+}
+
 func MergeOptions(base ModelOptions, overrides ...ModelOption) ModelOptions {
 	opts := base
 	for _, o := range overrides {
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Info describes the capabilities and prices of a model.
+type Info struct {
+	Name string
+	// ContextWindow is the maximum number of input and output tokens
+	ContextWindow int
+	// MaxOutputTokens is the maximum number of generated tokens
+	MaxOutputTokens int
+
+	// Vision models accept image contents
+	Vision bool
+	// Audio models accept and produce audio contents
+	Audio bool
+	// Reasoning models think before answering, spending reasoning tokens
+	Reasoning bool
+	// DeveloperRole models take system instructions as developer messages
+	DeveloperRole bool
+	// Temperature models accept sampling parameters, such as temperature, top_p and penalties
+	Temperature bool
+
+	Pricing Pricing
+}
+
+// Pricing holds the prices of a model in US dollars per million tokens.
+type Pricing struct {
+	Input float64
+	// CachedInput is the price of prompt tokens served from cache, Input when zero
+	CachedInput float64
+	Output      float64
+}
+
+// Registry indexes model descriptions by name.
+// It is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	models map[string]Info
+}
+
+// NewRegistry returns a Registry describing models.
+func NewRegistry(models ...Info) *Registry {
+	r := &Registry{models: make(map[string]Info, len(models))}
+	r.Register(models...)
+	return r
+}
+
+// Register adds or replaces model descriptions.
+func (r *Registry) Register(models ...Info) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range models {
+		r.models[m.Name] = m
+	}
+}
+
+// snapshot matches the date suffix of pinned model versions, such as "-2024-08-06" or "-0613"
+var snapshot = regexp.MustCompile(`-(\d{4}-\d{2}-\d{2}|\d{8}|\d{4})$`)
+
+// Lookup returns the description of the named model. Pinned snapshots such as "gpt-4o-2024-11-20"
+// and fine-tuned models such as "ft:gpt-4o-mini:org::id" fall back to their base model
+// when they are not registered themselves. Fine-tuned models are billed at their own rates,
+// so the Pricing of a base model is zero when a fine-tuned model falls back to it,
+// see [Registry.Price].
+func (r *Registry) Lookup(name string) (Info, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if info, ok := r.models[name]; ok {
+		return info, true
+	}
+	if base, ok := strings.CutPrefix(name, "ft:"); ok {
+		base, _, _ = strings.Cut(base, ":")
+		info, ok := r.models[base]
+		if !ok {
+			info, ok = r.pinned(base)
+		}
+		info.Pricing = Pricing{}
+		return info, ok
+	}
+	return r.pinned(name)
+}
+
+// Price returns the pricing of the named model, following pinned snapshots like [Registry.Lookup].
+// Fine-tuned models are only priced when registered under their full name.
+func (r *Registry) Price(name string) (Pricing, bool) {
+	info, ok := r.Lookup(name)
+	if strings.HasPrefix(name, "ft:") && info.Name != name {
+		return Pricing{}, false
+	}
+	return info.Pricing, ok
+}
+
+// pinned returns the base model of a pinned snapshot. The caller holds the lock.
+func (r *Registry) pinned(name string) (Info, bool) {
+	if base := snapshot.ReplaceAllString(name, ""); base != name {
+		info, ok := r.models[base]
+		return info, ok
+	}
+	return Info{}, false
+}
+
+// Models returns every registered description, sorted by name.
+func (r *Registry) Models() []Info {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Info, 0, len(r.models))
+	for _, m := range r.models {
+		out = append(out, m)
+	}
+	slices.SortFunc(out, func(a, b Info) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
@@ -0,0 +1,54 @@
+package model
+
+import "testing"
+
+func TestRegistry_Lookup(t *testing.T) {
+	r := NewRegistry(
+		Info{Name: "gpt-4o", MaxOutputTokens: 16_384, Pricing: Pricing{Input: 2.5, Output: 10}},
+		Info{Name: "gpt-4o-2024-05-13", MaxOutputTokens: 4_096},
+		Info{Name: "gpt-4", MaxOutputTokens: 8_192},
+		Info{Name: "claude-sonnet-4", MaxOutputTokens: 64_000},
+	)
+	tests := []struct {
+		name   string
+		want   string
+		output int
+	}{
+		{"gpt-4o", "gpt-4o", 16_384},
+		{"gpt-4o-2024-11-20", "gpt-4o", 16_384},
+		{"gpt-4o-2024-05-13", "gpt-4o-2024-05-13", 4_096},
+		{"gpt-4-0613", "gpt-4", 8_192},
+		{"claude-sonnet-4-20250514", "claude-sonnet-4", 64_000},
+		{"ft:gpt-4o:acme::9f8e7d", "gpt-4o", 16_384},
+		{"gpt-4o-mini", "", 0},
+		{"unknown", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, ok := r.Lookup(tt.name)
+			if ok != (tt.want != "") || info.Name != tt.want || info.MaxOutputTokens != tt.output {
+				t.Errorf("Lookup(%q) = %+v, %v, want %q", tt.name, info, ok, tt.want)
+			}
+		})
+	}
+
+	// Fine-tuned models are only priced when registered
+	if info, ok := r.Lookup("ft:gpt-4o:acme::9f8e7d"); !ok || info.Pricing != (Pricing{}) {
+		t.Errorf("Lookup() of a fine-tuned model = %+v, want the base model without its pricing", info)
+	}
+	if _, ok := r.Price("ft:gpt-4o:acme::9f8e7d"); ok {
+		t.Error("Price() of an unregistered fine-tuned model should fail")
+	}
+	if p, ok := r.Price("gpt-4o-2024-11-20"); !ok || p.Input != 2.5 {
+		t.Errorf("Price() of a snapshot = %+v, %v, want the base pricing", p, ok)
+	}
+	r.Register(Info{Name: "ft:gpt-4o:acme::9f8e7d", Pricing: Pricing{Input: 3.75, Output: 15}})
+	if p, ok := r.Price("ft:gpt-4o:acme::9f8e7d"); !ok || p.Input != 3.75 {
+		t.Errorf("Price() of a registered fine-tuned model = %+v, %v", p, ok)
+	}
+
+	r.Register(Info{Name: "gpt-4o-mini"})
+	if _, ok := r.Lookup("gpt-4o-mini"); !ok || len(r.Models()) != 6 || r.Models()[0].Name != "claude-sonnet-4" {
+		t.Errorf("Register() models = %+v", r.Models())
+	}
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer splits text into the tokens a model would count.
+type Tokenizer interface {
+	// Tokens returns the tokens of text, joining them reproduces text.
+	Tokens(text string) []string
+}
+
+// ApproxTokenizer approximates BPE tokenizers without a vocabulary.
+// Words become a token each with their leading space, punctuation marks are tokens
+// on their own and long words are split every 4 characters, which lands close to
+// OpenAI tokenizers on English text.
+type ApproxTokenizer struct{}
+
+// Compile type interface assertion
+var _ Tokenizer = ApproxTokenizer{}
+
+const approxMaxTokenRunes = 4
+
+func (ApproxTokenizer) Tokens(text string) []string {
+	var tokens []string
+	start := 0
+	for start < len(text) {
+		end := start
+		// Leading whitespace sticks to the following token
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			end += size
+		}
+		if end == len(text) {
+			tokens = append(tokens, text[start:end])
+			break
+		}
+		r, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			for n := 1; end < len(text) && n < approxMaxTokenRunes; n++ {
+				r, size := utf8.DecodeRuneInString(text[end:])
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				end += size
+			}
+		}
+		tokens = append(tokens, text[start:end])
+		start = end
+	}
+	return tokens
+}
+
+// CountTokens returns the number of tokens of text according to t.
+func CountTokens(t Tokenizer, text string) int {
+	return len(t.Tokens(text))
+}
@@ -0,0 +1,36 @@
+package model
+
+// Usage counts the tokens billed for a generation.
+type Usage struct {
+	InputTokens int `json:"input_tokens"`
+	// CachedInputTokens is the part of InputTokens served from the prompt cache
+	CachedInputTokens int `json:"cached_input_tokens,omitzero"`
+	OutputTokens      int `json:"output_tokens"`
+	// ReasoningTokens is the part of OutputTokens spent reasoning, not visible in the answer
+	ReasoningTokens int `json:"reasoning_tokens,omitzero"`
+}
+
+// Add returns the sum of u and other.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		InputTokens:       u.InputTokens + other.InputTokens,
+		CachedInputTokens: u.CachedInputTokens + other.CachedInputTokens,
+		OutputTokens:      u.OutputTokens + other.OutputTokens,
+		ReasoningTokens:   u.ReasoningTokens + other.ReasoningTokens,
+	}
+}
+
+// Total returns the number of input and output tokens.
+func (u Usage) Total() int {
+	return u.InputTokens + u.OutputTokens
+}
+
+// Cost returns the price of usage in US dollars.
+func (p Pricing) Cost(u Usage) float64 {
+	cached := p.CachedInput
+	if cached == 0 {
+		cached = p.Input
+	}
+	uncached := u.InputTokens - u.CachedInputTokens
+	return (float64(uncached)*p.Input + float64(u.CachedInputTokens)*cached + float64(u.OutputTokens)*p.Output) / 1e6
+}
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type jsonParser[T any] struct {
+	strict       bool
+	instructions string
+}
+
+// JSON returns a Parser for any JSON value, repaired with [Repair] before decoding.
+func JSON() Parser[any] {
+	return jsonParser[any]{instructions: "Answer with valid JSON only, without any other text."}
+}
+
+// Struct returns a Parser decoding a JSON object into T, repaired with [Repair] before decoding.
+// Fields unknown to T are rejected. The instructions describe the fields of T from their json tags.
+func Struct[T any]() Parser[T] {
+	return jsonParser[T]{
+		strict:       true,
+		instructions: "Answer with a JSON object only, without any other text, of the form:\n" + describe(reflect.TypeFor[T](), 0),
+	}
+}
+
+func (p jsonParser[T]) Parse(text string) (T, error) {
+	var v T
+	dec := json.NewDecoder(bytes.NewReader([]byte(Repair(text))))
+	if p.strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(&v); err != nil {
+		return v, fmt.Errorf("%w: %v", ErrInvalidOutput, err)
+	}
+	return v, nil
+}
+
+func (p jsonParser[T]) Instructions() string { return p.instructions }
+
+// describe renders a JSON skeleton of t, naming the type of every field.
+func describe(t reflect.Type, depth int) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if depth > 3 {
+			return "{...}"
+		}
+		indent := strings.Repeat("  ", depth+1)
+		var fields []string
+		for i := range t.NumField() {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			field := fmt.Sprintf("%s%q: %s", indent, name, describe(f.Type, depth+1))
+			if strings.Contains(opts, "omitempty") || strings.Contains(opts, "omitzero") {
+				field += " (optional)"
+			}
+			fields = append(fields, field)
+		}
+		return "{\n" + strings.Join(fields, ",\n") + "\n" + strings.Repeat("  ", depth) + "}"
+	case reflect.Slice, reflect.Array:
+		return "[" + describe(t.Elem(), depth) + ", ...]"
+	case reflect.Map:
+		return "{string: " + describe(t.Elem(), depth) + "}"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return "any"
+	}
+}
@@ -0,0 +1,147 @@
+// Package output parses model answers into Go values, tolerating the usual
+// formatting noise such as Markdown fences or truncated JSON.
+package output
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Errors
+var (
+	ErrInvalidOutput = errors.New("output: invalid model output")
+)
+
+// Parser turns the text of a model answer into a value.
+type Parser[T any] interface {
+	// Parse returns the value held by text, or an error wrapping [ErrInvalidOutput].
+	Parse(text string) (T, error)
+	// Instructions describes the expected format, to include in the prompt.
+	Instructions() string
+}
+
+// ParserFunc adapts a function to the [Parser] interface.
+type ParserFunc[T any] struct {
+	Func func(text string) (T, error)
+	// Format is returned by Instructions
+	Format string
+}
+
+func (p ParserFunc[T]) Parse(text string) (T, error) { return p.Func(text) }
+func (p ParserFunc[T]) Instructions() string         { return p.Format }
+
+type listParser struct{}
+
+// Compile type interface assertion
+var _ Parser[[]string] = listParser{}
+
+// List returns a Parser for bulleted, numbered, line separated or comma separated lists.
+func List() Parser[[]string] { return listParser{} }
+
+var bullet = regexp.MustCompile(`^\s*(?:[-*•+]|\d+[.)])\s+`)
+
+func (listParser) Parse(text string) ([]string, error) {
+	text = strings.TrimSpace(stripFences(text))
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		lines = strings.Split(text, ",")
+	}
+	var items []string
+	for _, line := range lines {
+		item := strings.TrimSpace(bullet.ReplaceAllString(line, ""))
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: empty list", ErrInvalidOutput)
+	}
+	return items, nil
+}
+
+func (listParser) Instructions() string {
+	return "Answer with a list, one item per line, without any other text."
+}
+
+type enumParser struct {
+	values []string
+}
+
+// Enum returns a Parser accepting one of values, ignoring case, surrounding quotes and punctuation.
+// The value is returned as spelled in values.
+func Enum(values ...string) Parser[string] { return enumParser{values: values} }
+
+func (p enumParser) Parse(text string) (string, error) {
+	answer := strings.Trim(strings.TrimSpace(stripFences(text)), "\"'`.!*")
+	for _, v := range p.values {
+		if strings.EqualFold(answer, v) {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q is not one of %s", ErrInvalidOutput, answer, strings.Join(p.values, ", "))
+}
+
+func (p enumParser) Instructions() string {
+	return "Answer with exactly one of: " + strings.Join(p.values, ", ") + "."
+}
+
+type xmlParser struct {
+	tags []string
+}
+
+// XML returns a Parser extracting the content of <tag>...</tag> sections, keyed by tag name.
+// A section left open at the end of a truncated answer runs to the end of the text.
+func XML(tags ...string) Parser[map[string]string] { return xmlParser{tags: tags} }
+
+func (p xmlParser) Parse(text string) (map[string]string, error) {
+	sections := make(map[string]string, len(p.tags))
+	var missing []string
+	for _, tag := range p.tags {
+		open, end := "<"+tag+">", "</"+tag+">"
+		start := strings.Index(text, open)
+		if start < 0 {
+			missing = append(missing, tag)
+			continue
+		}
+		content := text[start+len(open):]
+		if stop := strings.Index(content, end); stop >= 0 {
+			content = content[:stop]
+		}
+		sections[tag] = strings.TrimSpace(content)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: missing sections %s", ErrInvalidOutput, strings.Join(missing, ", "))
+	}
+	return sections, nil
+}
+
+func (p xmlParser) Instructions() string {
+	var sb strings.Builder
+	sb.WriteString("Answer with the following sections:\n")
+	for _, tag := range p.tags {
+		fmt.Fprintf(&sb, "<%s>...</%s>\n", tag, tag)
+	}
+	return sb.String()
+}
+
+// stripFences returns the content of the first Markdown code block of text,
+// or text when it holds none. An unterminated block runs to the end of text.
+func stripFences(text string) string {
+	start := strings.Index(text, "```")
+	if start < 0 {
+		return text
+	}
+	body := text[start+3:]
+	// Skip the language tag
+	if nl := strings.IndexByte(body, '\n'); nl >= 0 {
+		body = body[nl+1:]
+	} else {
+		return text
+	}
+	if end := strings.Index(body, "```"); end >= 0 {
+		body = body[:end]
+	}
+	return body
+}
@@ -0,0 +1,186 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"nyxze/fayth/model"
+)
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"Bullets", "- apples\n* pears\n• plums", []string{"apples", "pears", "plums"}},
+		{"Numbered", "1. apples\n2) pears\n\n3. plums", []string{"apples", "pears", "plums"}},
+		{"Comma separated", "apples, pears ,plums", []string{"apples", "pears", "plums"}},
+		{"Fenced", "```\n- apples\n- pears\n```", []string{"apples", "pears"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := List().Parse(tt.in)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Parse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if _, err := List().Parse(" \n"); !errors.Is(err, ErrInvalidOutput) {
+		t.Errorf("Parse() error = %v, want ErrInvalidOutput", err)
+	}
+}
+
+func TestEnum(t *testing.T) {
+	p := Enum("Positive", "Negative", "Neutral")
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"Positive", "Positive", false},
+		{" negative.\n", "Negative", false},
+		{`"NEUTRAL"`, "Neutral", false},
+		{"**positive**", "Positive", false},
+		{"Mixed", "", true},
+		{"The sentiment is positive", "", true},
+	}
+	for _, tt := range tests {
+		got, err := p.Parse(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Parse(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if !strings.Contains(p.Instructions(), "Positive, Negative, Neutral") {
+		t.Errorf("Instructions() = %q", p.Instructions())
+	}
+}
+
+func TestXML(t *testing.T) {
+	p := XML("thinking", "answer")
+	got, err := p.Parse("<thinking>\n2 + 2\n</thinking>\nSo:\n<answer>4</answer>")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	if want := map[string]string{"thinking": "2 + 2", "answer": "4"}; !maps.Equal(got, want) {
+		t.Errorf("Parse() = %v, want %v", got, want)
+	}
+
+	got, err = p.Parse("<thinking>a</thinking><answer>truncated")
+	if err != nil || got["answer"] != "truncated" {
+		t.Errorf("Parse() truncated = %v, %v", got, err)
+	}
+	if _, err := p.Parse("<answer>4</answer>"); !errors.Is(err, ErrInvalidOutput) {
+		t.Errorf("Parse() error = %v, want ErrInvalidOutput", err)
+	}
+}
+
+type person struct {
+	Name    string   `json:"name"`
+	Age     int      `json:"age"`
+	Emails  []string `json:"emails,omitempty"`
+	Address *struct {
+		City string `json:"city"`
+	} `json:"address"`
+	internal bool
+}
+
+func TestStruct(t *testing.T) {
+	p := Struct[person]()
+	got, err := p.Parse("```json\n{\"name\": \"Ada\", \"age\": 36, \"emails\": [\"ada@example.com\",], \"address\": {\"city\": \"Lon")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	if got.Name != "Ada" || got.Age != 36 || !slices.Equal(got.Emails, []string{"ada@example.com"}) || got.Address.City != "Lon" {
+		t.Errorf("Parse() = %+v", got)
+	}
+
+	for _, in := range []string{`{"name": "Ada", "nickname": "Countess"}`, `{"name": "Ada", "age": "old"}`, "I don't know"} {
+		if _, err := p.Parse(in); !errors.Is(err, ErrInvalidOutput) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalidOutput", in, err)
+		}
+	}
+
+	want := `{
+  "name": string,
+  "age": integer,
+  "emails": [string, ...] (optional),
+  "address": {
+    "city": string
+  }
+}`
+	if !strings.HasSuffix(p.Instructions(), want) {
+		t.Errorf("Instructions() = %s, want suffix %s", p.Instructions(), want)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	got, err := JSON().Parse(`Sure! {"tags": ["a", "b",], "extra": 1}`)
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	want := map[string]any{"tags": []any{"a", "b"}, "extra": 1.0}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %v, want %v", got, want)
+	}
+}
+
+// scriptedModel answers with the next reply on every call, keeping the conversations it was given
+type scriptedModel struct {
+	replies []string
+	inputs  [][]model.Message
+}
+
+func (s *scriptedModel) Generate(_ context.Context, m []model.Message, _ ...model.ModelOption) (*model.Generation, error) {
+	s.inputs = append(s.inputs, m)
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	return model.NewGeneration([]model.Message{model.NewTextMessage(model.Assistant, reply)}), nil
+}
+
+func TestParseWithRetry(t *testing.T) {
+	question := []model.Message{model.NewTextMessage(model.User, "Sentiment of: I love it")}
+
+	t.Run("Recovers", func(t *testing.T) {
+		llm := &scriptedModel{replies: []string{"It is very positive!", "Positive"}}
+		got, err := ParseWithRetry(context.Background(), llm, question, Enum("Positive", "Negative"), 2)
+		if err != nil {
+			t.Fatalf("ParseWithRetry() unexpected error: %v", err)
+		}
+		if got != "Positive" {
+			t.Errorf("ParseWithRetry() = %q, want Positive", got)
+		}
+		if len(llm.inputs) != 2 || len(llm.inputs[1]) != 3 {
+			t.Fatalf("inputs = %v, want a second call with the failed answer and the error", llm.inputs)
+		}
+		retry := llm.inputs[1]
+		if retry[1].Role != model.Assistant || retry[1].Text() != "It is very positive!" {
+			t.Errorf("retry answer = %+v", retry[1])
+		}
+		if retry[2].Role != model.User || !strings.Contains(retry[2].Text(), "is not one of Positive, Negative") {
+			t.Errorf("retry prompt = %q", retry[2].Text())
+		}
+		if len(question) != 1 {
+			t.Error("ParseWithRetry() modified its input")
+		}
+	})
+
+	t.Run("Gives up", func(t *testing.T) {
+		llm := &scriptedModel{replies: []string{"good", "great", "nice"}}
+		_, err := ParseWithRetry(context.Background(), llm, question, Enum("Positive", "Negative"), 2)
+		if !errors.Is(err, ErrInvalidOutput) {
+			t.Errorf("ParseWithRetry() error = %v, want ErrInvalidOutput", err)
+		}
+		if len(llm.inputs) != 3 {
+			t.Errorf("calls = %d, want 3", len(llm.inputs))
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package output
+
+import (
+	"strings"
+)
+
+// Repair returns a best-effort valid JSON version of a model answer. It strips Markdown
+// fences and surrounding prose, drops trailing commas and closes the strings, arrays
+// and objects left open by a truncated answer. Text without any object or array is
+// returned trimmed.
+func Repair(text string) string {
+	text = stripFences(text)
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return strings.TrimSpace(text)
+	}
+	text = text[start:]
+
+	var (
+		out      strings.Builder
+		stack    []byte // expected closing characters
+		inString bool
+		escaped  bool
+		// isKey reports whether the string being read is an object key,
+		// afterKey that a key was read without its colon yet
+		isKey, afterKey bool
+	)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			out.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+				afterKey = isKey
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+			isKey = len(stack) > 0 && stack[len(stack)-1] == '}' && expectsKey(out.String())
+		case '{':
+			stack = append(stack, '}')
+		case '[':
+			stack = append(stack, ']')
+		case '}', ']':
+			if len(stack) == 0 || stack[len(stack)-1] != c {
+				// Stray closing character
+				continue
+			}
+			trimTrailingComma(&out)
+			stack = stack[:len(stack)-1]
+			out.WriteByte(c)
+			if len(stack) == 0 {
+				return out.String()
+			}
+			continue
+		case ':':
+			afterKey = false
+		}
+		out.WriteByte(c)
+	}
+
+	// The answer was truncated, complete the last value and close containers
+	keyPending := afterKey || inString && isKey
+	if inString {
+		s := out.String()
+		if escaped {
+			s = s[:len(s)-1]
+		}
+		out.Reset()
+		out.WriteString(s)
+		out.WriteByte('"')
+	}
+	s := strings.TrimRight(out.String(), " \t\r\n")
+	s = strings.TrimRight(completeLiteral(s), " \t\r\n")
+	switch {
+	case keyPending:
+		s += ":null"
+	case strings.HasSuffix(s, ":"):
+		s += "null"
+	}
+	out.Reset()
+	out.WriteString(s)
+	trimTrailingComma(&out)
+	for i := len(stack) - 1; i >= 0; i-- {
+		out.WriteByte(stack[i])
+	}
+	return out.String()
+}
+
+// expectsKey reports whether a string starting after prefix, inside an object, is a key.
+func expectsKey(prefix string) bool {
+	prefix = strings.TrimRight(prefix, " \t\r\n")
+	return strings.HasSuffix(prefix, "{") || strings.HasSuffix(prefix, ",")
+}
+
+// trimTrailingComma removes a comma ending the content of b, ignoring whitespace.
+func trimTrailingComma(b *strings.Builder) {
+	s := strings.TrimRight(b.String(), " \t\r\n")
+	if !strings.HasSuffix(s, ",") {
+		return
+	}
+	b.Reset()
+	b.WriteString(s[:len(s)-1])
+}
+
+// completeLiteral finishes a truncated true, false or null literal ending s,
+// and drops a number left without digits after its sign, dot or exponent.
+func completeLiteral(s string) string {
+	end := len(s)
+	start := end
+	for start > 0 && strings.IndexByte("abcdefghijklmnopqrstuvwxyz0123456789.+-E", s[start-1]) >= 0 {
+		start--
+	}
+	word := s[start:end]
+	if word == "" {
+		return s
+	}
+	for _, lit := range []string{"true", "false", "null"} {
+		if strings.HasPrefix(lit, word) {
+			return s[:start] + lit
+		}
+	}
+	return strings.TrimRight(s, ".+-eE")
+}
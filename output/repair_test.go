@@ -0,0 +1,45 @@
+package output
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"Valid", `{"a": 1}`, `{"a": 1}`},
+		{"Fenced", "Here you go:\n```json\n{\"a\": [1, 2]}\n```\nAnything else?", `{"a": [1, 2]}`},
+		{"Prose", `The answer is {"ok": true} as requested.`, `{"ok": true}`},
+		{"Trailing commas", "{\"a\": [1, 2,], \"b\": 3,\n}", `{"a": [1, 2], "b": 3}`},
+		{"Truncated string", `{"text": "Hello, wor`, `{"text": "Hello, wor"}`},
+		{"Truncated escape", `{"text": "a\`, `{"text": "a"}`},
+		{"Truncated nesting", `{"items": [{"id": 1}, {"id": 2`, `{"items": [{"id": 1}, {"id": 2}]}`},
+		{"Truncated after comma", `[1, 2, `, `[1, 2]`},
+		{"Truncated key", `{"a": 1, "b`, `{"a": 1, "b":null}`},
+		{"Key without colon", `{"a": 1, "b"`, `{"a": 1, "b":null}`},
+		{"Key without value", `{"a": 1, "b": `, `{"a": 1, "b":null}`},
+		{"Truncated literal", `{"a": tr`, `{"a": true}`},
+		{"Truncated null", `[nu`, `[null]`},
+		{"Truncated number", `{"a": 1.`, `{"a": 1}`},
+		{"Truncated sign", `{"a": -`, `{"a":null}`},
+		{"Strings with brackets", `{"a": "}]{[", "b": [`, `{"a": "}]{[", "b": []}`},
+		{"Array in string key position", `["x", "y`, `["x", "y"]`},
+		{"Stray closing", `{"a": 1]}`, `{"a": 1}`},
+		{"Scalar", "  42 \n", "42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Repair(tt.in)
+			if got != tt.want {
+				t.Errorf("Repair(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+			if !json.Valid([]byte(got)) {
+				t.Errorf("Repair(%q) = %s is not valid JSON", tt.in, got)
+			}
+		})
+	}
+}
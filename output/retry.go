@@ -0,0 +1,34 @@
+package output
+
+import (
+	"context"
+	"fmt"
+
+	"nyxze/fayth/model"
+)
+
+// ParseWithRetry generates an answer to m and parses it with p. When parsing fails,
+// the answer and the parse error are appended to the conversation and the model is
+// asked again, up to maxRetries times, before returning the last error.
+func ParseWithRetry[T any](ctx context.Context, llm model.Model, m []model.Message, p Parser[T], maxRetries int, opts ...model.ModelOption) (T, error) {
+	var zero T
+	conversation := append([]model.Message(nil), m...)
+	var parseErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		answer, err := model.GenerateText(ctx, llm, conversation, opts...)
+		if err != nil {
+			return zero, err
+		}
+		v, err := p.Parse(answer)
+		if err == nil {
+			return v, nil
+		}
+		parseErr = err
+		conversation = append(conversation,
+			model.NewTextMessage(model.Assistant, answer),
+			model.NewTextMessage(model.User, fmt.Sprintf(
+				"Your answer could not be parsed: %v\nReply again with the corrected answer only.\n%s", err, p.Instructions())),
+		)
+	}
+	return zero, fmt.Errorf("output: giving up after %d attempts: %w", maxRetries+1, parseErr)
+}
@@ -0,0 +1,224 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+
+	"nyxze/fayth/model"
+)
+
+// Partial is a value decoded from an incomplete JSON document.
+type Partial[T any] struct {
+	Value T
+	// Final holds the paths of the values that are complete, in document order.
+	// Paths join object keys and array indexes with dots, such as "items.0.name".
+	Final []string
+	// Done reports whether the whole document is complete
+	Done bool
+}
+
+// IsFinal reports whether the value at path is complete.
+func (p Partial[T]) IsFinal(path string) bool {
+	return p.Done || slices.Contains(p.Final, path)
+}
+
+// StreamParser decodes a JSON document from text deltas, such as streamed chunks of
+// a JSON mode answer, into progressively more complete values of T.
+type StreamParser[T any] struct {
+	buf      strings.Builder
+	repaired string
+	last     Partial[T]
+}
+
+// NewStreamParser returns a StreamParser decoding into T, a struct or a generic type such as map[string]any.
+func NewStreamParser[T any]() *StreamParser[T] {
+	return &StreamParser[T]{}
+}
+
+// Write appends delta to the document. It returns the decoded partial value and true when
+// the value progressed, or false when the delta added nothing decodable.
+func (p *StreamParser[T]) Write(delta string) (Partial[T], bool) {
+	p.buf.WriteString(delta)
+	text := p.buf.String()
+	start := strings.IndexAny(stripFences(text), "{[")
+	if start < 0 {
+		return p.last, false
+	}
+	repaired := Repair(text)
+	final, done := finalPaths(stripFences(text)[start:])
+	if repaired == p.repaired && len(final) == len(p.last.Final) && done == p.last.Done {
+		return p.last, false
+	}
+	var v T
+	if err := json.Unmarshal([]byte(repaired), &v); err != nil {
+		return p.last, false
+	}
+	p.repaired = repaired
+	p.last = Partial[T]{Value: v, Final: final, Done: done}
+	return p.last, true
+}
+
+// Close returns the decoded document, failing with [ErrInvalidOutput] when it is incomplete.
+func (p *StreamParser[T]) Close() (T, error) {
+	if !p.last.Done {
+		var zero T
+		return zero, fmt.Errorf("%w: incomplete JSON document", ErrInvalidOutput)
+	}
+	return p.last.Value, nil
+}
+
+// Stream decodes the streamed text of the first choice of gen, yielding a Partial each
+// time the value progresses. It yields an error when the generation fails or ends
+// with an incomplete document.
+func Stream[T any](gen *model.Generation) iter.Seq2[Partial[T], error] {
+	return func(yield func(Partial[T], error) bool) {
+		p := NewStreamParser[T]()
+		for msg := range gen.Messages() {
+			if msg.Index != 0 {
+				continue
+			}
+			if partial, ok := p.Write(msg.Text()); ok && !yield(partial, nil) {
+				return
+			}
+		}
+		if err := gen.Error(); err != nil {
+			yield(Partial[T]{}, err)
+			return
+		}
+		if _, err := p.Close(); err != nil {
+			yield(p.last, err)
+		}
+	}
+}
+
+// finalPaths returns the paths of the complete values of a possibly truncated
+// JSON document, and whether the document itself is complete.
+func finalPaths(text string) ([]string, bool) {
+	s := pathScanner{s: text}
+	done := s.value("")
+	return s.final, done
+}
+
+type pathScanner struct {
+	s     string
+	i     int
+	final []string
+}
+
+// value scans the value at path, reporting whether it is complete.
+func (p *pathScanner) value(path string) bool {
+	p.skipSpace()
+	if p.i >= len(p.s) {
+		return false
+	}
+	switch p.s[p.i] {
+	case '{':
+		p.i++
+		for {
+			p.skipSpace()
+			if p.i >= len(p.s) {
+				return false
+			}
+			switch p.s[p.i] {
+			case '}':
+				p.i++
+				return p.complete(path)
+			case ',':
+				p.i++
+				continue
+			}
+			raw, ok := p.str()
+			if !ok {
+				return false
+			}
+			var key string
+			if json.Unmarshal([]byte(raw), &key) != nil {
+				return false
+			}
+			p.skipSpace()
+			if p.i >= len(p.s) || p.s[p.i] != ':' {
+				return false
+			}
+			p.i++
+			if !p.value(join(path, key)) {
+				return false
+			}
+		}
+	case '[':
+		p.i++
+		for index := 0; ; {
+			p.skipSpace()
+			if p.i >= len(p.s) {
+				return false
+			}
+			switch p.s[p.i] {
+			case ']':
+				p.i++
+				return p.complete(path)
+			case ',':
+				p.i++
+				continue
+			}
+			if !p.value(join(path, strconv.Itoa(index))) {
+				return false
+			}
+			index++
+		}
+	case '"':
+		if _, ok := p.str(); !ok {
+			return false
+		}
+		return p.complete(path)
+	default:
+		// Scalars are complete once followed by a delimiter, "12" may still become "123"
+		for p.i < len(p.s) && strings.IndexByte(",]} \t\r\n", p.s[p.i]) < 0 {
+			p.i++
+		}
+		if p.i >= len(p.s) {
+			return false
+		}
+		return p.complete(path)
+	}
+}
+
+// str scans a string starting at the current position and returns it quoted.
+func (p *pathScanner) str() (string, bool) {
+	if p.i >= len(p.s) || p.s[p.i] != '"' {
+		return "", false
+	}
+	start := p.i
+	for p.i++; p.i < len(p.s); p.i++ {
+		switch p.s[p.i] {
+		case '\\':
+			p.i++
+		case '"':
+			p.i++
+			return p.s[start:p.i], true
+		}
+	}
+	return "", false
+}
+
+func (p *pathScanner) complete(path string) bool {
+	if path != "" {
+		p.final = append(p.final, path)
+	}
+	return true
+}
+
+func (p *pathScanner) skipSpace() {
+	for p.i < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
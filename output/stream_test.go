@@ -0,0 +1,129 @@
+package output
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"nyxze/fayth/model"
+)
+
+type article struct {
+	Title string   `json:"title"`
+	Tags  []string `json:"tags"`
+	Score float64  `json:"score"`
+}
+
+const articleJSON = `{"title": "Go \"iterators\"", "tags": ["go", "iter"], "score": 4.5}`
+
+func TestStreamParser(t *testing.T) {
+	p := NewStreamParser[article]()
+	var partials []Partial[article]
+	for _, r := range articleJSON {
+		if partial, ok := p.Write(string(r)); ok {
+			partials = append(partials, partial)
+		}
+	}
+	if len(partials) < 10 {
+		t.Fatalf("Write() emitted %d partials, want one per progress", len(partials))
+	}
+
+	// Values and finality only ever progress
+	var titleFinal, scoreSeen bool
+	for i, partial := range partials {
+		if i > 0 && len(partial.Final) < len(partials[i-1].Final) {
+			t.Errorf("partial %d lost final paths: %v", i, partial.Final)
+		}
+		if partial.IsFinal("title") {
+			titleFinal = true
+			if partial.Value.Title != `Go "iterators"` {
+				t.Errorf("final title = %q", partial.Value.Title)
+			}
+		} else if titleFinal {
+			t.Errorf("partial %d: title no longer final", i)
+		}
+		if partial.Value.Score != 0 {
+			scoreSeen = true
+			if partial.IsFinal("score") && !partial.Done {
+				t.Errorf("partial %d: score final before the document ends", i)
+			}
+		}
+	}
+	if !scoreSeen {
+		t.Error("score never decoded")
+	}
+
+	last := partials[len(partials)-1]
+	if !last.Done || !slices.Equal(last.Final, []string{"title", "tags.0", "tags.1", "tags", "score"}) {
+		t.Errorf("last partial = %+v", last)
+	}
+	got, err := p.Close()
+	if err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+	if got.Title != `Go "iterators"` || !slices.Equal(got.Tags, []string{"go", "iter"}) || got.Score != 4.5 {
+		t.Errorf("Close() = %+v", got)
+	}
+}
+
+func TestStreamParser_Snapshots(t *testing.T) {
+	p := NewStreamParser[map[string]any]()
+	steps := []struct {
+		delta string
+		ok    bool
+		final []string
+		title any
+	}{
+		{"Sure:\n", false, nil, nil},
+		{`{"title": "Hel`, true, nil, "Hel"},
+		{`lo", "ta`, true, []string{"title"}, "Hello"},
+		{`gs": ["a"`, true, []string{"title", "tags.0"}, "Hello"},
+		{` `, false, []string{"title", "tags.0"}, "Hello"},
+		{`]}`, true, []string{"title", "tags.0", "tags"}, "Hello"},
+	}
+	for _, step := range steps {
+		partial, ok := p.Write(step.delta)
+		if ok != step.ok {
+			t.Errorf("Write(%q) ok = %v, want %v", step.delta, ok, step.ok)
+		}
+		if !slices.Equal(partial.Final, step.final) {
+			t.Errorf("Write(%q) final = %v, want %v", step.delta, partial.Final, step.final)
+		}
+		if partial.Value["title"] != step.title {
+			t.Errorf("Write(%q) title = %v, want %v", step.delta, partial.Value["title"], step.title)
+		}
+	}
+}
+
+func chunks(text string, size int) *model.Generation {
+	return model.NewGenerationWithStream(func(yield func(model.Message) bool) {
+		for i := 0; i < len(text); i += size {
+			if !yield(model.NewTextMessage(model.Assistant, text[i:min(i+size, len(text))])) {
+				return
+			}
+		}
+	})
+}
+
+func TestStream(t *testing.T) {
+	var last Partial[article]
+	count := 0
+	for partial, err := range Stream[article](chunks(articleJSON, 7)) {
+		if err != nil {
+			t.Fatalf("Stream() unexpected error: %v", err)
+		}
+		last = partial
+		count++
+	}
+	if count < 2 || !last.Done || last.Value.Score != 4.5 {
+		t.Errorf("Stream() yielded %d partials, last = %+v", count, last)
+	}
+
+	var streamErr error
+	for _, err := range Stream[article](chunks(articleJSON[:20], 7)) {
+		streamErr = err
+	}
+	if !errors.Is(streamErr, ErrInvalidOutput) {
+		t.Errorf("Stream() truncated error = %v, want ErrInvalidOutput", streamErr)
+	}
+}
@@ -0,0 +1,193 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+
+	"nyxze/fayth/model"
+	"nyxze/fayth/vectorstore"
+)
+
+// Example is a solved input shown to the model as a few-shot demonstration.
+type Example struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// Selector picks the few-shot examples to show for an input.
+type Selector interface {
+	// Select returns the examples for input, in the order they should be shown.
+	Select(ctx context.Context, input string) ([]Example, error)
+}
+
+// ExampleMessages renders examples as alternating user and assistant messages.
+func ExampleMessages(examples []Example) []model.Message {
+	messages := make([]model.Message, 0, 2*len(examples))
+	for _, e := range examples {
+		messages = append(messages,
+			model.NewTextMessage(model.User, e.Input),
+			model.NewTextMessage(model.Assistant, e.Output),
+		)
+	}
+	return messages
+}
+
+// SelectMessages picks examples for input with s and renders them with [ExampleMessages],
+// ready to fill a [Placeholder] turn.
+func SelectMessages(ctx context.Context, s Selector, input string) ([]model.Message, error) {
+	examples, err := s.Select(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return ExampleMessages(examples), nil
+}
+
+// Fixed is a Selector always returning the same examples.
+type Fixed []Example
+
+// Compile type interface assertion
+var _ Selector = Fixed(nil)
+
+func (f Fixed) Select(context.Context, string) ([]Example, error) {
+	return f, nil
+}
+
+// SimilaritySelector picks the examples whose input is the most similar to the input,
+// optionally diversified with maximal marginal relevance.
+// Examples are embedded on the first selection.
+type SimilaritySelector struct {
+	examples []Example
+	embedder model.Embedder
+	k        int
+	// MMR parameters, disabled when fetchK is zero
+	lambda float32
+	fetchK int
+
+	mu    sync.Mutex
+	store *vectorstore.Memory
+}
+
+// Compile type interface assertion
+var _ Selector = (*SimilaritySelector)(nil)
+
+// SimilarityOption configures a [SimilaritySelector]
+type SimilarityOption func(*SimilaritySelector)
+
+// WithMMR selects k examples among the fetchK most similar ones with maximal marginal relevance.
+// lambda balances similarity (1) against diversity (0).
+func WithMMR(lambda float32, fetchK int) SimilarityOption {
+	return func(s *SimilaritySelector) {
+		s.lambda = lambda
+		s.fetchK = fetchK
+	}
+}
+
+// NewSimilaritySelector returns a Selector picking k examples by embedding similarity.
+func NewSimilaritySelector(examples []Example, embedder model.Embedder, k int, opts ...SimilarityOption) *SimilaritySelector {
+	s := &SimilaritySelector{
+		examples: examples,
+		embedder: embedder,
+		k:        k,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Select returns the selected examples, most similar first.
+func (s *SimilaritySelector) Select(ctx context.Context, input string) ([]Example, error) {
+	store, err := s.index(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := s.embedder.Embed(ctx, []string{input})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != 1 {
+		return nil, errors.New("prompt: embedder returned an unexpected number of vectors")
+	}
+
+	var matches []vectorstore.Match
+	if s.fetchK > s.k {
+		candidates, err := store.Query(ctx, vectors[0], s.fetchK)
+		if err != nil {
+			return nil, err
+		}
+		matches = vectorstore.MMR(vectors[0], candidates, s.k, s.lambda)
+	} else if matches, err = store.Query(ctx, vectors[0], s.k); err != nil {
+		return nil, err
+	}
+
+	out := make([]Example, len(matches))
+	for i, m := range matches {
+		n, _ := strconv.Atoi(m.ID)
+		out[i] = s.examples[n]
+	}
+	return out, nil
+}
+
+// index embeds the examples into an in-memory store once.
+func (s *SimilaritySelector) index(ctx context.Context) (*vectorstore.Memory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.store != nil {
+		return s.store, nil
+	}
+	inputs := make([]string, len(s.examples))
+	for i, e := range s.examples {
+		inputs[i] = e.Input
+	}
+	vectors, err := s.embedder.Embed(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != len(s.examples) {
+		return nil, errors.New("prompt: embedder returned an unexpected number of vectors")
+	}
+	store := vectorstore.NewMemory(vectorstore.Cosine)
+	records := make([]vectorstore.Record, len(s.examples))
+	for i, e := range s.examples {
+		records[i] = vectorstore.Record{ID: strconv.Itoa(i), Vector: vectors[i], Content: e.Input}
+	}
+	if err := store.Upsert(ctx, records...); err != nil {
+		return nil, err
+	}
+	s.store = store
+	return store, nil
+}
+
+// BudgetSelector keeps the leading examples picked by another Selector
+// while the input and the examples fit in a token budget.
+type BudgetSelector struct {
+	next      Selector
+	tokenizer model.Tokenizer
+	budget    int
+}
+
+// Compile type interface assertion
+var _ Selector = (*BudgetSelector)(nil)
+
+// NewBudgetSelector returns a Selector trimming the examples of next to budget tokens,
+// counted with tokenizer. Wrap [Fixed] examples to select by length alone.
+func NewBudgetSelector(next Selector, tokenizer model.Tokenizer, budget int) *BudgetSelector {
+	return &BudgetSelector{next: next, tokenizer: tokenizer, budget: budget}
+}
+
+func (b *BudgetSelector) Select(ctx context.Context, input string) ([]Example, error) {
+	examples, err := b.next.Select(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	remaining := b.budget - model.CountTokens(b.tokenizer, input)
+	for i, e := range examples {
+		remaining -= model.CountTokens(b.tokenizer, e.Input) + model.CountTokens(b.tokenizer, e.Output)
+		if remaining < 0 {
+			return examples[:i], nil
+		}
+	}
+	return examples, nil
+}
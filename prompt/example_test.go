@@ -0,0 +1,134 @@
+package prompt
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"nyxze/fayth/model"
+)
+
+// topicEmbedder embeds texts by counting a fixed vocabulary
+type topicEmbedder []string
+
+func (e topicEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		v := make([]float32, len(e)+1)
+		v[len(e)] = 0.01
+		for j, w := range e {
+			v[j] = float32(strings.Count(strings.ToLower(t), w))
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+var (
+	topics   = topicEmbedder{"refund", "money", "password", "login"}
+	examples = []Example{
+		{Input: "I want a refund", Output: "billing"},
+		{Input: "Refund my money please", Output: "billing"},
+		{Input: "I forgot my password", Output: "account"},
+		{Input: "Cannot login", Output: "account"},
+	}
+)
+
+func inputs(examples []Example) []string {
+	out := make([]string, len(examples))
+	for i, e := range examples {
+		out[i] = e.Input
+	}
+	return out
+}
+
+func TestSimilaritySelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector Selector
+		input    string
+		want     []string
+	}{
+		{
+			name:     "Similarity",
+			selector: NewSimilaritySelector(examples, topics, 2),
+			input:    "refund money",
+			want:     []string{"Refund my money please", "I want a refund"},
+		},
+		{
+			name:     "Other topic",
+			selector: NewSimilaritySelector(examples, topics, 1),
+			input:    "password reset",
+			want:     []string{"I forgot my password"},
+		},
+		{
+			name:     "MMR",
+			selector: NewSimilaritySelector(examples, topics, 2, WithMMR(0.3, 4)),
+			input:    "refund money",
+			want:     []string{"Refund my money please", "I forgot my password"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.selector.Select(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("Select() unexpected error: %v", err)
+			}
+			if !slices.Equal(inputs(got), tt.want) {
+				t.Errorf("Select() = %q, want %q", inputs(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestBudgetSelector(t *testing.T) {
+	tok := model.ApproxTokenizer{}
+	tests := []struct {
+		name   string
+		budget int
+		want   int
+	}{
+		{"All fit", 100, 4},
+		{"Some fit", 22, 2},
+		{"Over by one", 21, 1},
+		{"Input only", 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewBudgetSelector(Fixed(examples), tok, tt.budget).Select(context.Background(), "Where is my refund")
+			if err != nil {
+				t.Fatalf("Select() unexpected error: %v", err)
+			}
+			if !slices.Equal(got, examples[:tt.want]) {
+				t.Errorf("Select() = %q, want the first %d examples", inputs(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectMessages(t *testing.T) {
+	classify := Must(New("classify",
+		System("Classify the support request as billing or account."),
+		Placeholder("examples"),
+		User("{{.Request}}"),
+	))
+	selector := NewSimilaritySelector(examples, topics, 1)
+	shots, err := SelectMessages(context.Background(), selector, "Cannot login today")
+	if err != nil {
+		t.Fatalf("SelectMessages() unexpected error: %v", err)
+	}
+	messages, err := classify.Render(map[string]any{"examples": shots, "Request": "Cannot login today"})
+	if err != nil {
+		t.Fatalf("Render() unexpected error: %v", err)
+	}
+	want := []rendered{
+		{model.System, "Classify the support request as billing or account."},
+		{model.User, "Cannot login"},
+		{model.Assistant, "account"},
+		{model.User, "Cannot login today"},
+	}
+	if got := flatten(messages); !slices.Equal(got, want) {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,226 @@
+// Package prompt builds chat conversations from [text/template] sources,
+// validating the variables they are rendered with.
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+
+	"nyxze/fayth/model"
+)
+
+// Errors
+var (
+	ErrMissingVariable = errors.New("prompt: missing variables")
+	ErrExtraVariable   = errors.New("prompt: unexpected variables")
+	ErrInvalidInput    = errors.New("prompt: input must be a struct or a map with string keys")
+)
+
+// Turn describes one message of a chat template.
+type Turn struct {
+	Role model.Role
+	// Text is the template source of the message text
+	Text string
+	// Placeholder names a variable holding messages spliced into the conversation, such as a history.
+	// Text is ignored when set.
+	Placeholder string
+}
+
+// System returns a system message turn rendered from text.
+func System(text string) Turn { return Turn{Role: model.System, Text: text} }
+
+// User returns a user message turn rendered from text.
+func User(text string) Turn { return Turn{Role: model.User, Text: text} }
+
+// Assistant returns an assistant message turn rendered from text, typically a few-shot answer.
+func Assistant(text string) Turn { return Turn{Role: model.Assistant, Text: text} }
+
+// Placeholder returns a turn replaced by the []model.Message or model.Message held by the variable name.
+func Placeholder(name string) Turn { return Turn{Placeholder: name} }
+
+// Template is a parsed chat template. It is immutable and safe for concurrent use.
+type Template struct {
+	name     string
+	version  string
+	turns    []turn
+	partials map[string]any
+	// vars holds the variables referenced by the turns, placeholders included
+	vars []string
+}
+
+type turn struct {
+	role        model.Role
+	tmpl        *template.Template
+	placeholder string
+}
+
+// New parses turns into a Template named name.
+func New(name string, turns ...Turn) (*Template, error) {
+	t := &Template{name: name, turns: make([]turn, len(turns))}
+	vars := make(map[string]bool)
+	for i, spec := range turns {
+		if spec.Placeholder != "" {
+			t.turns[i] = turn{placeholder: spec.Placeholder}
+			vars[spec.Placeholder] = true
+			continue
+		}
+		tmpl, err := template.New(fmt.Sprintf("%s[%d]", name, i)).Option("missingkey=error").Parse(spec.Text)
+		if err != nil {
+			return nil, err
+		}
+		t.turns[i] = turn{role: spec.Role, tmpl: tmpl}
+		collectVariables(tmpl, vars)
+	}
+	t.vars = slices.Sorted(maps.Keys(vars))
+	return t, nil
+}
+
+// Must panics when err is not nil, for templates declared as package variables.
+func Must(t *Template, err error) *Template {
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Name returns the name of the template.
+func (t *Template) Name() string { return t.name }
+
+// Version returns the version of the template, empty when it was not loaded from a versioned file.
+func (t *Template) Version() string { return t.version }
+
+// Variables returns the sorted names of the variables referenced by the template.
+func (t *Template) Variables() []string { return slices.Clone(t.vars) }
+
+// Partial returns a copy of t with some variables already bound, the rendering input
+// then only provides the remaining ones. A value of type func() string is called on every render,
+// such as for the current date.
+func (t *Template) Partial(vars map[string]any) *Template {
+	c := *t
+	c.partials = maps.Clone(t.partials)
+	if c.partials == nil {
+		c.partials = make(map[string]any, len(vars))
+	}
+	maps.Copy(c.partials, vars)
+	return &c
+}
+
+// Render executes the template with input, a struct or a map with string keys,
+// and returns the resulting messages. Message texts are trimmed of surrounding whitespace.
+//
+// Struct fields are named after their `prompt` tag, or their name when untagged.
+// Render fails with [ErrMissingVariable] when a variable is not provided and with
+// [ErrExtraVariable] when input holds values the template never uses.
+func (t *Template) Render(input any) ([]model.Message, error) {
+	values, err := toValues(input)
+	if err != nil {
+		return nil, err
+	}
+	var extra []string
+	for name := range values {
+		if !slices.Contains(t.vars, name) {
+			extra = append(extra, name)
+		}
+	}
+	if len(extra) > 0 {
+		slices.Sort(extra)
+		return nil, fmt.Errorf("%w: %s in template %q", ErrExtraVariable, strings.Join(extra, ", "), t.name)
+	}
+
+	data := make(map[string]any, len(t.vars))
+	for name, v := range t.partials {
+		if f, ok := v.(func() string); ok {
+			v = f()
+		}
+		data[name] = v
+	}
+	maps.Copy(data, values)
+	var missing []string
+	for _, name := range t.vars {
+		if _, ok := data[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s in template %q", ErrMissingVariable, strings.Join(missing, ", "), t.name)
+	}
+
+	var messages []model.Message
+	for _, tu := range t.turns {
+		if tu.placeholder != "" {
+			switch v := data[tu.placeholder].(type) {
+			case []model.Message:
+				messages = append(messages, v...)
+			case model.Message:
+				messages = append(messages, v)
+			default:
+				return nil, fmt.Errorf("prompt: placeholder %q holds %T, not messages", tu.placeholder, v)
+			}
+			continue
+		}
+		var sb strings.Builder
+		if err := tu.tmpl.Execute(&sb, data); err != nil {
+			return nil, err
+		}
+		messages = append(messages, model.NewTextMessage(tu.role, strings.TrimSpace(sb.String())))
+	}
+	return messages, nil
+}
+
+// toValues returns the variables held by input.
+func toValues(input any) (map[string]any, error) {
+	values := make(map[string]any)
+	if input == nil {
+		return values, nil
+	}
+	v := reflect.ValueOf(input)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return values, nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, ErrInvalidInput
+		}
+		for iter := v.MapRange(); iter.Next(); {
+			values[iter.Key().String()] = iter.Value().Interface()
+		}
+	case reflect.Struct:
+		for i, f := range fields(v.Type()) {
+			if f != "" {
+				values[f] = v.Field(i).Interface()
+			}
+		}
+	default:
+		return nil, ErrInvalidInput
+	}
+	return values, nil
+}
+
+// fields returns the variable name of every field of the struct type t,
+// empty for unexported fields and fields tagged `prompt:"-"`.
+func fields(t reflect.Type) []string {
+	names := make([]string, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		switch tag := f.Tag.Get("prompt"); tag {
+		case "-":
+		case "":
+			names[i] = f.Name
+		default:
+			names[i] = tag
+		}
+	}
+	return names
+}
@@ -0,0 +1,269 @@
+package prompt
+
+import (
+	"embed"
+	"errors"
+	"slices"
+	"testing"
+
+	"nyxze/fayth/model"
+)
+
+//go:embed testdata
+var testdata embed.FS
+
+type rendered struct {
+	role model.Role
+	text string
+}
+
+func flatten(messages []model.Message) []rendered {
+	out := make([]rendered, len(messages))
+	for i, m := range messages {
+		out[i] = rendered{m.Role, m.Text()}
+	}
+	return out
+}
+
+var qa = Must(New("qa",
+	System("You are a {{.Persona}}."),
+	Placeholder("history"),
+	User("{{if .Context}}Context: {{.Context}}\n{{end}}{{.Question}}"),
+))
+
+func TestTemplate_Variables(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"Fields", "{{.A}} and {{.B.C}}", []string{"A", "B"}},
+		{"Range", "{{range .Items}}{{.Name}} {{$.Sep}}{{end}}", []string{"Items", "Sep"}},
+		{"With else", "{{with .User}}{{.Name}}{{else}}{{.Anonymous}}{{end}}", []string{"Anonymous", "User"}},
+		{"Functions", `{{printf "%s-%s" .A (index .B 0)}}`, []string{"A", "B"}},
+		{"Define", `{{define "x"}}{{.Inner}}{{end}}{{template "x" .}}{{template "x" .Other}}`, []string{"Inner", "Other"}},
+		{"None", "Hello", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := New(tt.name, User(tt.text))
+			if err != nil {
+				t.Fatalf("New() unexpected error: %v", err)
+			}
+			if got := tmpl.Variables(); !slices.Equal(got, tt.want) {
+				t.Errorf("Variables() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplate_Render(t *testing.T) {
+	history := []model.Message{
+		model.NewTextMessage(model.User, "Hi"),
+		model.NewTextMessage(model.Assistant, "Hello!"),
+	}
+	tests := []struct {
+		name    string
+		tmpl    *Template
+		input   any
+		want    []rendered
+		wantErr error
+	}{
+		{
+			name:  "Map",
+			tmpl:  qa,
+			input: map[string]any{"Persona": "tutor", "history": history, "Context": "", "Question": "Why?"},
+			want: []rendered{
+				{model.System, "You are a tutor."},
+				{model.User, "Hi"},
+				{model.Assistant, "Hello!"},
+				{model.User, "Why?"},
+			},
+		},
+		{
+			name: "Struct",
+			tmpl: qa,
+			input: struct {
+				Persona  string
+				History  []model.Message `prompt:"history"`
+				Context  string
+				Question string
+				internal int
+			}{"guide", history[:1], "Paris", "Where?", 0},
+			want: []rendered{
+				{model.System, "You are a guide."},
+				{model.User, "Hi"},
+				{model.User, "Context: Paris\nWhere?"},
+			},
+		},
+		{
+			name:  "Partial",
+			tmpl:  qa.Partial(map[string]any{"Persona": "bot", "history": []model.Message{}, "Context": func() string { return "now" }}),
+			input: map[string]string{"Question": "When?"},
+			want: []rendered{
+				{model.System, "You are a bot."},
+				{model.User, "Context: now\nWhen?"},
+			},
+		},
+		{
+			name:    "Missing",
+			tmpl:    qa,
+			input:   map[string]any{"Persona": "tutor", "Question": "Why?"},
+			wantErr: ErrMissingVariable,
+		},
+		{
+			name:    "Extra",
+			tmpl:    qa,
+			input:   map[string]any{"Persona": "tutor", "history": history, "Context": "", "Question": "Why?", "Tone": "dry"},
+			wantErr: ErrExtraVariable,
+		},
+		{
+			name:    "Invalid input",
+			tmpl:    qa,
+			input:   []string{"Why?"},
+			wantErr: ErrInvalidInput,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := tt.tmpl.Render(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Render() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := flatten(messages); !slices.Equal(got, tt.want) {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := qa.Render(map[string]any{"Persona": "tutor", "history": "none", "Context": "", "Question": "Why?"}); err == nil {
+		t.Error("Render() expected an error for a placeholder without messages")
+	}
+}
+
+type qaInput struct {
+	Persona  string
+	History  []model.Message `prompt:"history"`
+	Context  string
+	Question string
+}
+
+func TestBind(t *testing.T) {
+	typed, err := Bind[qaInput](qa)
+	if err != nil {
+		t.Fatalf("Bind() unexpected error: %v", err)
+	}
+	messages, err := typed.Render(qaInput{Persona: "tutor", Question: "Why?"})
+	if err != nil {
+		t.Fatalf("Render() unexpected error: %v", err)
+	}
+	if len(messages) != 2 || messages[1].Text() != "Why?" {
+		t.Errorf("Render() = %q", flatten(messages))
+	}
+
+	type partialInput struct {
+		Question string
+		Context  string `prompt:"Context"`
+		Debug    bool   `prompt:"-"`
+	}
+	if _, err := Bind[partialInput](qa); !errors.Is(err, ErrMissingVariable) {
+		t.Errorf("Bind() error = %v, want ErrMissingVariable", err)
+	}
+	if _, err := Bind[partialInput](qa.Partial(map[string]any{"Persona": "bot", "history": []model.Message{}})); err != nil {
+		t.Errorf("Bind() with partials unexpected error: %v", err)
+	}
+	if _, err := Bind[struct {
+		Persona, Context, Question, Tone string
+		History                          []model.Message `prompt:"history"`
+	}](qa); !errors.Is(err, ErrExtraVariable) {
+		t.Errorf("Bind() error = %v, want ErrExtraVariable", err)
+	}
+	if _, err := Bind[string](qa); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Bind() error = %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr bool
+	}{
+		{"Valid", "-- system --\nBe brief.\n-- user --\n{{.Q}}\n", false},
+		{"Text before turns", "Hello\n-- user --\n{{.Q}}\n", true},
+		{"Unknown role", "-- tool --\nresult\n", true},
+		{"Placeholder with text", "-- placeholder history --\nHello\n", true},
+		{"Empty", "\n\n", true},
+		{"Invalid template", "-- user --\n{{.Q\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.name, tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	registry, err := Load(testdata, "testdata")
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if got := registry.Versions("qa"); !slices.Equal(got, []string{"v1", "v2", "v10"}) {
+		t.Errorf("Versions() = %v, want [v1 v2 v10]", got)
+	}
+
+	latest, err := registry.Get("qa")
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if latest.Name() != "qa" || latest.Version() != "v10" {
+		t.Errorf("Get() = %s@%s, want qa@v10", latest.Name(), latest.Version())
+	}
+	if want := []string{"Date", "Language", "Persona", "Question", "history"}; !slices.Equal(latest.Variables(), want) {
+		t.Errorf("Variables() = %v, want %v", latest.Variables(), want)
+	}
+	messages, err := latest.Partial(map[string]any{"Date": func() string { return "Monday" }}).Render(map[string]any{
+		"Persona": "tutor", "Language": "French", "Question": "Pourquoi ?", "history": []model.Message{},
+	})
+	if err != nil {
+		t.Fatalf("Render() unexpected error: %v", err)
+	}
+	want := []rendered{
+		{model.System, "You are a tutor.\nAnswer in French, today is Monday."},
+		{model.User, "Pourquoi ?"},
+	}
+	if got := flatten(messages); !slices.Equal(got, want) {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	v1, err := registry.GetVersion("qa", "v1")
+	if err != nil || !slices.Equal(v1.Variables(), []string{"Question"}) {
+		t.Errorf("GetVersion() = %v, %v", v1, err)
+	}
+	summarize, err := registry.Get("summarize")
+	if err != nil || summarize.Version() != "" {
+		t.Fatalf("Get() = %v, %v", summarize, err)
+	}
+	messages, _ = summarize.Render(map[string]any{"Points": []string{"a", "b"}})
+	if got := messages[0].Text(); got != "Summarize these points:\n- a\n- b" {
+		t.Errorf("Render() = %q", got)
+	}
+
+	if _, err := registry.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+	if _, err := registry.GetVersion("qa", "v3"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetVersion() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	versions := []string{"v10", "", "1.2", "v2", "1.10", "beta"}
+	slices.SortFunc(versions, compareVersions)
+	if want := []string{"", "1.2", "1.10", "v2", "v10", "beta"}; !slices.Equal(versions, want) {
+		t.Errorf("sorted versions = %q, want %q", versions, want)
+	}
+}
@@ -0,0 +1,169 @@
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"nyxze/fayth/model"
+)
+
+// Ext is the extension of template files loaded by [Load].
+const Ext = ".prompt"
+
+// Errors
+var (
+	ErrNotFound      = errors.New("prompt: template not found")
+	ErrInvalidFormat = errors.New("prompt: invalid template file")
+)
+
+// Parse parses a template file. Each turn starts with a marker line naming its role,
+// and placeholders name their variable:
+//
+//	-- system --
+//	You are a {{.Persona}}.
+//	-- placeholder history --
+//	-- user --
+//	{{.Question}}
+func Parse(name, src string) (*Template, error) {
+	var (
+		turns   []Turn
+		current *Turn
+		body    strings.Builder
+	)
+	flush := func() {
+		if current != nil && current.Placeholder == "" {
+			current.Text = body.String()
+			turns = append(turns, *current)
+		}
+		body.Reset()
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(src))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		marker, ok := strings.CutPrefix(strings.TrimSpace(text), "-- ")
+		if ok {
+			marker, ok = strings.CutSuffix(marker, " --")
+		}
+		if !ok {
+			if current == nil && strings.TrimSpace(text) != "" {
+				return nil, fmt.Errorf("%w: %s:%d: text before the first turn", ErrInvalidFormat, name, line)
+			}
+			if current != nil && current.Placeholder != "" && strings.TrimSpace(text) != "" {
+				return nil, fmt.Errorf("%w: %s:%d: text in a placeholder turn", ErrInvalidFormat, name, line)
+			}
+			body.WriteString(text)
+			body.WriteByte('\n')
+			continue
+		}
+
+		flush()
+		switch fields := strings.Fields(marker); {
+		case len(fields) == 2 && fields[0] == "placeholder":
+			turns = append(turns, Placeholder(fields[1]))
+			current = &Turn{Placeholder: fields[1]}
+		case len(fields) == 1 && slices.Contains([]model.Role{model.System, model.User, model.Assistant}, model.Role(fields[0])):
+			current = &Turn{Role: model.Role(fields[0])}
+		default:
+			return nil, fmt.Errorf("%w: %s:%d: unknown turn %q", ErrInvalidFormat, name, line, marker)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	if len(turns) == 0 {
+		return nil, fmt.Errorf("%w: %s: no turns", ErrInvalidFormat, name)
+	}
+	return New(name, turns...)
+}
+
+// Registry holds versioned templates by name.
+type Registry struct {
+	// templates holds the versions of each template, oldest first
+	templates map[string][]*Template
+}
+
+// Load parses the template files of dir in fsys, typically an [embed.FS].
+// Files are named "<name>@<version>.prompt", or "<name>.prompt" for unversioned templates.
+func Load(fsys fs.FS, dir string) (*Registry, error) {
+	files, err := fs.Glob(fsys, path.Join(dir, "*"+Ext))
+	if err != nil {
+		return nil, err
+	}
+	r := &Registry{templates: make(map[string][]*Template)}
+	for _, file := range files {
+		src, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		name, version, _ := strings.Cut(strings.TrimSuffix(path.Base(file), Ext), "@")
+		t, err := Parse(name, string(src))
+		if err != nil {
+			return nil, err
+		}
+		t.version = version
+		r.templates[name] = append(r.templates[name], t)
+	}
+	for _, versions := range r.templates {
+		slices.SortFunc(versions, func(a, b *Template) int {
+			return compareVersions(a.version, b.version)
+		})
+	}
+	return r, nil
+}
+
+// Get returns the latest version of the template name.
+func (r *Registry) Get(name string) (*Template, error) {
+	versions := r.templates[name]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	return versions[len(versions)-1], nil
+}
+
+// GetVersion returns the given version of the template name.
+func (r *Registry) GetVersion(name, version string) (*Template, error) {
+	for _, t := range r.templates[name] {
+		if t.version == version {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q version %q", ErrNotFound, name, version)
+}
+
+// Versions returns the versions of the template name, oldest first.
+func (r *Registry) Versions(name string) []string {
+	var out []string
+	for _, t := range r.templates[name] {
+		out = append(out, t.version)
+	}
+	return out
+}
+
+// compareVersions orders versions such as "v2" and "1.10" by their dot separated numeric
+// components, comparing non-numeric components lexically.
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := range min(len(as), len(bs)) {
+		an, aerr := strconv.Atoi(as[i])
+		bn, berr := strconv.Atoi(bs[i])
+		if aerr == nil && berr == nil {
+			if an != bn {
+				return an - bn
+			}
+			continue
+		}
+		if c := strings.Compare(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return len(as) - len(bs)
+}
@@ -0,0 +1,65 @@
+package prompt
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"nyxze/fayth/model"
+)
+
+// Typed is a [Template] rendered from inputs of the struct type T,
+// whose fields were checked against the template variables when binding.
+type Typed[T any] struct {
+	template *Template
+}
+
+// Bind checks that the fields of T provide every variable of t not bound as a partial,
+// and that T has no field the template never uses.
+func Bind[T any](t *Template) (*Typed[T], error) {
+	typ := reflect.TypeFor[T]()
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("prompt: cannot bind %s: %w", typ, ErrInvalidInput)
+	}
+	provided := fields(typ)
+
+	var missing, extra []string
+	for _, name := range t.vars {
+		if _, partial := t.partials[name]; !partial && !slices.Contains(provided, name) {
+			missing = append(missing, name)
+		}
+	}
+	for _, name := range provided {
+		if name != "" && !slices.Contains(t.vars, name) {
+			extra = append(extra, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s not provided by %s", ErrMissingVariable, strings.Join(missing, ", "), typ)
+	}
+	if len(extra) > 0 {
+		return nil, fmt.Errorf("%w: %s of %s not used by template %q", ErrExtraVariable, strings.Join(extra, ", "), typ, t.name)
+	}
+	return &Typed[T]{template: t}, nil
+}
+
+// MustBind is like [Bind] but panics on error.
+func MustBind[T any](t *Template) *Typed[T] {
+	typed, err := Bind[T](t)
+	if err != nil {
+		panic(err)
+	}
+	return typed
+}
+
+// Template returns the bound template.
+func (t *Typed[T]) Template() *Template { return t.template }
+
+// Render executes the template with input.
+func (t *Typed[T]) Render(input T) ([]model.Message, error) {
+	return t.template.Render(input)
+}
@@ -0,0 +1,89 @@
+package prompt
+
+import (
+	"text/template"
+	"text/template/parse"
+)
+
+// collectVariables adds to vars the top-level fields of the data tmpl is executed with,
+// such as Question for {{.Question}} or {{$.Question}}.
+// Fields read inside range and with blocks belong to another value and are skipped.
+func collectVariables(tmpl *template.Template, vars map[string]bool) {
+	w := walker{tmpl: tmpl, vars: vars, seen: make(map[string]bool)}
+	w.node(tmpl.Tree.Root, true)
+}
+
+type walker struct {
+	tmpl *template.Template
+	vars map[string]bool
+	// seen guards against recursive template invocations
+	seen map[string]bool
+}
+
+// node walks n, root reporting whether the dot is the template data.
+func (w walker) node(n parse.Node, root bool) {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			w.node(c, root)
+		}
+	case *parse.ActionNode:
+		w.pipe(n.Pipe, root)
+	case *parse.IfNode:
+		w.pipe(n.Pipe, root)
+		w.node(n.List, root)
+		w.node(n.ElseList, root)
+	case *parse.RangeNode:
+		w.pipe(n.Pipe, root)
+		w.node(n.List, false)
+		w.node(n.ElseList, root)
+	case *parse.WithNode:
+		w.pipe(n.Pipe, root)
+		w.node(n.List, false)
+		w.node(n.ElseList, root)
+	case *parse.TemplateNode:
+		w.pipe(n.Pipe, root)
+		called := w.tmpl.Lookup(n.Name)
+		if called == nil || called.Tree == nil || w.seen[n.Name] {
+			return
+		}
+		w.seen[n.Name] = true
+		w.node(called.Tree.Root, root && isDot(n.Pipe))
+	case *parse.PipeNode:
+		w.pipe(n, root)
+	case *parse.ChainNode:
+		w.node(n.Node, root)
+	case *parse.FieldNode:
+		if root {
+			w.vars[n.Ident[0]] = true
+		}
+	case *parse.VariableNode:
+		// $ is always the template data
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			w.vars[n.Ident[1]] = true
+		}
+	}
+}
+
+func (w walker) pipe(p *parse.PipeNode, root bool) {
+	if p == nil {
+		return
+	}
+	for _, cmd := range p.Cmds {
+		for _, arg := range cmd.Args {
+			w.node(arg, root)
+		}
+	}
+}
+
+// isDot reports whether p passes the dot unchanged, as in {{template "name" .}}.
+func isDot(p *parse.PipeNode) bool {
+	if p == nil || len(p.Cmds) != 1 || len(p.Cmds[0].Args) != 1 {
+		return false
+	}
+	_, ok := p.Cmds[0].Args[0].(*parse.DotNode)
+	return ok
+}
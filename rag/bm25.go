@@ -0,0 +1,233 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+
+	"nyxze/fayth/vectorstore"
+)
+
+// Analyzer turns a text into the terms indexed and searched by [BM25].
+type Analyzer func(text string) []string
+
+// DefaultAnalyzer lowercases text and splits it on anything but letters, digits and underscores,
+// keeping identifiers such as error codes and snake_case names whole.
+func DefaultAnalyzer(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
+
+// BM25 is an in-process inverted index ranking chunks with the Okapi BM25 function.
+// It is safe for concurrent use.
+//
+// See https://en.wikipedia.org/wiki/Okapi_BM25
+type BM25 struct {
+	mu       sync.RWMutex
+	analyzer Analyzer
+	k1, b    float64
+
+	docs     map[string]*bm25Doc
+	postings map[string]map[string]int // term -> chunk id -> term frequency
+	totalLen int
+}
+
+type bm25Doc struct {
+	chunk  Chunk
+	length int
+	terms  map[string]int
+}
+
+// Compile type interface assertion
+var _ Retriever = (*BM25)(nil)
+
+// BM25Option configures a [BM25] index
+type BM25Option func(*BM25)
+
+// WithAnalyzer sets the analyzer producing terms. The default is [DefaultAnalyzer].
+func WithAnalyzer(a Analyzer) BM25Option {
+	return func(idx *BM25) {
+		idx.analyzer = a
+	}
+}
+
+// WithBM25Params sets the term frequency saturation k1 and length normalization b.
+// The defaults are 1.2 and 0.75.
+func WithBM25Params(k1, b float64) BM25Option {
+	return func(idx *BM25) {
+		idx.k1, idx.b = k1, b
+	}
+}
+
+// NewBM25 returns an empty BM25 index.
+func NewBM25(opts ...BM25Option) *BM25 {
+	idx := &BM25{
+		analyzer: DefaultAnalyzer,
+		k1:       1.2,
+		b:        0.75,
+		docs:     make(map[string]*bm25Doc),
+		postings: make(map[string]map[string]int),
+	}
+	for _, opt := range opts {
+		opt(idx)
+	}
+	return idx
+}
+
+// Add indexes chunks, replacing chunks with the same ID.
+func (idx *BM25) Add(chunks ...Chunk) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, c := range chunks {
+		idx.remove(c.ID)
+		terms := idx.analyzer(c.Content)
+		doc := &bm25Doc{
+			chunk:  Chunk{ID: c.ID, Content: c.Content, Metadata: maps.Clone(c.Metadata)},
+			length: len(terms),
+			terms:  make(map[string]int),
+		}
+		for _, t := range terms {
+			doc.terms[t]++
+		}
+		for t, tf := range doc.terms {
+			if idx.postings[t] == nil {
+				idx.postings[t] = make(map[string]int)
+			}
+			idx.postings[t][c.ID] = tf
+		}
+		idx.docs[c.ID] = doc
+		idx.totalLen += doc.length
+	}
+}
+
+// Delete removes the chunks with the given IDs, ignoring unknown ones.
+func (idx *BM25) Delete(ids ...string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, id := range ids {
+		idx.remove(id)
+	}
+}
+
+func (idx *BM25) remove(id string) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for t := range doc.terms {
+		delete(idx.postings[t], id)
+		if len(idx.postings[t]) == 0 {
+			delete(idx.postings, t)
+		}
+	}
+	idx.totalLen -= doc.length
+	delete(idx.docs, id)
+}
+
+// Len returns the number of indexed chunks.
+func (idx *BM25) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Retrieve returns at most k chunks containing query terms, highest BM25 score first.
+func (idx *BM25) Retrieve(_ context.Context, query string, k int) ([]vectorstore.Match, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	if k <= 0 || len(idx.docs) == 0 {
+		return nil, nil
+	}
+	n := float64(len(idx.docs))
+	avgLen := float64(idx.totalLen) / n
+
+	scores := make(map[string]float64)
+	seen := make(map[string]bool)
+	for _, t := range idx.analyzer(query) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		postings := idx.postings[t]
+		df := float64(len(postings))
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range postings {
+			norm := idx.k1 * (1 - idx.b + idx.b*float64(idx.docs[id].length)/avgLen)
+			scores[id] += idf * float64(tf) * (idx.k1 + 1) / (float64(tf) + norm)
+		}
+	}
+
+	matches := make([]vectorstore.Match, 0, len(scores))
+	for id, score := range scores {
+		c := idx.docs[id].chunk
+		matches = append(matches, vectorstore.Match{
+			Record: vectorstore.Record{ID: c.ID, Content: c.Content, Metadata: maps.Clone(c.Metadata)},
+			Score:  float32(score),
+		})
+	}
+	vectorstore.SortMatches(matches)
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+// bm25File is the on-disk representation of an index, terms are rebuilt on load.
+type bm25File struct {
+	K1     float64 `json:"k1"`
+	B      float64 `json:"b"`
+	Chunks []Chunk `json:"chunks"`
+}
+
+// Save writes the indexed chunks as JSON to path, replacing the file atomically.
+func (idx *BM25) Save(path string) error {
+	idx.mu.RLock()
+	file := bm25File{K1: idx.k1, B: idx.b, Chunks: make([]Chunk, 0, len(idx.docs))}
+	for _, d := range idx.docs {
+		file.Chunks = append(file.Chunks, d.chunk)
+	}
+	idx.mu.RUnlock()
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadBM25 reads an index saved with [BM25.Save], terms are rebuilt with the configured analyzer.
+func LoadBM25(path string, opts ...BM25Option) (*BM25, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file bm25File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	idx := NewBM25(append([]BM25Option{WithBM25Params(file.K1, file.B)}, opts...)...)
+	idx.Add(file.Chunks...)
+	return idx, nil
+}
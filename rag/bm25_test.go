@@ -0,0 +1,190 @@
+package rag
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"nyxze/fayth/model"
+	"nyxze/fayth/vectorstore"
+)
+
+var bm25Chunks = []Chunk{
+	{ID: "conn", Content: "The client failed with ERR_CONN_RESET while dialing.", Metadata: map[string]string{"source": "errors.md"}},
+	{ID: "timeout", Content: "A timeout error is returned when the server is slow to answer."},
+	{ID: "retry", Content: "Retry the request after a connection error, the server may be restarting."},
+	{ID: "gopher", Content: "The Go gopher is the Go mascot."},
+}
+
+func ids(matches []vectorstore.Match) []string {
+	out := make([]string, len(matches))
+	for i, m := range matches {
+		out[i] = m.ID
+	}
+	return out
+}
+
+func TestBM25_Retrieve(t *testing.T) {
+	idx := NewBM25()
+	idx.Add(bm25Chunks...)
+
+	tests := []struct {
+		name  string
+		query string
+		k     int
+		want  []string
+	}{
+		{"Identifier", "err_conn_reset", 3, []string{"conn"}},
+		{"Ranked", "server timeout error", 3, []string{"timeout", "retry"}},
+		{"Limit", "server timeout error", 1, []string{"timeout"}},
+		{"Term frequency", "go", 3, []string{"gopher"}},
+		{"Unknown terms", "kubernetes", 3, []string{}},
+		{"No results", "server", 0, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := idx.Retrieve(context.Background(), tt.query, tt.k)
+			if err != nil {
+				t.Fatalf("Retrieve() unexpected error: %v", err)
+			}
+			if got := ids(matches); !slices.Equal(got, tt.want) {
+				t.Errorf("Retrieve(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	matches, _ := idx.Retrieve(context.Background(), "ERR_CONN_RESET", 1)
+	if matches[0].Metadata["source"] != "errors.md" || matches[0].Score <= 0 {
+		t.Errorf("Retrieve() match = %+v, want metadata and a positive score", matches[0])
+	}
+}
+
+func TestBM25_AddDelete(t *testing.T) {
+	idx := NewBM25()
+	idx.Add(bm25Chunks...)
+	idx.Add(Chunk{ID: "gopher", Content: "Gophers dig tunnels."})
+	idx.Delete("timeout", "missing")
+
+	if idx.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", idx.Len())
+	}
+	if matches, _ := idx.Retrieve(context.Background(), "mascot", 3); len(matches) != 0 {
+		t.Errorf("Retrieve() returned replaced content: %v", ids(matches))
+	}
+	if matches, _ := idx.Retrieve(context.Background(), "timeout", 3); len(matches) != 0 {
+		t.Errorf("Retrieve() returned deleted chunk: %v", ids(matches))
+	}
+	if matches, _ := idx.Retrieve(context.Background(), "tunnels", 3); !slices.Equal(ids(matches), []string{"gopher"}) {
+		t.Errorf("Retrieve() = %v, want [gopher]", ids(matches))
+	}
+}
+
+func TestBM25_Analyzer(t *testing.T) {
+	// Character trigrams match partial words
+	trigrams := func(text string) []string {
+		var out []string
+		for _, w := range DefaultAnalyzer(text) {
+			for i := 0; i+3 <= len(w); i++ {
+				out = append(out, w[i:i+3])
+			}
+		}
+		return out
+	}
+	idx := NewBM25(WithAnalyzer(trigrams))
+	idx.Add(bm25Chunks...)
+
+	matches, _ := idx.Retrieve(context.Background(), "restart", 1)
+	if !slices.Equal(ids(matches), []string{"retry"}) {
+		t.Errorf("Retrieve() = %v, want [retry]", ids(matches))
+	}
+}
+
+func TestBM25_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	idx := NewBM25(WithBM25Params(1.5, 0.5))
+	idx.Add(bm25Chunks...)
+	if err := idx.Save(path); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+
+	loaded, err := LoadBM25(path)
+	if err != nil {
+		t.Fatalf("LoadBM25() unexpected error: %v", err)
+	}
+	if loaded.k1 != 1.5 || loaded.b != 0.5 {
+		t.Errorf("LoadBM25() params = %v, %v, want 1.5, 0.5", loaded.k1, loaded.b)
+	}
+	want, _ := idx.Retrieve(context.Background(), "server error", 4)
+	got, _ := loaded.Retrieve(context.Background(), "server error", 4)
+	if !slices.EqualFunc(got, want, func(a, b vectorstore.Match) bool {
+		return a.ID == b.ID && a.Score == b.Score && a.Content == b.Content
+	}) {
+		t.Errorf("loaded Retrieve() = %v, want %v", got, want)
+	}
+
+	if _, err := LoadBM25(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadBM25() expected an error for a missing file")
+	}
+}
+
+// staticRetriever returns a fixed ranking
+type staticRetriever []string
+
+func (s staticRetriever) Retrieve(_ context.Context, _ string, k int) ([]vectorstore.Match, error) {
+	var out []vectorstore.Match
+	for i, id := range s[:min(k, len(s))] {
+		out = append(out, vectorstore.Match{Record: vectorstore.Record{ID: id, Content: strings.ToUpper(id)}, Score: float32(100 - i)})
+	}
+	return out, nil
+}
+
+func TestHybrid_Retrieve(t *testing.T) {
+	tests := []struct {
+		name    string
+		lists   []Retriever
+		options []HybridOption
+		k       int
+		want    []string
+	}{
+		{"Agreement wins", []Retriever{staticRetriever{"a", "b", "c"}, staticRetriever{"d", "b", "c"}}, nil, 4, []string{"b", "c", "a", "d"}},
+		{"Limit", []Retriever{staticRetriever{"a", "b", "c"}, staticRetriever{"d", "b", "c"}}, nil, 1, []string{"b"}},
+		{"Weights", []Retriever{staticRetriever{"a", "b"}, staticRetriever{"b", "a"}}, []HybridOption{WithWeights(1, 0.5)}, 2, []string{"a", "b"}},
+		{"Single", []Retriever{staticRetriever{"x", "y"}}, nil, 2, []string{"x", "y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := NewHybrid(tt.lists, tt.options...).Retrieve(context.Background(), "", tt.k)
+			if err != nil {
+				t.Fatalf("Retrieve() unexpected error: %v", err)
+			}
+			if got := ids(matches); !slices.Equal(got, tt.want) {
+				t.Errorf("Retrieve() = %v, want %v", got, tt.want)
+			}
+			if matches[0].Content != strings.ToUpper(matches[0].ID) {
+				t.Errorf("Retrieve() lost the record content: %+v", matches[0])
+			}
+		})
+	}
+}
+
+func TestHybrid_Pipeline(t *testing.T) {
+	idx := NewBM25()
+	idx.Add(
+		Chunk{ID: "fr", Content: "Paris is the capital of France.", Metadata: map[string]string{"source": "geo.md"}},
+		Chunk{ID: "go", Content: "The Go gopher is the Go mascot.", Metadata: map[string]string{"source": "go.md"}},
+	)
+	hybrid := NewHybrid([]Retriever{idx, NewVectorRetriever(newStore(t), vocabulary)})
+	llm := &recordingModel{}
+
+	_, citations, err := New(llm, hybrid, WithTopK(2)).Augment(context.Background(), []model.Message{
+		model.NewTextMessage(model.User, "What is the capital of France?"),
+	})
+	if err != nil {
+		t.Fatalf("Augment() unexpected error: %v", err)
+	}
+	if len(citations) != 2 || citations[0].ID != "fr" || citations[0].Source != "geo.md" {
+		t.Errorf("Augment() citations = %+v, want fr from geo.md first", citations)
+	}
+}
@@ -0,0 +1,115 @@
+package rag
+
+import (
+	"context"
+
+	"nyxze/fayth/vectorstore"
+)
+
+// Hybrid fuses the results of several retrievers, typically a [BM25] index and a
+// [VectorRetriever], with reciprocal rank fusion.
+//
+// Each match scores the sum of weight/(c+rank) over the retrievers returning it,
+// so only ranks matter and retrievers with unrelated score scales can be combined.
+type Hybrid struct {
+	retrievers []Retriever
+	weights    []float64
+	c          float64
+	fetchK     int
+}
+
+// Compile type interface assertion
+var _ Retriever = (*Hybrid)(nil)
+
+// HybridOption configures a [Hybrid] retriever
+type HybridOption func(*Hybrid)
+
+// WithWeights sets the weight of each retriever, in the order given to [NewHybrid].
+// Missing weights default to 1.
+func WithWeights(weights ...float64) HybridOption {
+	return func(h *Hybrid) {
+		copy(h.weights, weights)
+	}
+}
+
+// WithRankConstant sets the constant c damping the contribution of top ranks. The default is 60.
+func WithRankConstant(c float64) HybridOption {
+	return func(h *Hybrid) {
+		h.c = c
+	}
+}
+
+// WithFetchK sets the number of candidates requested from each retriever.
+// The default is twice the number of requested matches.
+func WithFetchK(k int) HybridOption {
+	return func(h *Hybrid) {
+		h.fetchK = k
+	}
+}
+
+// NewHybrid returns a Retriever fusing the rankings of retrievers.
+func NewHybrid(retrievers []Retriever, opts ...HybridOption) *Hybrid {
+	h := &Hybrid{
+		retrievers: retrievers,
+		weights:    make([]float64, len(retrievers)),
+		c:          60,
+	}
+	for i := range h.weights {
+		h.weights[i] = 1
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Retrieve queries every retriever and returns at most k fused matches, with their fused score.
+// The first retriever returning a match provides its record.
+func (h *Hybrid) Retrieve(ctx context.Context, query string, k int) ([]vectorstore.Match, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+	fetchK := h.fetchK
+	if fetchK < k {
+		fetchK = 2 * k
+	}
+
+	results := make([][]vectorstore.Match, len(h.retrievers))
+	for i, r := range h.retrievers {
+		matches, err := r.Retrieve(ctx, query, fetchK)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = matches
+	}
+	return Fuse(results, h.weights, h.c, k), nil
+}
+
+// Fuse merges ranked lists with weighted reciprocal rank fusion and returns the k best matches.
+// Lists without a matching weight are weighted 1.
+func Fuse(lists [][]vectorstore.Match, weights []float64, c float64, k int) []vectorstore.Match {
+	scores := make(map[string]float64)
+	records := make(map[string]vectorstore.Record)
+	for i, list := range lists {
+		w := 1.0
+		if i < len(weights) {
+			w = weights[i]
+		}
+		for rank, m := range list {
+			scores[m.ID] += w / (c + float64(rank+1))
+			if _, ok := records[m.ID]; !ok {
+				records[m.ID] = m.Record
+			}
+		}
+	}
+
+	fused := make([]vectorstore.Match, 0, len(scores))
+	for id, score := range scores {
+		fused = append(fused, vectorstore.Match{Record: records[id], Score: float32(score)})
+	}
+	vectorstore.SortMatches(fused)
+	if len(fused) > k {
+		fused = fused[:k]
+	}
+	return fused
+}
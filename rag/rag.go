@@ -0,0 +1,233 @@
+// Package rag implements retrieval-augmented generation: passages relevant to the
+// user question are retrieved and injected into the conversation before generating.
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"strings"
+	"text/template"
+
+	"nyxze/fayth/model"
+	"nyxze/fayth/vectorstore"
+)
+
+// CitationsKey is the [model.Message] metadata key holding the JSON encoded citations of an answer.
+const CitationsKey = "rag.citations"
+
+// SourceKey is the chunk metadata key reported as the citation source, when present.
+const SourceKey = "source"
+
+// Errors
+var (
+	ErrNoQuestion = errors.New("rag: no user message to answer")
+)
+
+// DefaultTemplate renders retrieved passages into the system message injected before the conversation.
+var DefaultTemplate = template.Must(template.New("rag").Parse(
+	`Answer the question using only the context below.
+Cite the passages you use with their number in brackets, such as [1].
+If the context does not contain the answer, say that you don't know.
+
+Context:
+{{range .Passages}}[{{.Number}}] {{.Content}}
+{{end}}`))
+
+// Passage is a retrieved chunk as exposed to prompt templates.
+type Passage struct {
+	// Number is the 1-based position of the passage, used for citing it
+	Number   int
+	ID       string
+	Content  string
+	Score    float32
+	Metadata map[string]string
+}
+
+// TemplateData is the value prompt templates are executed with.
+type TemplateData struct {
+	Question string
+	Passages []Passage
+}
+
+// Citation links an answer to a retrieved passage.
+type Citation struct {
+	Number int     `json:"number"`
+	ID     string  `json:"id"`
+	Source string  `json:"source,omitempty"`
+	Score  float32 `json:"score"`
+}
+
+// Pipeline is a [model.Model] augmenting conversations with retrieved context.
+type Pipeline struct {
+	model     model.Model
+	retriever Retriever
+	template  *template.Template
+	k         int
+	reranker  Reranker
+	fetchK    int
+}
+
+// Compile type interface assertion
+var _ model.Model = (*Pipeline)(nil)
+
+// Option configures a [Pipeline]
+type Option func(*Pipeline)
+
+// WithTemplate sets the template rendering the injected system message from [TemplateData].
+func WithTemplate(t *template.Template) Option {
+	return func(p *Pipeline) {
+		p.template = t
+	}
+}
+
+// WithTopK sets the number of passages retrieved. The default is 4.
+func WithTopK(k int) Option {
+	return func(p *Pipeline) {
+		p.k = max(k, 1)
+	}
+}
+
+// WithReranker reorders fetchK retrieved candidates with r before keeping the top passages.
+func WithReranker(r Reranker, fetchK int) Option {
+	return func(p *Pipeline) {
+		p.reranker = r
+		p.fetchK = fetchK
+	}
+}
+
+// New returns a Pipeline answering with m from passages found by retriever.
+func New(m model.Model, retriever Retriever, opts ...Option) *Pipeline {
+	p := &Pipeline{
+		model:     m,
+		retriever: retriever,
+		template:  DefaultTemplate,
+		k:         4,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Generate retrieves passages relevant to the last user message, injects them as
+// a system message and generates the answer. Every returned message carries the
+// citations under [CitationsKey].
+func (p *Pipeline) Generate(ctx context.Context, m []model.Message, opts ...model.ModelOption) (*model.Generation, error) {
+	augmented, citations, err := p.Augment(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	gen, err := p.model.Generate(ctx, augmented, opts...)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(citations)
+	if err != nil {
+		return nil, err
+	}
+	// The metadata of msg may be shared, such as with a cache entry
+	cite := func(msg model.Message) model.Message {
+		msg.Metadata = maps.Clone(msg.Metadata)
+		if msg.Metadata == nil {
+			msg.Metadata = map[string]string{}
+		}
+		msg.Metadata[CitationsKey] = string(encoded)
+		return msg
+	}
+
+	out := &model.Generation{}
+	// The usage of a generation that is not streamed is already known
+	out.SetUsage(gen.Usage())
+	out.MsgIter = func(yield func(model.Message) bool) {
+		for msg := range gen.Messages() {
+			if !yield(cite(msg)) {
+				return
+			}
+		}
+		out.Err = gen.Error()
+		out.SetUsage(gen.Usage())
+	}
+	return out, nil
+}
+
+// Augment returns m with the retrieved context injected as a system message,
+// along with the citations of the retrieved passages.
+func (p *Pipeline) Augment(ctx context.Context, m []model.Message) ([]model.Message, []Citation, error) {
+	question := ""
+	for i := len(m) - 1; i >= 0 && question == ""; i-- {
+		if m[i].Role == model.User {
+			question = m[i].Text()
+		}
+	}
+	if question == "" {
+		return nil, nil, ErrNoQuestion
+	}
+
+	matches, err := p.retrieve(ctx, question)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := TemplateData{Question: question, Passages: make([]Passage, len(matches))}
+	citations := make([]Citation, len(matches))
+	for i, match := range matches {
+		data.Passages[i] = passage(i+1, match)
+		citations[i] = Citation{
+			Number: i + 1,
+			ID:     match.ID,
+			Source: match.Metadata[SourceKey],
+			Score:  match.Score,
+		}
+	}
+
+	var sb strings.Builder
+	if err := p.template.Execute(&sb, data); err != nil {
+		return nil, nil, err
+	}
+
+	// Keep leading system messages first, the context follows them
+	pos := 0
+	for pos < len(m) && m[pos].Role == model.System {
+		pos++
+	}
+	augmented := make([]model.Message, 0, len(m)+1)
+	augmented = append(augmented, m[:pos]...)
+	augmented = append(augmented, model.NewTextMessage(model.System, sb.String()))
+	augmented = append(augmented, m[pos:]...)
+	return augmented, citations, nil
+}
+
+// retrieve returns the top passages for question, reranked when a reranker is configured.
+func (p *Pipeline) retrieve(ctx context.Context, question string) ([]vectorstore.Match, error) {
+	if p.reranker == nil {
+		return p.retriever.Retrieve(ctx, question, p.k)
+	}
+	candidates, err := p.retriever.Retrieve(ctx, question, max(p.fetchK, p.k))
+	if err != nil {
+		return nil, err
+	}
+	return p.reranker.Rerank(ctx, question, candidates, p.k)
+}
+
+func passage(number int, m vectorstore.Match) Passage {
+	return Passage{
+		Number:   number,
+		ID:       m.ID,
+		Content:  m.Content,
+		Score:    m.Score,
+		Metadata: m.Metadata,
+	}
+}
+
+// Citations decodes the citations attached to msg, if any.
+func Citations(msg model.Message) ([]Citation, error) {
+	raw, ok := msg.Metadata[CitationsKey]
+	if !ok {
+		return nil, nil
+	}
+	var c []Citation
+	err := json.Unmarshal([]byte(raw), &c)
+	return c, err
+}
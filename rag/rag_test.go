@@ -0,0 +1,111 @@
+package rag
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"nyxze/fayth/model"
+	"nyxze/fayth/vectorstore"
+)
+
+// keywordEmbedder embeds texts by counting a fixed vocabulary
+type keywordEmbedder []string
+
+func (e keywordEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		v := make([]float32, len(e)+1)
+		v[len(e)] = 0.01
+		for j, w := range e {
+			v[j] = float32(strings.Count(strings.ToLower(t), w))
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// recordingModel answers with a fixed text and keeps the last input
+type recordingModel struct {
+	input []model.Message
+	// metadata is shared by every answer, as a cache entry would be
+	metadata map[string]string
+}
+
+func (r *recordingModel) Generate(_ context.Context, m []model.Message, _ ...model.ModelOption) (*model.Generation, error) {
+	r.input = m
+	answer := model.NewTextMessage(model.Assistant, "Paris [1]")
+	answer.Metadata = r.metadata
+	gen := model.NewGeneration([]model.Message{answer})
+	gen.SetUsage(model.Usage{InputTokens: 12, OutputTokens: 3})
+	return gen, nil
+}
+
+var vocabulary = keywordEmbedder{"france", "capital", "paris", "go", "gopher"}
+
+func newStore(t *testing.T) vectorstore.Store {
+	t.Helper()
+	store := vectorstore.NewMemory(vectorstore.Cosine)
+	err := Index(context.Background(), store, vocabulary, 2,
+		Chunk{ID: "fr", Content: "Paris is the capital of France.", Metadata: map[string]string{"source": "geo.md"}},
+		Chunk{ID: "go", Content: "The Go gopher is the Go mascot.", Metadata: map[string]string{"source": "go.md"}},
+		Chunk{ID: "fr2", Content: "France has Paris as capital city.", Metadata: map[string]string{"source": "geo2.md"}},
+	)
+	if err != nil {
+		t.Fatalf("Index() unexpected error: %v", err)
+	}
+	return store
+}
+
+func TestPipeline_Generate(t *testing.T) {
+	llm := &recordingModel{metadata: map[string]string{"cached": "true"}}
+	pipeline := New(llm, NewVectorRetriever(newStore(t), vocabulary), WithTopK(1))
+
+	input := []model.Message{
+		model.NewTextMessage(model.System, "You are a geography tutor."),
+		model.NewTextMessage(model.User, "What is the capital of France?"),
+	}
+	gen, err := pipeline.Generate(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Generate() unexpected error: %v", err)
+	}
+	if want := (model.Usage{InputTokens: 12, OutputTokens: 3}); gen.Usage() != want {
+		t.Errorf("Usage() = %+v, want the usage of the model %+v", gen.Usage(), want)
+	}
+	var answers []model.Message
+	for m := range gen.Messages() {
+		answers = append(answers, m)
+	}
+	if len(answers) != 1 || answers[0].Text() != "Paris [1]" {
+		t.Fatalf("Unexpected answer: %+v", answers)
+	}
+
+	if len(llm.input) != 3 {
+		t.Fatalf("Expected context to be injected, got %d messages", len(llm.input))
+	}
+	if llm.input[0].Text() != "You are a geography tutor." || llm.input[1].Role != model.System {
+		t.Errorf("Expected context after the leading system message, got %+v", llm.input)
+	}
+	if !strings.Contains(llm.input[1].Text(), "[1] Paris is the capital of France.") {
+		t.Errorf("Expected passage in context, got %q", llm.input[1].Text())
+	}
+
+	citations, err := Citations(answers[0])
+	if err != nil {
+		t.Fatalf("Citations() unexpected error: %v", err)
+	}
+	if len(citations) != 1 || citations[0].ID != "fr" || citations[0].Source != "geo.md" {
+		t.Errorf("Unexpected citations: %+v", citations)
+	}
+	if answers[0].Metadata["cached"] != "true" || len(llm.metadata) != 1 {
+		t.Errorf("Expected the citations added to a copy of the metadata, got %v and %v", answers[0].Metadata, llm.metadata)
+	}
+}
+
+func TestPipeline_NoQuestion(t *testing.T) {
+	pipeline := New(&recordingModel{}, NewVectorRetriever(newStore(t), vocabulary))
+	_, err := pipeline.Generate(context.Background(), []model.Message{model.NewTextMessage(model.System, "Hi")})
+	if err != ErrNoQuestion {
+		t.Errorf("Expected ErrNoQuestion, got %v", err)
+	}
+}
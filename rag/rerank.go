@@ -0,0 +1,225 @@
+package rag
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"nyxze/fayth/model"
+	"nyxze/fayth/vectorstore"
+)
+
+// Errors
+var (
+	ErrInvalidRerank = errors.New("rag: invalid rerank response")
+)
+
+// Reranker reorders retrieved matches by relevance to the query.
+// Implementations keep the records, metadata included, and replace the scores with their own.
+type Reranker interface {
+	// Rerank returns at most k matches, most relevant first, or all of them when k is not positive.
+	Rerank(ctx context.Context, query string, matches []vectorstore.Match, k int) ([]vectorstore.Match, error)
+}
+
+// RerankMode selects how an [LLMReranker] prompts the model.
+type RerankMode int
+
+const (
+	// Pointwise asks for an independent 0-10 relevance score per passage.
+	Pointwise RerankMode = iota
+	// Listwise asks for a single ordering of all passages.
+	Listwise
+)
+
+const pointwisePrompt = `You rate how relevant passages are to a query.
+Give every passage a score from 0 (irrelevant) to 10 (fully answers the query).
+Reply with a JSON object of the form {"scores": [{"passage": 1, "score": 7}]}.`
+
+const listwisePrompt = `You rank passages by relevance to a query.
+Reply with a JSON object listing the passage numbers from most to least relevant,
+of the form {"ranking": [3, 1, 2]}.`
+
+// LLMReranker reranks matches by asking a [model.Model] to judge their relevance,
+// using JSON mode for parseable answers.
+type LLMReranker struct {
+	model       model.Model
+	mode        RerankMode
+	batchSize   int
+	concurrency int
+	options     []model.ModelOption
+}
+
+// Compile type interface assertion
+var _ Reranker = (*LLMReranker)(nil)
+
+// RerankOption configures an [LLMReranker]
+type RerankOption func(*LLMReranker)
+
+// WithRerankMode sets the prompting strategy. The default is [Pointwise].
+func WithRerankMode(mode RerankMode) RerankOption {
+	return func(r *LLMReranker) {
+		r.mode = mode
+	}
+}
+
+// WithBatchSize sets the number of passages scored per call in [Pointwise] mode. The default is 5.
+func WithBatchSize(n int) RerankOption {
+	return func(r *LLMReranker) {
+		r.batchSize = max(n, 1)
+	}
+}
+
+// WithConcurrency bounds the number of concurrent calls in [Pointwise] mode. The default is 4.
+func WithConcurrency(n int) RerankOption {
+	return func(r *LLMReranker) {
+		r.concurrency = max(n, 1)
+	}
+}
+
+// WithRerankModelOptions sets options passed to the model on every call, such as the model name.
+func WithRerankModelOptions(opts ...model.ModelOption) RerankOption {
+	return func(r *LLMReranker) {
+		r.options = opts
+	}
+}
+
+// NewLLMReranker returns a Reranker judging relevance with m.
+func NewLLMReranker(m model.Model, opts ...RerankOption) *LLMReranker {
+	r := &LLMReranker{
+		model:       m,
+		batchSize:   5,
+		concurrency: 4,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *LLMReranker) Rerank(ctx context.Context, query string, matches []vectorstore.Match, k int) ([]vectorstore.Match, error) {
+	var (
+		scores []float32
+		err    error
+	)
+	if r.mode == Listwise {
+		scores, err = r.listwise(ctx, query, matches)
+	} else {
+		scores, err = r.pointwise(ctx, query, matches)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return applyScores(matches, scores, k), nil
+}
+
+// pointwise scores batches of matches concurrently.
+func (r *LLMReranker) pointwise(ctx context.Context, query string, matches []vectorstore.Match) ([]float32, error) {
+	scores := make([]float32, len(matches))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		sem      = make(chan struct{}, r.concurrency)
+	)
+	for start := 0; start < len(matches); start += r.batchSize {
+		batch := matches[start:min(start+r.batchSize, len(matches))]
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			var reply struct {
+				Scores []struct {
+					Passage int     `json:"passage"`
+					Score   float32 `json:"score"`
+				} `json:"scores"`
+			}
+			if err := r.ask(ctx, pointwisePrompt, query, batch, &reply); err != nil {
+				once.Do(func() { firstErr = err; cancel() })
+				return
+			}
+			// Each goroutine writes its own window of scores
+			for _, s := range reply.Scores {
+				if s.Passage >= 1 && s.Passage <= len(batch) {
+					scores[start+s.Passage-1] = s.Score
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return scores, firstErr
+}
+
+// listwise asks for an ordering of all matches, ranked matches score from 1 down to 1/n
+// and matches left out of the ranking score 0.
+func (r *LLMReranker) listwise(ctx context.Context, query string, matches []vectorstore.Match) ([]float32, error) {
+	var reply struct {
+		Ranking []int `json:"ranking"`
+	}
+	if err := r.ask(ctx, listwisePrompt, query, matches, &reply); err != nil {
+		return nil, err
+	}
+	scores := make([]float32, len(matches))
+	n := float32(len(matches))
+	next := n
+	for _, number := range reply.Ranking {
+		if number < 1 || number > len(matches) || scores[number-1] != 0 {
+			continue
+		}
+		scores[number-1] = next / n
+		next--
+	}
+	return scores, nil
+}
+
+// ask sends the passages to the model in JSON mode and decodes its answer into reply.
+func (r *LLMReranker) ask(ctx context.Context, instructions, query string, matches []vectorstore.Match, reply any) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Query: %s\n\nPassages:\n", query)
+	for i, m := range matches {
+		fmt.Fprintf(&sb, "[%d] %s\n", i+1, m.Content)
+	}
+	messages := []model.Message{
+		model.NewTextMessage(model.System, instructions),
+		model.NewTextMessage(model.User, sb.String()),
+	}
+	opts := append([]model.ModelOption{model.WithJSONMode()}, r.options...)
+	gen, err := r.model.Generate(ctx, messages, opts...)
+	if err != nil {
+		return err
+	}
+	var answer strings.Builder
+	for msg := range gen.Messages() {
+		answer.WriteString(msg.Text())
+	}
+	if gen.Error() != nil {
+		return gen.Error()
+	}
+	if err := json.Unmarshal([]byte(answer.String()), reply); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRerank, err)
+	}
+	return nil
+}
+
+// applyScores returns copies of matches with their new scores, highest first.
+// Ties keep the retrieval order.
+func applyScores(matches []vectorstore.Match, scores []float32, k int) []vectorstore.Match {
+	out := slices.Clone(matches)
+	for i := range out {
+		out[i].Score = scores[i]
+	}
+	slices.SortStableFunc(out, func(a, b vectorstore.Match) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	if k > 0 && len(out) > k {
+		out = out[:k]
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"nyxze/fayth/vectorstore"
+)
+
+// RelevanceScorer scores documents against a query, typically a dedicated cross-encoder rerank model.
+type RelevanceScorer interface {
+	// Score returns one relevance score per document, in the order of documents.
+	Score(ctx context.Context, query string, documents []string) ([]float32, error)
+}
+
+// ScoringReranker is a [Reranker] ordering matches with a [RelevanceScorer].
+type ScoringReranker struct {
+	scorer RelevanceScorer
+}
+
+// Compile type interface assertion
+var _ Reranker = (*ScoringReranker)(nil)
+
+// NewScoringReranker returns a Reranker backed by s.
+func NewScoringReranker(s RelevanceScorer) *ScoringReranker {
+	return &ScoringReranker{scorer: s}
+}
+
+func (r *ScoringReranker) Rerank(ctx context.Context, query string, matches []vectorstore.Match, k int) ([]vectorstore.Match, error) {
+	documents := make([]string, len(matches))
+	for i, m := range matches {
+		documents[i] = m.Content
+	}
+	scores, err := r.scorer.Score(ctx, query, documents)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) != len(matches) {
+		return nil, fmt.Errorf("%w: got %d scores for %d documents", ErrInvalidRerank, len(scores), len(matches))
+	}
+	return applyScores(matches, scores, k), nil
+}
+
+// RerankAPI selects the wire format of a rerank endpoint.
+type RerankAPI int
+
+const (
+	// CohereAPI is the Cohere /v1/rerank format, also served by Jina and vLLM.
+	CohereAPI RerankAPI = iota
+	// TEIAPI is the Hugging Face text-embeddings-inference /rerank format.
+	TEIAPI
+)
+
+// RerankClient is a [RelevanceScorer] calling an HTTP rerank endpoint.
+type RerankClient struct {
+	url    string
+	api    RerankAPI
+	model  string
+	apiKey string
+	client *http.Client
+}
+
+// Compile type interface assertion
+var _ RelevanceScorer = (*RerankClient)(nil)
+
+// RerankClientOption configures a [RerankClient]
+type RerankClientOption func(*RerankClient)
+
+// WithRerankAPI sets the wire format of the endpoint. The default is [CohereAPI].
+func WithRerankAPI(api RerankAPI) RerankClientOption {
+	return func(c *RerankClient) {
+		c.api = api
+	}
+}
+
+// WithRerankModel sets the model name sent with Cohere compatible requests.
+func WithRerankModel(name string) RerankClientOption {
+	return func(c *RerankClient) {
+		c.model = name
+	}
+}
+
+// WithRerankAPIKey sets the bearer token sent with every request.
+func WithRerankAPIKey(key string) RerankClientOption {
+	return func(c *RerankClient) {
+		c.apiKey = key
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for requests. The default is [http.DefaultClient].
+func WithHTTPClient(client *http.Client) RerankClientOption {
+	return func(c *RerankClient) {
+		c.client = client
+	}
+}
+
+// NewRerankClient returns a client posting to the rerank endpoint at url,
+// such as "http://localhost:8080/rerank".
+func NewRerankClient(url string, opts ...RerankClientOption) *RerankClient {
+	c := &RerankClient{
+		url:    url,
+		client: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type rerankResult struct {
+	Index int     `json:"index"`
+	Score float32 `json:"score"`
+	// RelevanceScore is the Cohere name of Score
+	RelevanceScore float32 `json:"relevance_score"`
+}
+
+func (c *RerankClient) Score(ctx context.Context, query string, documents []string) ([]float32, error) {
+	var body any
+	if c.api == TEIAPI {
+		body = map[string]any{"query": query, "texts": documents}
+	} else {
+		body = map[string]any{"model": c.model, "query": query, "documents": documents, "top_n": len(documents)}
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rag: rerank endpoint returned %s", resp.Status)
+	}
+
+	var results []rerankResult
+	if c.api == TEIAPI {
+		err = json.NewDecoder(resp.Body).Decode(&results)
+	} else {
+		var reply struct {
+			Results []rerankResult `json:"results"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&reply)
+		results = reply.Results
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRerank, err)
+	}
+
+	scores := make([]float32, len(documents))
+	for _, r := range results {
+		if r.Index < 0 || r.Index >= len(documents) {
+			return nil, fmt.Errorf("%w: document index %d out of range", ErrInvalidRerank, r.Index)
+		}
+		if c.api == TEIAPI {
+			scores[r.Index] = r.Score
+		} else {
+			scores[r.Index] = r.RelevanceScore
+		}
+	}
+	return scores, nil
+}
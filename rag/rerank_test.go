@@ -0,0 +1,220 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nyxze/fayth/model"
+	"nyxze/fayth/vectorstore"
+)
+
+// judgeModel answers rerank prompts with the reply function, tracking calls and concurrency
+type judgeModel struct {
+	reply func(passages []string) string
+
+	calls, active, peak atomic.Int32
+	mu                  sync.Mutex
+	options             []model.ModelOptions
+}
+
+var passageLine = regexp.MustCompile(`(?m)^\[\d+\] (.*)$`)
+
+func (j *judgeModel) Generate(_ context.Context, m []model.Message, opts ...model.ModelOption) (*model.Generation, error) {
+	j.calls.Add(1)
+	if n := j.active.Add(1); n > j.peak.Load() {
+		j.peak.Store(n)
+	}
+	defer j.active.Add(-1)
+	time.Sleep(5 * time.Millisecond)
+
+	j.mu.Lock()
+	j.options = append(j.options, model.MergeOptions(model.ModelOptions{}, opts...))
+	j.mu.Unlock()
+
+	var passages []string
+	for _, match := range passageLine.FindAllStringSubmatch(m[len(m)-1].Text(), -1) {
+		passages = append(passages, match[1])
+	}
+	return model.NewGeneration([]model.Message{model.NewTextMessage(model.Assistant, j.reply(passages))}), nil
+}
+
+// scoreByKeyword scores passages 9 when they mention keyword, 1 otherwise
+func scoreByKeyword(keyword string) func([]string) string {
+	return func(passages []string) string {
+		var scores []string
+		for i, p := range passages {
+			score := 1
+			if strings.Contains(p, keyword) {
+				score = 9
+			}
+			scores = append(scores, fmt.Sprintf(`{"passage": %d, "score": %d}`, i+1, score))
+		}
+		return `{"scores": [` + strings.Join(scores, ",") + `]}`
+	}
+}
+
+func candidates(contents ...string) []vectorstore.Match {
+	out := make([]vectorstore.Match, len(contents))
+	for i, c := range contents {
+		out[i] = vectorstore.Match{
+			Record: vectorstore.Record{ID: fmt.Sprint("c", i), Content: c, Metadata: map[string]string{"source": fmt.Sprint("doc", i, ".md")}},
+			Score:  0.5,
+		}
+	}
+	return out
+}
+
+func TestLLMReranker_Pointwise(t *testing.T) {
+	judge := &judgeModel{reply: scoreByKeyword("Paris")}
+	reranker := NewLLMReranker(judge, WithBatchSize(2), WithConcurrency(2), WithRerankModelOptions(model.WithModel("judge")))
+	matches := candidates("Lyon is large.", "Paris is the capital.", "Nice is sunny.", "Lille is north.", "Paris hosts the Louvre.")
+
+	got, err := reranker.Rerank(context.Background(), "capital of France", matches, 3)
+	if err != nil {
+		t.Fatalf("Rerank() unexpected error: %v", err)
+	}
+	if want := []string{"c1", "c4", "c0"}; !slices.Equal(ids(got), want) {
+		t.Errorf("Rerank() = %v, want %v", ids(got), want)
+	}
+	if got[0].Score != 9 || got[2].Score != 1 {
+		t.Errorf("Rerank() scores = %v, %v, want 9, 1", got[0].Score, got[2].Score)
+	}
+	if got[0].Metadata["source"] != "doc1.md" {
+		t.Errorf("Rerank() lost metadata: %+v", got[0])
+	}
+	if judge.calls.Load() != 3 {
+		t.Errorf("calls = %d, want 3 batches", judge.calls.Load())
+	}
+	if judge.peak.Load() > 2 {
+		t.Errorf("peak concurrency = %d, want at most 2", judge.peak.Load())
+	}
+	for _, o := range judge.options {
+		if o.ResponseFormat.Type != "json_object" || o.Model != "judge" {
+			t.Errorf("options = %+v, want JSON mode with the judge model", o)
+		}
+	}
+	if matches[1].Score != 0.5 {
+		t.Error("Rerank() modified its input")
+	}
+}
+
+func TestLLMReranker_Listwise(t *testing.T) {
+	judge := &judgeModel{reply: func([]string) string { return `{"ranking": [3, 1, 3, 7]}` }}
+	reranker := NewLLMReranker(judge, WithRerankMode(Listwise))
+
+	got, err := reranker.Rerank(context.Background(), "query", candidates("a", "b", "c", "d"), 0)
+	if err != nil {
+		t.Fatalf("Rerank() unexpected error: %v", err)
+	}
+	if want := []string{"c2", "c0", "c1", "c3"}; !slices.Equal(ids(got), want) {
+		t.Errorf("Rerank() = %v, want %v", ids(got), want)
+	}
+	if got[0].Score != 1 || got[1].Score != 0.75 || got[2].Score != 0 {
+		t.Errorf("Rerank() scores = %v, %v, %v, want 1, 0.75, 0", got[0].Score, got[1].Score, got[2].Score)
+	}
+	if judge.calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", judge.calls.Load())
+	}
+}
+
+func TestLLMReranker_InvalidReply(t *testing.T) {
+	judge := &judgeModel{reply: func([]string) string { return "Passage 1 is best." }}
+	_, err := NewLLMReranker(judge).Rerank(context.Background(), "query", candidates("a"), 1)
+	if !errors.Is(err, ErrInvalidRerank) {
+		t.Errorf("Rerank() error = %v, want ErrInvalidRerank", err)
+	}
+}
+
+func TestRerankClient(t *testing.T) {
+	tests := []struct {
+		name  string
+		api   RerankAPI
+		reply string
+		check func(t *testing.T, body map[string]any)
+	}{
+		{
+			name:  "Cohere",
+			api:   CohereAPI,
+			reply: `{"results": [{"index": 1, "relevance_score": 0.9}, {"index": 0, "relevance_score": 0.2}]}`,
+			check: func(t *testing.T, body map[string]any) {
+				if body["model"] != "rerank-v3" || body["documents"] == nil || body["top_n"] != 2.0 {
+					t.Errorf("request body = %v", body)
+				}
+			},
+		},
+		{
+			name:  "TEI",
+			api:   TEIAPI,
+			reply: `[{"index": 1, "score": 0.9}, {"index": 0, "score": 0.2}]`,
+			check: func(t *testing.T, body map[string]any) {
+				if body["texts"] == nil || body["model"] != nil {
+					t.Errorf("request body = %v", body)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer secret" {
+					t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+				}
+				var body map[string]any
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("invalid request body: %v", err)
+				}
+				if body["query"] != "capital" {
+					t.Errorf("query = %v", body["query"])
+				}
+				tt.check(t, body)
+				w.Write([]byte(tt.reply))
+			}))
+			defer srv.Close()
+
+			client := NewRerankClient(srv.URL, WithRerankAPI(tt.api), WithRerankModel("rerank-v3"), WithRerankAPIKey("secret"))
+			got, err := NewScoringReranker(client).Rerank(context.Background(), "capital", candidates("Lyon", "Paris"), 2)
+			if err != nil {
+				t.Fatalf("Rerank() unexpected error: %v", err)
+			}
+			if !slices.Equal(ids(got), []string{"c1", "c0"}) || got[0].Score != 0.9 || got[0].Metadata["source"] != "doc1.md" {
+				t.Errorf("Rerank() = %+v", got)
+			}
+		})
+	}
+
+	t.Run("Error status", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+		if _, err := NewRerankClient(srv.URL).Score(context.Background(), "q", []string{"a"}); err == nil {
+			t.Error("Score() expected an error")
+		}
+	})
+}
+
+func TestPipeline_WithReranker(t *testing.T) {
+	retriever := staticRetriever{"a", "b", "c", "d"}
+	judge := &judgeModel{reply: scoreByKeyword("C")}
+	llm := &recordingModel{}
+	p := New(llm, retriever, WithTopK(1), WithReranker(NewLLMReranker(judge), 3))
+
+	_, citations, err := p.Augment(context.Background(), []model.Message{model.NewTextMessage(model.User, "Which one?")})
+	if err != nil {
+		t.Fatalf("Augment() unexpected error: %v", err)
+	}
+	if len(citations) != 1 || citations[0].ID != "c" || citations[0].Score != 9 {
+		t.Errorf("Augment() citations = %+v, want c reranked first", citations)
+	}
+}
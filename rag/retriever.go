@@ -0,0 +1,123 @@
+package rag
+
+import (
+	"context"
+	"errors"
+
+	"nyxze/fayth/model"
+	"nyxze/fayth/vectorstore"
+)
+
+// Retriever finds the passages most relevant to a query.
+type Retriever interface {
+	// Retrieve returns at most k matches, most relevant first.
+	Retrieve(ctx context.Context, query string, k int) ([]vectorstore.Match, error)
+}
+
+// Chunk is a piece of a source document to index.
+type Chunk struct {
+	ID       string            `json:"id"`
+	Content  string            `json:"content"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Index embeds chunks in batches of batchSize and upserts them into store.
+// A batchSize lower than 1 embeds all chunks at once.
+func Index(ctx context.Context, store vectorstore.Store, embedder model.Embedder, batchSize int, chunks ...Chunk) error {
+	if batchSize < 1 {
+		batchSize = max(len(chunks), 1)
+	}
+	for start := 0; start < len(chunks); start += batchSize {
+		batch := chunks[start:min(start+batchSize, len(chunks))]
+		texts := make([]string, len(batch))
+		for i, c := range batch {
+			texts[i] = c.Content
+		}
+		vectors, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return err
+		}
+		if len(vectors) != len(batch) {
+			return errors.New("rag: embedder returned an unexpected number of vectors")
+		}
+		records := make([]vectorstore.Record, len(batch))
+		for i, c := range batch {
+			records[i] = vectorstore.Record{
+				ID:       c.ID,
+				Vector:   vectors[i],
+				Content:  c.Content,
+				Metadata: c.Metadata,
+			}
+		}
+		if err := store.Upsert(ctx, records...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VectorRetriever retrieves passages by embedding the query and searching a vector store.
+type VectorRetriever struct {
+	store    vectorstore.Store
+	embedder model.Embedder
+	filter   vectorstore.Filter
+	// MMR parameters, disabled when fetchK is zero
+	lambda float32
+	fetchK int
+}
+
+// Compile type interface assertion
+var _ Retriever = (*VectorRetriever)(nil)
+
+// RetrieverOption configures a [VectorRetriever]
+type RetrieverOption func(*VectorRetriever)
+
+// WithFilter restricts retrieval to records matching f.
+func WithFilter(f vectorstore.Filter) RetrieverOption {
+	return func(r *VectorRetriever) {
+		r.filter = f
+	}
+}
+
+// WithMMR reranks fetchK candidates with maximal marginal relevance.
+// lambda balances relevance (1) against diversity (0).
+func WithMMR(lambda float32, fetchK int) RetrieverOption {
+	return func(r *VectorRetriever) {
+		r.lambda = lambda
+		r.fetchK = fetchK
+	}
+}
+
+// NewVectorRetriever returns a Retriever searching store with queries embedded by embedder.
+func NewVectorRetriever(store vectorstore.Store, embedder model.Embedder, opts ...RetrieverOption) *VectorRetriever {
+	r := &VectorRetriever{
+		store:    store,
+		embedder: embedder,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *VectorRetriever) Retrieve(ctx context.Context, query string, k int) ([]vectorstore.Match, error) {
+	vectors, err := r.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != 1 {
+		return nil, errors.New("rag: embedder returned an unexpected number of vectors")
+	}
+	var opts []vectorstore.QueryOption
+	if r.filter != nil {
+		opts = append(opts, vectorstore.WithFilter(r.filter))
+	}
+	if r.fetchK <= k {
+		return r.store.Query(ctx, vectors[0], k, opts...)
+	}
+	candidates, err := r.store.Query(ctx, vectors[0], r.fetchK, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return vectorstore.MMR(vectors[0], candidates, k, r.lambda), nil
+}
@@ -0,0 +1,84 @@
+package trace
+
+import "nyxze/fayth/model"
+
+// Attribute keys following the OpenTelemetry GenAI semantic conventions.
+// See https://opentelemetry.io/docs/specs/semconv/gen-ai/
+const (
+	GenAISystem               = "gen_ai.system"
+	GenAIOperationName        = "gen_ai.operation.name"
+	GenAIRequestModel         = "gen_ai.request.model"
+	GenAIRequestTemperature   = "gen_ai.request.temperature"
+	GenAIRequestTopP          = "gen_ai.request.top_p"
+	GenAIRequestMaxTokens     = "gen_ai.request.max_tokens"
+	GenAIRequestSeed          = "gen_ai.request.seed"
+	GenAIRequestStopSequences = "gen_ai.request.stop_sequences"
+	GenAIResponseID           = "gen_ai.response.id"
+	GenAIResponseModel        = "gen_ai.response.model"
+	GenAIResponseFinish       = "gen_ai.response.finish_reasons"
+	GenAIUsageInputTokens     = "gen_ai.usage.input_tokens"
+	GenAIUsageOutputTokens    = "gen_ai.usage.output_tokens"
+	GenAIToolName             = "gen_ai.tool.name"
+	GenAIToolCallID           = "gen_ai.tool.call.id"
+	GenAIAgentName            = "gen_ai.agent.name"
+)
+
+// Operation names defined by the GenAI semantic conventions.
+const (
+	OperationChat        = "chat"
+	OperationExecuteTool = "execute_tool"
+	OperationInvokeAgent = "invoke_agent"
+)
+
+// RequestAttributes returns the GenAI request attributes describing a call made with opts.
+func RequestAttributes(system, operation string, opts model.ModelOptions) []Attribute {
+	attrs := []Attribute{
+		String(GenAISystem, system),
+		String(GenAIOperationName, operation),
+		String(GenAIRequestModel, opts.Model),
+		Float64(GenAIRequestTemperature, opts.Temperature),
+	}
+	if opts.TopP != 0 {
+		attrs = append(attrs, Float64(GenAIRequestTopP, opts.TopP))
+	}
+	if opts.MaxTokens != 0 {
+		attrs = append(attrs, Int(GenAIRequestMaxTokens, opts.MaxTokens))
+	}
+	if opts.Seed != 0 {
+		attrs = append(attrs, Int(GenAIRequestSeed, int(opts.Seed)))
+	}
+	if len(opts.Stop) > 0 {
+		attrs = append(attrs, Strings(GenAIRequestStopSequences, opts.Stop...))
+	}
+	return attrs
+}
+
+// Response describes the outcome of a generation for tracing purposes.
+type Response struct {
+	ID            string
+	Model         string
+	FinishReasons []string
+	InputTokens   int
+	OutputTokens  int
+}
+
+// Attributes returns the GenAI response attributes for r, omitting unknown values.
+func (r Response) Attributes() []Attribute {
+	var attrs []Attribute
+	if r.ID != "" {
+		attrs = append(attrs, String(GenAIResponseID, r.ID))
+	}
+	if r.Model != "" {
+		attrs = append(attrs, String(GenAIResponseModel, r.Model))
+	}
+	if len(r.FinishReasons) > 0 {
+		attrs = append(attrs, Strings(GenAIResponseFinish, r.FinishReasons...))
+	}
+	if r.InputTokens != 0 || r.OutputTokens != 0 {
+		attrs = append(attrs,
+			Int(GenAIUsageInputTokens, r.InputTokens),
+			Int(GenAIUsageOutputTokens, r.OutputTokens),
+		)
+	}
+	return attrs
+}
@@ -0,0 +1,113 @@
+package trace
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// NewSlogTracer returns a Tracer emitting one record per finished span on logger.
+// Span and trace identifiers are generated locally, continuing the trace carried by ctx.
+func NewSlogTracer(logger *slog.Logger) Tracer {
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	return &slogTracer{logger: logger}
+}
+
+type slogTracer struct {
+	logger *slog.Logger
+}
+
+func (t *slogTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	parent := SpanFromContext(ctx).SpanContext()
+	span := &slogSpan{
+		ctx:    ctx,
+		logger: t.logger,
+		name:   name,
+		parent: parent,
+		sc:     newSpanContext(parent),
+		start:  time.Now(),
+		attrs:  attrs,
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+type slogSpan struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	logger *slog.Logger
+	name   string
+	parent SpanContext
+	sc     SpanContext
+	start  time.Time
+	attrs  []Attribute
+	events int
+	err    error
+	ended  bool
+}
+
+func (s *slogSpan) SetAttributes(attrs ...Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+func (s *slogSpan) AddEvent(name string, attrs ...Attribute) {
+	s.mu.Lock()
+	s.events++
+	s.mu.Unlock()
+	s.logger.DebugContext(s.ctx, "trace: span event",
+		"span", s.name,
+		"event", name,
+		"trace_id", hex.EncodeToString(s.sc.TraceID[:]),
+		"span_id", hex.EncodeToString(s.sc.SpanID[:]),
+		slog.Group("attributes", toArgs(attrs)...),
+	)
+}
+
+func (s *slogSpan) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *slogSpan) SpanContext() SpanContext { return s.sc }
+
+func (s *slogSpan) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	attrs, events, err := s.attrs, s.events, s.err
+	s.mu.Unlock()
+
+	args := []any{
+		"span", s.name,
+		"trace_id", hex.EncodeToString(s.sc.TraceID[:]),
+		"span_id", hex.EncodeToString(s.sc.SpanID[:]),
+		"duration", time.Since(s.start),
+		"events", events,
+		slog.Group("attributes", toArgs(attrs)...),
+	}
+	if s.parent.IsValid() {
+		args = append(args, "parent_span_id", hex.EncodeToString(s.parent.SpanID[:]))
+	}
+	if err != nil {
+		s.logger.ErrorContext(s.ctx, "trace: span ended", append(args, "error", err)...)
+		return
+	}
+	s.logger.InfoContext(s.ctx, "trace: span ended", args...)
+}
+
+func toArgs(attrs []Attribute) []any {
+	args := make([]any, 0, len(attrs))
+	for _, a := range attrs {
+		args = append(args, slog.Any(a.Key, a.Value))
+	}
+	return args
+}
@@ -0,0 +1,153 @@
+// Package trace defines a small tracing abstraction used to observe generations,
+// HTTP attempts and agent steps without depending on a specific tracing SDK.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Attribute is a key/value pair attached to a span or a span event.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String returns a string valued Attribute.
+func String(key, value string) Attribute { return Attribute{Key: key, Value: value} }
+
+// Int returns an int valued Attribute.
+func Int(key string, value int) Attribute { return Attribute{Key: key, Value: value} }
+
+// Float64 returns a float64 valued Attribute.
+func Float64(key string, value float64) Attribute { return Attribute{Key: key, Value: value} }
+
+// Bool returns a bool valued Attribute.
+func Bool(key string, value bool) Attribute { return Attribute{Key: key, Value: value} }
+
+// Strings returns a string slice valued Attribute.
+func Strings(key string, value ...string) Attribute { return Attribute{Key: key, Value: value} }
+
+// Tracer creates spans.
+//
+// Start begins a new span as a child of the span carried by ctx, if any,
+// and returns a context carrying the new span.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span represents a single traced operation.
+// End must be called exactly once when the operation completes.
+type Span interface {
+	// SetAttributes adds or overrides attributes on the span.
+	SetAttributes(attrs ...Attribute)
+	// AddEvent records a named point in time within the span.
+	AddEvent(name string, attrs ...Attribute)
+	// RecordError marks the span as failed with err.
+	RecordError(err error)
+	// SpanContext returns the identifiers propagated to downstream services.
+	SpanContext() SpanContext
+	// End completes the span.
+	End()
+}
+
+// ErrInvalidTraceParent is returned when a traceparent header cannot be parsed.
+var ErrInvalidTraceParent = errors.New("trace: invalid traceparent")
+
+// SpanContext holds the identifiers of a span, as defined by W3C Trace Context.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid reports whether both trace and span identifiers are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceParent formats sc as a W3C traceparent header value.
+// It returns an empty string when sc is not valid.
+func (sc SpanContext) TraceParent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]), flags)
+}
+
+// ParseTraceParent parses a W3C traceparent header value.
+func ParseTraceParent(s string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, ErrInvalidTraceParent
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return sc, ErrInvalidTraceParent
+	}
+	sc.Sampled = flags[0]&0x01 == 0x01
+	if !sc.IsValid() {
+		return sc, ErrInvalidTraceParent
+	}
+	return sc, nil
+}
+
+// newSpanContext returns a SpanContext continuing parent's trace, or starting a new one.
+func newSpanContext(parent SpanContext) SpanContext {
+	sc := SpanContext{TraceID: parent.TraceID, Sampled: true}
+	if !parent.IsValid() {
+		rand.Read(sc.TraceID[:])
+	} else {
+		sc.Sampled = parent.Sampled
+	}
+	rand.Read(sc.SpanID[:])
+	return sc
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying span.
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span carried by ctx, or a no-op span.
+func SpanFromContext(ctx context.Context) Span {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		return span
+	}
+	return noopSpan{}
+}
+
+// Noop returns a Tracer whose spans record nothing.
+func Noop() Tracer { return noopTracer{} }
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute)    {}
+func (noopSpan) AddEvent(string, ...Attribute) {}
+func (noopSpan) RecordError(error)             {}
+func (noopSpan) SpanContext() SpanContext      { return SpanContext{} }
+func (noopSpan) End()                          {}
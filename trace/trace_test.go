@@ -0,0 +1,74 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestTraceParent_RoundTrip(t *testing.T) {
+	sc := newSpanContext(SpanContext{})
+	header := sc.TraceParent()
+	if len(header) != 55 || !strings.HasPrefix(header, "00-") || !strings.HasSuffix(header, "-01") {
+		t.Fatalf("Unexpected traceparent format: %q", header)
+	}
+	parsed, err := ParseTraceParent(header)
+	if err != nil {
+		t.Fatalf("ParseTraceParent failed: %v", err)
+	}
+	if parsed != sc {
+		t.Errorf("Round trip mismatch, got %+v, want %+v", parsed, sc)
+	}
+}
+
+func TestParseTraceParent_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"Empty":          "",
+		"Missing parts":  "00-4bf92f3577b34da6a3ce929d0e0e4736-01",
+		"Zero trace id":  "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"Invalid hex":    "00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+		"Forbidden vers": "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+	for name, header := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseTraceParent(header); err == nil {
+				t.Errorf("Expected error for %q", header)
+			}
+		})
+	}
+}
+
+func TestSlogTracer_ChildSpan(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewSlogTracer(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	_, child := tracer.Start(ctx, "child", String(GenAIRequestModel, "gpt-4"))
+
+	if child.SpanContext().TraceID != parent.SpanContext().TraceID {
+		t.Error("Expected child span to share the parent trace id")
+	}
+	if child.SpanContext().SpanID == parent.SpanContext().SpanID {
+		t.Error("Expected child span to have its own span id")
+	}
+	child.End()
+	parent.End()
+
+	out := buf.String()
+	if strings.Count(out, "trace: span ended") != 2 {
+		t.Errorf("Expected 2 span records, got %s", out)
+	}
+	if !strings.Contains(out, "attributes.gen_ai.request.model=gpt-4") {
+		t.Errorf("Expected GenAI attributes in records, got %s", out)
+	}
+}
+
+func TestNoop_NoTraceParent(t *testing.T) {
+	_, span := Noop().Start(context.Background(), "noop")
+	defer span.End()
+	if tp := span.SpanContext().TraceParent(); tp != "" {
+		t.Errorf("Expected empty traceparent, got %q", tp)
+	}
+}
@@ -0,0 +1,67 @@
+package vectorstore
+
+// Filter selects records by metadata.
+type Filter func(metadata map[string]string) bool
+
+// Eq matches records whose metadata key equals value.
+func Eq(key, value string) Filter {
+	return func(md map[string]string) bool {
+		v, ok := md[key]
+		return ok && v == value
+	}
+}
+
+// In matches records whose metadata key equals any of values.
+func In(key string, values ...string) Filter {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return func(md map[string]string) bool {
+		v, ok := md[key]
+		if !ok {
+			return false
+		}
+		_, ok = set[v]
+		return ok
+	}
+}
+
+// Exists matches records having the metadata key, whatever its value.
+func Exists(key string) Filter {
+	return func(md map[string]string) bool {
+		_, ok := md[key]
+		return ok
+	}
+}
+
+// And matches records accepted by every filter.
+func And(filters ...Filter) Filter {
+	return func(md map[string]string) bool {
+		for _, f := range filters {
+			if !f(md) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches records accepted by at least one filter.
+func Or(filters ...Filter) Filter {
+	return func(md map[string]string) bool {
+		for _, f := range filters {
+			if f(md) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches records rejected by f.
+func Not(f Filter) Filter {
+	return func(md map[string]string) bool {
+		return !f(md)
+	}
+}
@@ -0,0 +1,458 @@
+package vectorstore
+
+import (
+	"cmp"
+	"container/heap"
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+)
+
+// HNSW is a [Store] backed by a Hierarchical Navigable Small World graph,
+// giving approximate nearest neighbors in logarithmic time.
+//
+// Vectors are held in a single contiguous slice and the graph is persisted
+// to a file on [HNSW.Save] and [HNSW.Close]. Deleted and replaced records are
+// tombstoned, [HNSW.Compact] rebuilds the graph without them.
+// It is safe for concurrent use.
+//
+// See https://arxiv.org/abs/1603.09320
+type HNSW struct {
+	mu   sync.RWMutex
+	path string
+
+	metric         Metric
+	dim            int
+	m              int
+	efConstruction int
+	efSearch       int
+	levelMult      float64
+	rng            *rand.Rand
+
+	// vectors of node i are stored at [i*dim:(i+1)*dim]
+	vectors  []float32
+	nodes    []hnswNode
+	ids      map[string]uint32
+	entry    int64
+	maxLevel int
+	live     int
+
+	dirty  bool
+	closed bool
+}
+
+type hnswNode struct {
+	id       string
+	content  string
+	metadata map[string]string
+	// links holds the neighbors of the node for each of its levels
+	links   [][]uint32
+	deleted bool
+}
+
+// Compile type interface assertion
+var _ Store = (*HNSW)(nil)
+
+// HNSWOption configures an [HNSW] store
+type HNSWOption func(*HNSW)
+
+// WithMetric sets the metric used to compare vectors. The default is [Cosine].
+// It is ignored when loading an existing index, which keeps its own metric.
+func WithMetric(metric Metric) HNSWOption {
+	return func(h *HNSW) {
+		h.metric = metric
+	}
+}
+
+// WithM sets the number of neighbors per node, 2*m on the ground layer. The default is 16.
+// Higher values improve recall at the cost of memory.
+func WithM(m int) HNSWOption {
+	return func(h *HNSW) {
+		h.m = max(m, 2)
+	}
+}
+
+// WithEfConstruction sets the candidate list size used while inserting. The default is 200.
+func WithEfConstruction(ef int) HNSWOption {
+	return func(h *HNSW) {
+		h.efConstruction = max(ef, 1)
+	}
+}
+
+// WithEfSearch sets the minimum candidate list size used while querying. The default is 64.
+func WithEfSearch(ef int) HNSWOption {
+	return func(h *HNSW) {
+		h.efSearch = max(ef, 1)
+	}
+}
+
+// OpenHNSW opens the index persisted at path, or creates an empty one if the file does not exist.
+// An empty path creates an index living in memory only.
+func OpenHNSW(path string, opts ...HNSWOption) (*HNSW, error) {
+	h := &HNSW{
+		path:           path,
+		metric:         Cosine,
+		m:              16,
+		efConstruction: 200,
+		efSearch:       64,
+		rng:            rand.New(rand.NewPCG(0x9e3779b97f4a7c15, 0xbf58476d1ce4e5b9)),
+		ids:            make(map[string]uint32),
+		entry:          -1,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if path != "" {
+		err := h.load(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	h.levelMult = 1 / math.Log(float64(h.m))
+	return h, nil
+}
+
+func (h *HNSW) Upsert(_ context.Context, records ...Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return ErrClosed
+	}
+	// The first record fixes the dimension of an empty index, once the whole batch is valid
+	dim := h.dim
+	for _, r := range records {
+		if r.ID == "" {
+			return ErrEmptyID
+		}
+		if dim == 0 {
+			dim = len(r.Vector)
+		}
+		if len(r.Vector) == 0 || len(r.Vector) != dim {
+			return ErrDimensionMismatch
+		}
+	}
+	h.dim = dim
+	for _, r := range records {
+		h.remove(r.ID)
+		h.insert(r)
+	}
+	h.dirty = h.dirty || len(records) > 0
+	return nil
+}
+
+func (h *HNSW) Query(_ context.Context, vector []float32, k int, opts ...QueryOption) ([]Match, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return nil, ErrClosed
+	}
+	if k <= 0 || h.live == 0 {
+		return nil, nil
+	}
+	if len(vector) != h.dim {
+		return nil, ErrDimensionMismatch
+	}
+	o := applyQueryOptions(opts)
+	q := h.metric.prepare(vector)
+
+	ep := uint32(h.entry)
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.greedy(q, ep, l)
+	}
+
+	// Widen the search until enough candidates survive tombstones and filters
+	var matches []Match
+	for ef := max(h.efSearch, k); ; ef *= 2 {
+		matches = matches[:0]
+		for _, c := range h.searchLayer(q, ep, ef, 0) {
+			n := &h.nodes[c.node]
+			if n.deleted {
+				continue
+			}
+			score := h.metric.score(c.dist)
+			if !o.accept(n.metadata, score) {
+				continue
+			}
+			matches = append(matches, Match{
+				Record: Record{
+					ID:       n.id,
+					Vector:   slices.Clone(h.vector(c.node)),
+					Content:  n.content,
+					Metadata: maps.Clone(n.metadata),
+				},
+				Score: score,
+			})
+		}
+		if len(matches) >= k || ef >= len(h.nodes) {
+			break
+		}
+	}
+	SortMatches(matches)
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+func (h *HNSW) Delete(_ context.Context, ids ...string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return ErrClosed
+	}
+	for _, id := range ids {
+		if h.remove(id) {
+			h.dirty = true
+		}
+	}
+	return nil
+}
+
+// Len returns the number of live records.
+func (h *HNSW) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.live
+}
+
+// Compact rebuilds the graph from live records, reclaiming tombstoned nodes.
+func (h *HNSW) Compact() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.live == len(h.nodes) {
+		return
+	}
+	nodes, vectors, dim := h.nodes, h.vectors, h.dim
+	h.nodes = make([]hnswNode, 0, h.live)
+	h.vectors = make([]float32, 0, h.live*dim)
+	h.ids = make(map[string]uint32, h.live)
+	h.entry, h.maxLevel, h.live = -1, 0, 0
+	for i, n := range nodes {
+		if n.deleted {
+			continue
+		}
+		h.insert(Record{
+			ID:       n.id,
+			Content:  n.content,
+			Metadata: n.metadata,
+			Vector:   vectors[i*dim : (i+1)*dim],
+		})
+	}
+	h.dirty = true
+}
+
+// remove tombstones the live node holding id, reporting whether one existed.
+func (h *HNSW) remove(id string) bool {
+	idx, ok := h.ids[id]
+	if !ok {
+		return false
+	}
+	h.nodes[idx].deleted = true
+	delete(h.ids, id)
+	h.live--
+	return true
+}
+
+func (h *HNSW) vector(i uint32) []float32 {
+	return h.vectors[int(i)*h.dim : (int(i)+1)*h.dim]
+}
+
+func (h *HNSW) distance(q []float32, i uint32) float32 {
+	return h.metric.distance(q, h.vector(i))
+}
+
+func (h *HNSW) randomLevel() int {
+	return int(-math.Log(1-h.rng.Float64()) * h.levelMult)
+}
+
+// maxLinks returns the neighbor capacity of a node at level l.
+func (h *HNSW) maxLinks(l int) int {
+	if l == 0 {
+		return 2 * h.m
+	}
+	return h.m
+}
+
+func (h *HNSW) insert(r Record) {
+	idx := uint32(len(h.nodes))
+	q := h.metric.prepare(r.Vector)
+	level := h.randomLevel()
+	h.vectors = append(h.vectors, q...)
+	h.nodes = append(h.nodes, hnswNode{
+		id:       r.ID,
+		content:  r.Content,
+		metadata: maps.Clone(r.Metadata),
+		links:    make([][]uint32, level+1),
+	})
+	h.ids[r.ID] = idx
+	h.live++
+
+	if h.entry < 0 {
+		h.entry, h.maxLevel = int64(idx), level
+		return
+	}
+
+	ep := uint32(h.entry)
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.greedy(q, ep, l)
+	}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(q, ep, h.efConstruction, l)
+		neighbors := h.selectNeighbors(candidates, h.m)
+		links := make([]uint32, len(neighbors))
+		for i, n := range neighbors {
+			links[i] = n.node
+			h.connect(n.node, idx, l)
+		}
+		h.nodes[idx].links[l] = links
+		ep = candidates[0].node
+	}
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = int64(idx), level
+	}
+}
+
+// connect adds a link from node to target at level l, pruning the node links when over capacity.
+func (h *HNSW) connect(node, target uint32, l int) {
+	links := append(h.nodes[node].links[l], target)
+	if len(links) <= h.maxLinks(l) {
+		h.nodes[node].links[l] = links
+		return
+	}
+	v := h.vector(node)
+	candidates := make([]candidate, len(links))
+	for i, n := range links {
+		candidates[i] = candidate{node: n, dist: h.distance(v, n)}
+	}
+	sortCandidates(candidates)
+	selected := h.selectNeighbors(candidates, h.maxLinks(l))
+	links = links[:0]
+	for _, c := range selected {
+		links = append(links, c.node)
+	}
+	h.nodes[node].links[l] = links
+}
+
+// selectNeighbors picks up to m candidates, sorted by increasing distance,
+// preferring ones closer to the base than to already selected neighbors.
+// Remaining slots are filled with the closest discarded candidates.
+func (h *HNSW) selectNeighbors(candidates []candidate, m int) []candidate {
+	if len(candidates) <= m {
+		return candidates
+	}
+	selected := make([]candidate, 0, m)
+	var discarded []candidate
+	for _, c := range candidates {
+		if len(selected) == m {
+			break
+		}
+		good := true
+		for _, s := range selected {
+			if h.distance(h.vector(c.node), s.node) < c.dist {
+				good = false
+				break
+			}
+		}
+		if good {
+			selected = append(selected, c)
+		} else {
+			discarded = append(discarded, c)
+		}
+	}
+	for _, c := range discarded {
+		if len(selected) == m {
+			break
+		}
+		selected = append(selected, c)
+	}
+	return selected
+}
+
+// greedy walks level l towards q from ep, returning the closest node found.
+func (h *HNSW) greedy(q []float32, ep uint32, l int) uint32 {
+	best, bestDist := ep, h.distance(q, ep)
+	for changed := true; changed; {
+		changed = false
+		for _, n := range h.nodes[best].links[l] {
+			if d := h.distance(q, n); d < bestDist {
+				best, bestDist, changed = n, d, true
+			}
+		}
+	}
+	return best
+}
+
+// searchLayer returns up to ef nodes of level l closest to q, sorted by increasing distance.
+func (h *HNSW) searchLayer(q []float32, ep uint32, ef, l int) []candidate {
+	visited := map[uint32]struct{}{ep: {}}
+	start := candidate{node: ep, dist: h.distance(q, ep)}
+	candidates := &minHeap{start}
+	results := &maxHeap{start}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(candidate)
+		if c.dist > (*results)[0].dist && results.Len() >= ef {
+			break
+		}
+		for _, n := range h.nodes[c.node].links[l] {
+			if _, ok := visited[n]; ok {
+				continue
+			}
+			visited[n] = struct{}{}
+			d := h.distance(q, n)
+			if results.Len() < ef || d < (*results)[0].dist {
+				heap.Push(candidates, candidate{node: n, dist: d})
+				heap.Push(results, candidate{node: n, dist: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := []candidate(*results)
+	sortCandidates(out)
+	return out
+}
+
+type candidate struct {
+	node uint32
+	dist float32
+}
+
+func sortCandidates(c []candidate) {
+	slices.SortFunc(c, func(a, b candidate) int {
+		return cmp.Compare(a.dist, b.dist)
+	})
+}
+
+type minHeap []candidate
+
+func (h minHeap) Len() int           { return len(h) }
+func (h minHeap) Less(i, j int) bool { return h[i].dist < h[j].dist }
+func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+type maxHeap []candidate
+
+func (h maxHeap) Len() int           { return len(h) }
+func (h maxHeap) Less(i, j int) bool { return h[i].dist > h[j].dist }
+func (h maxHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *maxHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,311 @@
+package vectorstore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// File layout, all integers are unsigned varints unless stated otherwise:
+//
+//	magic "FYHNSW01"
+//	metric, dim, m, efConstruction, efSearch, maxLevel, entry+1, node count
+//	per node: id, content, metadata count, metadata key/values, deleted flag,
+//	          level count, per level: link count, links
+//	vectors: node count * dim little-endian float32
+const hnswMagic = "FYHNSW01"
+
+// ErrCorruptIndex is returned when an index file cannot be decoded.
+var ErrCorruptIndex = errors.New("vectorstore: corrupt index file")
+
+// Save writes the index to its file, replacing it atomically.
+// It is a no-op for indexes without a path or without changes since the last save.
+func (h *HNSW) Save() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.save()
+}
+
+// Close saves pending changes and releases the index. Further calls return [ErrClosed].
+func (h *HNSW) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil
+	}
+	err := h.save()
+	h.closed = true
+	h.nodes, h.vectors, h.ids = nil, nil, nil
+	return err
+}
+
+func (h *HNSW) save() error {
+	if h.closed {
+		return ErrClosed
+	}
+	if h.path == "" || !h.dirty {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	bw := bufio.NewWriterSize(tmp, 1<<20)
+	if err := h.encode(bw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), h.path); err != nil {
+		return err
+	}
+	h.dirty = false
+	return nil
+}
+
+func (h *HNSW) encode(w *bufio.Writer) error {
+	e := encoder{w: w}
+	e.raw([]byte(hnswMagic))
+	for _, v := range []int{int(h.metric), h.dim, h.m, h.efConstruction, h.efSearch, h.maxLevel, int(h.entry + 1), len(h.nodes)} {
+		e.uvarint(uint64(v))
+	}
+	for _, n := range h.nodes {
+		e.string(n.id)
+		e.string(n.content)
+		e.uvarint(uint64(len(n.metadata)))
+		for k, v := range n.metadata {
+			e.string(k)
+			e.string(v)
+		}
+		if n.deleted {
+			e.uvarint(1)
+		} else {
+			e.uvarint(0)
+		}
+		e.uvarint(uint64(len(n.links)))
+		for _, links := range n.links {
+			e.uvarint(uint64(len(links)))
+			for _, l := range links {
+				e.uvarint(uint64(l))
+			}
+		}
+	}
+	if e.err != nil {
+		return e.err
+	}
+	return binary.Write(w, binary.LittleEndian, h.vectors)
+}
+
+func (h *HNSW) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := h.decode(bufio.NewReaderSize(f, 1<<20), info.Size()); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrCorruptIndex, path, err)
+	}
+	return nil
+}
+
+// decode reads an index of size bytes. Every count and link is checked against the
+// remaining bytes and the node count, so a corrupt file fails here rather than in queries.
+func (h *HNSW) decode(r *bufio.Reader, size int64) error {
+	d := decoder{r: r, remaining: size}
+	magic := make([]byte, len(hnswMagic))
+	d.raw(magic)
+	if d.err == nil && string(magic) != hnswMagic {
+		return errors.New("unknown file format")
+	}
+	header := make([]uint64, 8)
+	for i := range header {
+		header[i] = d.uvarint()
+	}
+	if d.err != nil {
+		return d.err
+	}
+	switch {
+	case header[0] > uint64(L2):
+		return fmt.Errorf("unknown metric %d", header[0])
+	case header[2] < 2 || header[3] < 1 || header[4] < 1:
+		return fmt.Errorf("invalid parameters m=%d efConstruction=%d efSearch=%d", header[2], header[3], header[4])
+	}
+	dim, count, entry := header[1], header[7], int64(header[6])-1
+	// Each node takes at least 5 bytes of links and strings, and its vector
+	if nodeSize := 5 + 4*dim; dim > uint64(d.remaining) || count > uint64(d.remaining)/nodeSize {
+		return fmt.Errorf("%d nodes of dimension %d exceed the %d bytes left", count, dim, d.remaining)
+	}
+	if header[6] > count || (count > 0) != (entry >= 0) {
+		return fmt.Errorf("entry point %d out of the %d nodes", entry, count)
+	}
+	if header[5] > uint64(d.remaining) || (count == 0 && header[5] != 0) {
+		return fmt.Errorf("invalid top level %d", header[5])
+	}
+	h.metric = Metric(header[0])
+	h.dim, h.m, h.efConstruction, h.efSearch = int(dim), int(header[2]), int(header[3]), int(header[4])
+	h.maxLevel, h.entry = int(header[5]), entry
+
+	h.nodes = make([]hnswNode, count)
+	h.ids = make(map[string]uint32, count)
+	h.live = 0
+	for i := range h.nodes {
+		n := &h.nodes[i]
+		n.id = d.string()
+		n.content = d.string()
+		if size := d.count(2); size > 0 {
+			n.metadata = make(map[string]string, size)
+			for range size {
+				k := d.string()
+				n.metadata[k] = d.string()
+			}
+		}
+		switch d.uvarint() {
+		case 0:
+		case 1:
+			n.deleted = true
+		default:
+			d.fail("invalid deleted flag")
+		}
+		n.links = make([][]uint32, d.count(1))
+		for l := range n.links {
+			links := make([]uint32, d.count(1))
+			for j := range links {
+				if links[j] = uint32(d.uvarint()); uint64(links[j]) >= count {
+					d.fail("link to node %d out of the %d nodes", links[j], count)
+				}
+			}
+			n.links[l] = links
+		}
+		if d.err == nil && len(n.links) == 0 {
+			d.fail("node %d has no level", i)
+		}
+		if d.err != nil {
+			return d.err
+		}
+		if !n.deleted {
+			h.ids[n.id] = uint32(i)
+			h.live++
+		}
+	}
+
+	// Searches walk the links of level l from the entry point down, every node reached must have the level
+	if entry >= 0 && len(h.nodes[entry].links) <= h.maxLevel {
+		return fmt.Errorf("entry point %d below the top level %d", entry, h.maxLevel)
+	}
+	for i, n := range h.nodes {
+		for l, links := range n.links {
+			for _, link := range links {
+				if len(h.nodes[link].links) <= l {
+					return fmt.Errorf("node %d links node %d on level %d it does not have", i, link, l)
+				}
+			}
+		}
+	}
+
+	if want := count * dim * 4; want > uint64(d.remaining) {
+		return fmt.Errorf("%d bytes of vectors exceed the %d bytes left", want, d.remaining)
+	}
+	h.vectors = make([]float32, count*dim)
+	return binary.Read(r, binary.LittleEndian, h.vectors)
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+	err error
+}
+
+func (e *encoder) raw(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *encoder) uvarint(v uint64) {
+	n := binary.PutUvarint(e.buf[:], v)
+	e.raw(e.buf[:n])
+}
+
+func (e *encoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s)
+	}
+}
+
+type decoder struct {
+	r *bufio.Reader
+	// remaining is the number of bytes left to read
+	remaining int64
+	err       error
+}
+
+func (d *decoder) fail(format string, args ...any) {
+	if d.err == nil {
+		d.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (d *decoder) raw(b []byte) {
+	if d.err == nil {
+		var n int
+		n, d.err = io.ReadFull(d.r, b)
+		d.remaining -= int64(n)
+	}
+}
+
+// ReadByte implements [io.ByteReader] for reading varints
+func (d *decoder) ReadByte() (byte, error) {
+	b, err := d.r.ReadByte()
+	if err == nil {
+		d.remaining--
+	}
+	return b, err
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	var v uint64
+	v, d.err = binary.ReadUvarint(d)
+	return v
+}
+
+// count reads the number of items following, each taking at least size bytes.
+func (d *decoder) count(size int64) int {
+	v := d.uvarint()
+	if d.err == nil && v > uint64(d.remaining/size) {
+		d.fail("count %d exceeds the %d bytes left", v, d.remaining)
+	}
+	if d.err != nil {
+		return 0
+	}
+	return int(v)
+}
+
+func (d *decoder) string() string {
+	size := d.count(1)
+	if d.err != nil {
+		return ""
+	}
+	b := make([]byte, size)
+	d.raw(b)
+	return string(b)
+}
@@ -0,0 +1,95 @@
+package vectorstore
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+// Memory is an in-memory [Store] comparing the query against every record.
+// It is exact and suited to small collections. It is safe for concurrent use.
+type Memory struct {
+	mu      sync.RWMutex
+	metric  Metric
+	dim     int
+	records map[string]Record
+}
+
+// Compile type interface assertion
+var _ Store = (*Memory)(nil)
+
+// NewMemory returns an empty Memory store comparing vectors with metric.
+func NewMemory(metric Metric) *Memory {
+	return &Memory{
+		metric:  metric,
+		records: make(map[string]Record),
+	}
+}
+
+func (s *Memory) Upsert(_ context.Context, records ...Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// The first record fixes the dimension of an empty store, once the whole batch is valid
+	dim := s.dim
+	for _, r := range records {
+		if r.ID == "" {
+			return ErrEmptyID
+		}
+		if dim == 0 {
+			dim = len(r.Vector)
+		}
+		if len(r.Vector) == 0 || len(r.Vector) != dim {
+			return ErrDimensionMismatch
+		}
+	}
+	s.dim = dim
+	for _, r := range records {
+		r.Vector = s.metric.prepare(r.Vector)
+		r.Metadata = maps.Clone(r.Metadata)
+		s.records[r.ID] = r
+	}
+	return nil
+}
+
+func (s *Memory) Query(_ context.Context, vector []float32, k int, opts ...QueryOption) ([]Match, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if k <= 0 || len(s.records) == 0 {
+		return nil, nil
+	}
+	if len(vector) != s.dim {
+		return nil, ErrDimensionMismatch
+	}
+	o := applyQueryOptions(opts)
+	q := s.metric.prepare(vector)
+
+	matches := make([]Match, 0, min(k, len(s.records)))
+	for _, r := range s.records {
+		score := s.metric.score(s.metric.distance(q, r.Vector))
+		if !o.accept(r.Metadata, score) {
+			continue
+		}
+		matches = append(matches, Match{Record: r, Score: score})
+	}
+	SortMatches(matches)
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+func (s *Memory) Delete(_ context.Context, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.records, id)
+	}
+	return nil
+}
+
+// Len returns the number of records stored.
+func (s *Memory) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.records)
+}
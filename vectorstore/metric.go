@@ -0,0 +1,113 @@
+package vectorstore
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Metric defines how vectors are compared.
+type Metric uint8
+
+const (
+	// Cosine compares vector directions, scores range from -1 to 1
+	Cosine Metric = iota
+	// Dot uses the raw inner product, suited to already normalized embeddings
+	Dot
+	// L2 uses the euclidean distance, scores are the negated distance
+	L2
+)
+
+func (m Metric) String() string {
+	switch m {
+	case Cosine:
+		return "cosine"
+	case Dot:
+		return "dot"
+	case L2:
+		return "l2"
+	default:
+		return fmt.Sprintf("metric(%d)", uint8(m))
+	}
+}
+
+// prepare returns the vector as stored for the metric.
+// Cosine vectors are normalized once, so comparisons reduce to a dot product.
+func (m Metric) prepare(v []float32) []float32 {
+	if m == Cosine {
+		return Normalize(v)
+	}
+	return slices.Clone(v)
+}
+
+// distance returns a dissimilarity between prepared vectors, lower is closer.
+func (m Metric) distance(a, b []float32) float32 {
+	switch m {
+	case L2:
+		var sum float32
+		for i := range a {
+			d := a[i] - b[i]
+			sum += d * d
+		}
+		return sum
+	case Cosine:
+		return 1 - dot(a, b)
+	default:
+		return -dot(a, b)
+	}
+}
+
+// score converts a distance into a similarity score, higher is closer.
+func (m Metric) score(distance float32) float32 {
+	switch m {
+	case L2:
+		return -float32(math.Sqrt(float64(distance)))
+	case Cosine:
+		return 1 - distance
+	default:
+		return -distance
+	}
+}
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// Normalize returns a copy of v scaled to unit length, or a copy of v when it is zero.
+func Normalize(v []float32) []float32 {
+	out := slices.Clone(v)
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return out
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range out {
+		out[i] /= norm
+	}
+	return out
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, from -1 to 1.
+// It is zero when a vector is zero or the dimensions differ.
+func CosineSimilarity(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return float32(dot / math.Sqrt(na*nb))
+}
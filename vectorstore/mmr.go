@@ -0,0 +1,45 @@
+package vectorstore
+
+import "math"
+
+// MMR selects k candidates by maximal marginal relevance, trading similarity to
+// the query against similarity to already selected candidates.
+// lambda weights relevance (1) against diversity (0). Candidates keep their original score.
+//
+// See https://www.cs.cmu.edu/~jgc/publication/The_Use_MMR_Diversity_Based_LTMIR_1998.pdf
+func MMR(query []float32, candidates []Match, k int, lambda float32) []Match {
+	if k >= len(candidates) {
+		return candidates
+	}
+	relevance := make([]float32, len(candidates))
+	for i, c := range candidates {
+		relevance[i] = CosineSimilarity(query, c.Vector)
+	}
+	// redundancy[i] is the highest similarity of candidate i to a selected one
+	redundancy := make([]float32, len(candidates))
+	used := make([]bool, len(candidates))
+	selected := make([]Match, 0, k)
+	for len(selected) < k {
+		best, bestScore := -1, float32(math.Inf(-1))
+		for i := range candidates {
+			if used[i] {
+				continue
+			}
+			score := lambda*relevance[i] - (1-lambda)*redundancy[i]
+			if len(selected) == 0 {
+				score = relevance[i]
+			}
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		used[best] = true
+		selected = append(selected, candidates[best])
+		for i := range candidates {
+			if !used[i] {
+				redundancy[i] = max(redundancy[i], CosineSimilarity(candidates[i].Vector, candidates[best].Vector))
+			}
+		}
+	}
+	return selected
+}
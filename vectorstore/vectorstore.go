@@ -0,0 +1,93 @@
+// Package vectorstore defines storage and similarity search for embedding vectors.
+package vectorstore
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Errors
+var (
+	ErrDimensionMismatch = errors.New("vectorstore: vector dimension mismatch")
+	ErrEmptyID           = errors.New("vectorstore: record id cannot be empty")
+	ErrClosed            = errors.New("vectorstore: store is closed")
+)
+
+// Record is a vector stored with its identifier, source content and metadata.
+// Metadata uses the same shape as [model.Message] metadata so it can flow between both.
+type Record struct {
+	ID       string
+	Vector   []float32
+	Content  string
+	Metadata map[string]string
+}
+
+// Match is a record returned by a query, along with its similarity score.
+// Higher scores are more similar, whatever the metric.
+type Match struct {
+	Record
+	Score float32
+}
+
+// Store persists records and retrieves the nearest ones to a query vector.
+type Store interface {
+	// Upsert inserts records, replacing existing records with the same ID.
+	Upsert(ctx context.Context, records ...Record) error
+	// Query returns at most k records closest to vector, most similar first.
+	Query(ctx context.Context, vector []float32, k int, opts ...QueryOption) ([]Match, error)
+	// Delete removes the records with the given IDs, ignoring unknown ones.
+	Delete(ctx context.Context, ids ...string) error
+}
+
+// QueryOptions contains the parameters of a query
+type QueryOptions struct {
+	// Filter restricts the candidates to records whose metadata it accepts
+	Filter Filter
+	// MinScore drops matches scoring below it, when set
+	MinScore *float32
+}
+
+type QueryOption func(*QueryOptions)
+
+// WithFilter restricts the query to records matching f.
+func WithFilter(f Filter) QueryOption {
+	return func(o *QueryOptions) {
+		o.Filter = f
+	}
+}
+
+// WithMinScore drops matches scoring below score.
+func WithMinScore(score float32) QueryOption {
+	return func(o *QueryOptions) {
+		o.MinScore = &score
+	}
+}
+
+func applyQueryOptions(opts []QueryOption) QueryOptions {
+	var o QueryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// accept reports whether a candidate passes the filter and minimum score.
+func (o QueryOptions) accept(meta map[string]string, score float32) bool {
+	if o.MinScore != nil && score < *o.MinScore {
+		return false
+	}
+	return o.Filter == nil || o.Filter(meta)
+}
+
+// SortMatches orders matches by decreasing score, breaking ties by ID.
+func SortMatches(m []Match) {
+	slices.SortFunc(m, func(a, b Match) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+}
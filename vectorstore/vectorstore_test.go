@@ -0,0 +1,352 @@
+package vectorstore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func stores(t *testing.T, metric Metric) map[string]Store {
+	t.Helper()
+	h, err := OpenHNSW("", WithMetric(metric))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]Store{
+		"Memory": NewMemory(metric),
+		"HNSW":   h,
+	}
+}
+
+func TestStore_UpsertQueryDelete(t *testing.T) {
+	ctx := context.Background()
+	records := []Record{
+		{ID: "a", Vector: []float32{1, 0}, Metadata: map[string]string{"lang": "go"}},
+		{ID: "b", Vector: []float32{0.8, 0.6}, Metadata: map[string]string{"lang": "rust"}},
+		{ID: "c", Vector: []float32{0, 1}, Metadata: map[string]string{"lang": "go"}},
+	}
+	for name, store := range stores(t, Cosine) {
+		t.Run(name, func(t *testing.T) {
+			if err := store.Upsert(ctx, records...); err != nil {
+				t.Fatalf("Upsert() unexpected error: %v", err)
+			}
+
+			matches, err := store.Query(ctx, []float32{1, 0.1}, 2)
+			if err != nil {
+				t.Fatalf("Query() unexpected error: %v", err)
+			}
+			if len(matches) != 2 || matches[0].ID != "a" || matches[1].ID != "b" {
+				t.Errorf("Expected [a b], got %v", ids(matches))
+			}
+
+			matches, _ = store.Query(ctx, []float32{1, 0.1}, 2, WithFilter(Eq("lang", "go")))
+			if len(matches) != 2 || matches[0].ID != "a" || matches[1].ID != "c" {
+				t.Errorf("Expected filtered [a c], got %v", ids(matches))
+			}
+
+			if err := store.Delete(ctx, "a"); err != nil {
+				t.Fatalf("Delete() unexpected error: %v", err)
+			}
+			matches, _ = store.Query(ctx, []float32{1, 0}, 3)
+			if len(matches) != 2 || matches[0].ID != "b" {
+				t.Errorf("Expected [b c] after delete, got %v", ids(matches))
+			}
+
+			// Replacing a record moves it
+			store.Upsert(ctx, Record{ID: "c", Vector: []float32{1, 0}})
+			matches, _ = store.Query(ctx, []float32{1, 0}, 1)
+			if len(matches) != 1 || matches[0].ID != "c" {
+				t.Errorf("Expected replaced [c], got %v", ids(matches))
+			}
+
+			if err := store.Upsert(ctx, Record{ID: "d", Vector: []float32{1, 0, 0}}); err != ErrDimensionMismatch {
+				t.Errorf("Expected ErrDimensionMismatch, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMetric_Scores(t *testing.T) {
+	tests := map[string]struct {
+		metric Metric
+		want   float32
+	}{
+		"Cosine": {metric: Cosine, want: 0.8},
+		"Dot":    {metric: Dot, want: 8},
+		"L2":     {metric: L2, want: -3.6055512},
+	}
+	a, b := []float32{0, 2}, []float32{3, 4}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tt.metric.score(tt.metric.distance(tt.metric.prepare(a), tt.metric.prepare(b)))
+			if diff := got - tt.want; diff > 1e-5 || diff < -1e-5 {
+				t.Errorf("Expected score %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := map[string]struct {
+		a, b []float32
+		want float32
+	}{
+		"Parallel":   {a: []float32{1, 2}, b: []float32{2, 4}, want: 1},
+		"Orthogonal": {a: []float32{1, 0}, b: []float32{0, 3}, want: 0},
+		"Angle":      {a: []float32{0, 2}, b: []float32{3, 4}, want: 0.8},
+		"Zero":       {a: []float32{0, 0}, b: []float32{1, 1}, want: 0},
+		"Mismatch":   {a: []float32{1}, b: []float32{1, 1}, want: 0},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := CosineSimilarity(tt.a, tt.b)
+			if diff := got - tt.want; diff > 1e-5 || diff < -1e-5 {
+				t.Errorf("Expected similarity %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFilters(t *testing.T) {
+	md := map[string]string{"lang": "go", "kind": "doc"}
+	tests := map[string]struct {
+		filter Filter
+		want   bool
+	}{
+		"Eq":        {Eq("lang", "go"), true},
+		"Eq miss":   {Eq("lang", "rust"), false},
+		"In":        {In("lang", "rust", "go"), true},
+		"Exists":    {Exists("kind"), true},
+		"And":       {And(Eq("lang", "go"), Eq("kind", "code")), false},
+		"Or":        {Or(Eq("lang", "rust"), Eq("kind", "doc")), true},
+		"Not":       {Not(Exists("author")), true},
+		"Missing":   {In("author", "me"), false},
+		"Empty And": {And(), true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.filter(md); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHNSW_Recall(t *testing.T) {
+	const (
+		n       = 2000
+		dim     = 16
+		queries = 50
+		k       = 10
+	)
+	ctx := context.Background()
+	rng := rand.New(rand.NewPCG(1, 2))
+	exact := NewMemory(L2)
+	approx, _ := OpenHNSW("", WithMetric(L2))
+
+	records := make([]Record, n)
+	for i := range records {
+		records[i] = Record{ID: strconv.Itoa(i), Vector: randomVector(rng, dim)}
+	}
+	exact.Upsert(ctx, records...)
+	approx.Upsert(ctx, records...)
+
+	var found int
+	for range queries {
+		q := randomVector(rng, dim)
+		want, _ := exact.Query(ctx, q, k)
+		got, _ := approx.Query(ctx, q, k)
+		set := map[string]bool{}
+		for _, m := range want {
+			set[m.ID] = true
+		}
+		for _, m := range got {
+			if set[m.ID] {
+				found++
+			}
+		}
+	}
+	if recall := float64(found) / (queries * k); recall < 0.9 {
+		t.Errorf("Expected recall >= 0.9, got %.2f", recall)
+	}
+}
+
+func TestHNSW_Persistence(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "index.hnsw")
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	h, err := OpenHNSW(path, WithMetric(Dot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 300 {
+		h.Upsert(ctx, Record{
+			ID:       strconv.Itoa(i),
+			Vector:   randomVector(rng, 8),
+			Content:  "chunk " + strconv.Itoa(i),
+			Metadata: map[string]string{"parity": strconv.Itoa(i % 2)},
+		})
+	}
+	h.Delete(ctx, "0", "1")
+	q := randomVector(rng, 8)
+	before, _ := h.Query(ctx, q, 5, WithFilter(Eq("parity", "1")))
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+	if _, err := h.Query(ctx, q, 5); err != ErrClosed {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+
+	reopened, err := OpenHNSW(path)
+	if err != nil {
+		t.Fatalf("OpenHNSW() unexpected error: %v", err)
+	}
+	if reopened.Len() != 298 {
+		t.Errorf("Expected 298 live records, got %d", reopened.Len())
+	}
+	after, _ := reopened.Query(ctx, q, 5, WithFilter(Eq("parity", "1")))
+	if len(after) != len(before) {
+		t.Fatalf("Expected %d matches, got %d", len(before), len(after))
+	}
+	for i := range after {
+		if after[i].ID != before[i].ID || after[i].Content != before[i].Content {
+			t.Errorf("Match %d: expected %s, got %s", i, before[i].ID, after[i].ID)
+		}
+	}
+
+	reopened.Compact()
+	compacted, _ := reopened.Query(ctx, q, 5, WithFilter(Eq("parity", "1")))
+	if len(compacted) != 5 || len(reopened.nodes) != 298 {
+		t.Errorf("Expected compacted index of 298 nodes, got %d", len(reopened.nodes))
+	}
+}
+
+func randomVector(rng *rand.Rand, dim int) []float32 {
+	v := make([]float32, dim)
+	for i := range v {
+		v[i] = rng.Float32()*2 - 1
+	}
+	return v
+}
+
+func ids(m []Match) []string {
+	out := make([]string, len(m))
+	for i := range m {
+		out[i] = m[i].ID
+	}
+	return out
+}
+
+func TestStore_RejectedBatchKeepsDimension(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range stores(t, Cosine) {
+		t.Run(name, func(t *testing.T) {
+			err := s.Upsert(ctx, Record{ID: "a", Vector: []float32{1, 0}}, Record{ID: "b", Vector: []float32{1, 0, 0}})
+			if err != ErrDimensionMismatch {
+				t.Fatalf("Expected ErrDimensionMismatch, got %v", err)
+			}
+			for _, vector := range [][]float32{nil, {}} {
+				if err := s.Upsert(ctx, Record{ID: "empty", Vector: vector}); err != ErrDimensionMismatch {
+					t.Errorf("Expected ErrDimensionMismatch for an empty vector, got %v", err)
+				}
+			}
+			if err := s.Upsert(ctx, Record{ID: "c", Vector: []float32{0, 0, 1}}); err != nil {
+				t.Errorf("Expected the rejected batches not to fix the dimension, got %v", err)
+			}
+			if _, err := s.Query(ctx, []float32{0, 0, 1}, 2); err != nil {
+				t.Errorf("Query() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// savedIndex returns the bytes of a small persisted index
+func savedIndex(t testing.TB) []byte {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "index.hnsw")
+	h, err := OpenHNSW(path, WithM(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewPCG(5, 6))
+	for i := range 20 {
+		h.Upsert(context.Background(), Record{ID: strconv.Itoa(i), Vector: randomVector(rng, 3), Metadata: map[string]string{"k": "v"}})
+	}
+	h.Delete(context.Background(), "3")
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// openCorrupt decodes data as an index file, which must either fail or answer queries.
+func openCorrupt(t *testing.T, data []byte) {
+	h, err := OpenHNSW("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.decode(bufio.NewReader(bytes.NewReader(data)), int64(len(data))); err != nil {
+		return
+	}
+	if _, err := h.Query(context.Background(), make([]float32, h.dim), 5); err != nil {
+		t.Fatalf("Query() unexpected error: %v", err)
+	}
+}
+
+func TestHNSW_CorruptFile(t *testing.T) {
+	data := savedIndex(t)
+	path := filepath.Join(t.TempDir(), "index.hnsw")
+	if err := os.WriteFile(path, data[:len(data)-1], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenHNSW(path); !errors.Is(err, ErrCorruptIndex) {
+		t.Errorf("Expected ErrCorruptIndex for a truncated file, got %v", err)
+	}
+	for n := range len(data) {
+		openCorrupt(t, data[:n])
+	}
+	rng := rand.New(rand.NewPCG(7, 8))
+	for range 500 {
+		corrupt := bytes.Clone(data)
+		corrupt[len(hnswMagic)+rng.IntN(len(corrupt)-len(hnswMagic))] = byte(rng.Uint32())
+		openCorrupt(t, corrupt)
+	}
+}
+
+func FuzzHNSW_Decode(f *testing.F) {
+	data := savedIndex(f)
+	f.Add(data)
+	f.Add(data[:len(data)/2])
+	f.Fuzz(func(t *testing.T, data []byte) {
+		openCorrupt(t, data)
+	})
+}
+
+func TestMMR_Diversity(t *testing.T) {
+	query := []float32{1, 0}
+	candidates := []Match{
+		{Record: Record{ID: "a", Vector: []float32{1, 0}}},
+		{Record: Record{ID: "a-dup", Vector: []float32{0.99, 0.01}}},
+		{Record: Record{ID: "b", Vector: []float32{0.7, 0.7}}},
+	}
+	got := MMR(query, candidates, 2, 0.3)
+	if len(got) != 2 || got[0].ID != "a" || got[1].ID != "b" {
+		t.Errorf("Expected diverse [a b], got [%s %s]", got[0].ID, got[1].ID)
+	}
+
+	got = MMR(query, candidates, 2, 1)
+	if got[1].ID != "a-dup" {
+		t.Errorf("Expected pure relevance to pick a-dup, got %s", got[1].ID)
+	}
+}